@@ -0,0 +1,31 @@
+package ringbuffer
+
+// maxAlign is the largest payload alignment the format word can record
+const maxAlign = 128
+
+// WithPayloadAlignment pads every frame so that its payload starts at an
+// offset that is a multiple of align, a power of two from 2 to 128, for
+// code that reads payloads in place as structs, such as a Reserve buffer
+// or another process mapping the file. Offsets count from the start of the
+// buffer, which is page aligned except in a region whose offset is not a
+// multiple of align. A payload that wraps around the end of the buffer is
+// only aligned up to the end. The padding follows the fixed prefix fields
+// and its size follows from the frame's position, so nothing extra is
+// stored, but each frame takes up to align-1 more bytes and MaxMsgSize
+// shrinks by as much. The alignment is recorded in the file, and opening
+// it with a different one returns ErrFormatMismatch.
+func WithPayloadAlignment(align int) Option {
+	return func(o *options) {
+		o.align = uint32(align)
+	}
+}
+
+// prefixAt returns the size of the prefix of a frame whose length field is
+// at pos, including the padding that aligns the payload
+func (r *RingBuffer) prefixAt(pos uint32) uint32 {
+	if r.opts.align == 0 {
+		return r.prefix
+	}
+	mask := r.opts.align - 1
+	return (pos+r.prefix+mask)&^mask - pos
+}
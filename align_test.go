@@ -0,0 +1,96 @@
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"unsafe"
+)
+
+func TestPayloadAlignment(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"plain", []Option{WithPayloadAlignment(64)}},
+		{"all fields", []Option{WithPayloadAlignment(8), WithFlags(), WithTimestamps(), WithBackLinks(), WithDebugChecks()}},
+		{"no wrap", []Option{WithPayloadAlignment(64), WithNoWrapWrites(), WithStrategy(StrategyCount)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rb, err := NewRingBuffer("/tmp/test_rb_align.mmap", 1000, true, tc.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create ring buffer: %v", err)
+			}
+			defer os.Remove("/tmp/test_rb_align.mmap")
+			defer rb.Close()
+
+			// Odd sizes move head around the buffer many times
+			inPlace := 0
+			for i := 0; i < 200; i++ {
+				n := 1 + i*37%150
+				buf, commit, err := rb.Reserve(n)
+				if err != nil {
+					t.Fatalf("Failed to reserve %d bytes: %v", n, err)
+				}
+				addr := uintptr(unsafe.Pointer(&buf[0]))
+				base := uintptr(unsafe.Pointer(&rb.buf[0]))
+				if addr >= base && addr < base+uintptr(len(rb.buf)) {
+					inPlace++
+					if (addr-base)%uintptr(rb.opts.align) != 0 {
+						t.Fatalf("Payload %d at offset %d is not %d-byte aligned", i, addr-base, rb.opts.align)
+					}
+				}
+				for j := range buf {
+					buf[j] = byte(i + j)
+				}
+				if err := commit(); err != nil {
+					t.Fatalf("Failed to commit: %v", err)
+				}
+
+				msg, err := rb.ReadMsg()
+				if err != nil || len(msg) != n {
+					t.Fatalf("Expected a %d-byte message, got %d (%v)", n, len(msg), err)
+				}
+				for j, b := range msg {
+					if b != byte(i+j) {
+						t.Fatalf("Byte %d of message %d is %d", j, i, b)
+					}
+				}
+			}
+			if inPlace == 0 {
+				t.Errorf("Expected some payloads to be reserved in place")
+			}
+
+			// The largest message still fits in an empty buffer at the start
+			rb.setHead(rb.dataStart)
+			rb.setTail(rb.dataStart)
+			if ok, err := rb.WriteMsg(make([]byte, rb.MaxMsgSize())); !ok || err != nil {
+				t.Errorf("Failed to write MaxMsgSize: %v", err)
+			}
+		})
+	}
+}
+
+func TestPayloadAlignmentOptions(t *testing.T) {
+	for _, align := range []int{1, 3, 256} {
+		if _, err := NewRingBuffer("/tmp/test_rb_align.mmap", 1000, true, WithPayloadAlignment(align)); !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("Expected ErrInvalidSize for alignment %d, got: %v", align, err)
+		}
+	}
+
+	rb, err := NewRingBuffer("/tmp/test_rb_align.mmap", 1000, true, WithPayloadAlignment(16))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_align.mmap")
+	rb.Close()
+
+	if _, err := OpenRingBuffer("/tmp/test_rb_align.mmap", WithPayloadAlignment(32)); !errors.Is(err, ErrIncompatibleOptions) {
+		t.Errorf("Expected ErrIncompatibleOptions for another alignment, got: %v", err)
+	}
+	rb, err = OpenRingBuffer("/tmp/test_rb_align.mmap", WithPayloadAlignment(16))
+	if err != nil {
+		t.Fatalf("Failed to reopen with the same alignment: %v", err)
+	}
+	rb.Close()
+}
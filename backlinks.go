@@ -0,0 +1,78 @@
+package ringbuffer
+
+import "encoding/binary"
+
+// WithBackLinks ends every frame with a 4-byte link back to where it
+// starts, so messages can be walked newest first with ReadMsgReverse. The
+// option is recorded in the file, so OpenRingBuffer may leave it out.
+func WithBackLinks() Option {
+	return func(o *options) {
+		o.backLinks = true
+	}
+}
+
+// ReadMsgReverse returns the buffered messages newest first without
+// consuming them. The first call returns the message just behind head and
+// each further call the one before it, until the oldest message has been
+// returned; the call after that returns ErrBufferEmpty and the next one
+// starts over from the newest message. If readers consume past the cursor
+// meanwhile, it also returns ErrBufferEmpty and starts over.
+// Returns ErrIncompatibleOptions without WithBackLinks.
+func (r *RingBuffer) ReadMsgReverse() ([]byte, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+	if !r.opts.backLinks {
+		return nil, ErrIncompatibleOptions
+	}
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return nil, err
+	}
+	pos := r.reversePos
+	if pos == 0 {
+		if r.full.empty(r, head, tail) {
+			return nil, ErrBufferEmpty
+		}
+		pos = head
+	} else if pos == tail || r.distance(pos, tail) > r.used(head, tail) {
+		// Past the oldest message, or the cursor was consumed
+		r.reversePos = 0
+		return nil, ErrBufferEmpty
+	}
+
+	origin, err := r.linkBefore(pos)
+	if err != nil {
+		r.reversePos = 0
+		return nil, err
+	}
+	f, err := r.frameAt(origin)
+	if err != nil || f.next != pos {
+		r.reversePos = 0
+		return nil, ErrCorruptHeader
+	}
+	msg := make([]byte, f.msgLen)
+	r.copyOut(f.payload(), msg)
+	r.reversePos = r.origin(f)
+	return r.open(msg)
+}
+
+// linkBefore returns the back-link stored in the 4 bytes that end at pos
+func (r *RingBuffer) linkBefore(pos uint32) (uint32, error) {
+	start := pos - 4
+	if pos < r.dataStart+4 {
+		// The link wrapped around the end of the buffer
+		start = uint32(r.size) - (r.dataStart + 4 - pos)
+	}
+	var link [4]byte
+	r.copyOut(start, link[:])
+	origin := binary.LittleEndian.Uint32(link[:])
+	if !r.inData(origin) {
+		return 0, ErrCorruptHeader
+	}
+	return origin, nil
+}
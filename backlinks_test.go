@@ -0,0 +1,104 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestReadMsgReverse(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_reverse.mmap", 1024, true, WithBackLinks())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_reverse.mmap")
+
+	if _, err := rb.ReadMsgReverse(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty on an empty buffer, got: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("message-%d", i))); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+
+	// Twice, since the cursor starts over after the oldest message
+	for round := 0; round < 2; round++ {
+		for i := 4; i >= 0; i-- {
+			readMsg, err := rb.ReadMsgReverse()
+			if err != nil {
+				t.Fatalf("Failed to read message %d in reverse: %v", i, err)
+			}
+			if want := fmt.Sprintf("message-%d", i); string(readMsg) != want {
+				t.Errorf("Reverse message mismatch. Got: %s, Want: %s", string(readMsg), want)
+			}
+		}
+		if _, err := rb.ReadMsgReverse(); err != ErrBufferEmpty {
+			t.Errorf("Expected ErrBufferEmpty after the oldest message, got: %v", err)
+		}
+	}
+
+	// Reverse reads do not consume
+	readMsg, err := rb.ReadMsg()
+	if err != nil || string(readMsg) != "message-0" {
+		t.Errorf("Expected the oldest message from ReadMsg, got: %s (%v)", string(readMsg), err)
+	}
+}
+
+func TestReadMsgReverseWrapAround(t *testing.T) {
+	for _, strategy := range []Strategy{StrategySentinel, StrategyCount} {
+		rb, err := NewRingBuffer("/tmp/test_rb_reverse_wrap.mmap", headerSize+68, true, WithBackLinks(), WithStrategy(strategy))
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+
+		// Keep the buffer full while writing, so frames wrap at every
+		// possible position, and check the newest ones in reverse each time
+		var written []string
+		for i := 0; i < 40; i++ {
+			msg := fmt.Sprintf("m%d", i)
+			for {
+				ok, err := rb.WriteMsg([]byte(msg))
+				if ok && err == nil {
+					break
+				}
+				if err != ErrBufferFull {
+					t.Fatalf("%v: failed to write message %d: %v", strategy, i, err)
+				}
+				if _, err := rb.ReadMsg(); err != nil {
+					t.Fatalf("%v: failed to make room: %v", strategy, err)
+				}
+				written = written[1:]
+			}
+			written = append(written, msg)
+
+			for j := len(written) - 1; j >= 0; j-- {
+				readMsg, err := rb.ReadMsgReverse()
+				if err != nil || string(readMsg) != written[j] {
+					t.Fatalf("%v: reverse message mismatch after write %d. Got: %s (%v), Want: %s", strategy, i, string(readMsg), err, written[j])
+				}
+			}
+			if _, err := rb.ReadMsgReverse(); err != ErrBufferEmpty {
+				t.Fatalf("%v: expected ErrBufferEmpty after the oldest message, got: %v", strategy, err)
+			}
+		}
+
+		rb.Close()
+		os.Remove("/tmp/test_rb_reverse_wrap.mmap")
+	}
+}
+
+func TestReadMsgReverseWithoutOption(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_reverse_noopt.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_reverse_noopt.mmap")
+
+	if _, err := rb.ReadMsgReverse(); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions without WithBackLinks, got: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package ringbuffer
+
+// WriteMsgAll writes msgs as one batch: either all of them or none. The
+// space for the whole batch is checked before anything is copied, and head
+// is advanced once after the last message, so readers never see part of a
+// batch. If the batch does not fit it returns ErrBufferFull and writes
+// nothing; the full policy is not applied and the buffer does not grow. An
+// empty message returns ErrInvalidSize and one larger than MaxMsgSize
+// returns ErrMessageTooLarge, also before anything is written. With
+// WithTimestamps the messages share the time of the call. Returns
+// ErrIncompatibleOptions with WithConcurrentWriters.
+func (r *RingBuffer) WriteMsgAll(msgs [][]byte) error {
+	if r.opts.concurrentWriters {
+		// Head is advanced by claims outside writeMu
+		return ErrIncompatibleOptions
+	}
+	total := 0
+	for _, msg := range msgs {
+		if len(msg) == 0 {
+			return ErrInvalidSize
+		}
+		if len(msg) > r.MaxMsgSize() {
+			return ErrMessageTooLarge
+		}
+		total += len(msg)
+	}
+	if err := r.limitWrite(total); err != nil {
+		return err
+	}
+	sealed := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		var err error
+		if sealed[i], err = r.seal(msg); err != nil {
+			return err
+		}
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if !r.acceptsWrites() {
+		return ErrClosed
+	}
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return err
+	}
+
+	// Place every frame first, so a batch that does not fit leaves no trace
+	frames := make([]frame, len(sealed))
+	pos, used, avail := head, uint32(0), r.free(head, tail, 0)
+	for i, msg := range sealed {
+		f := r.place(pos, uint32(len(msg)))
+		_, _, f.next = r.span(f.payload(), f.msgLen+f.link)
+		if used += f.footprint(); used > avail {
+			r.stats.add(Stats{FullErrors: 1})
+			return ErrBufferFull
+		}
+		frames[i] = f
+		pos = f.next
+	}
+
+	now := r.stamp()
+	written := Stats{Writes: uint64(len(frames))}
+	for i, f := range frames {
+		f.time = now
+		f.seq = r.sequence()
+		r.putLink(f, r.copyIn(f.payload(), sealed[i]))
+		r.putPrefix(f)
+		written.WriteBytes += uint64(f.msgLen)
+	}
+	if len(frames) > 0 {
+		r.advanceHead(pos, used)
+		r.stats.add(written)
+	}
+
+	if r.opts.debugChecks {
+		return r.checkInvariants("write")
+	}
+	return nil
+}
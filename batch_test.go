@@ -0,0 +1,111 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestRingBufferWriteMsgAll(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_batch.mmap", 256, true, WithSequenceNumbers(), WithDebugChecks())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_batch.mmap")
+	defer rb.Close()
+
+	batch := [][]byte{[]byte("one"), []byte("two"), []byte("three")}
+	if err := rb.WriteMsgAll(batch); err != nil {
+		t.Fatalf("Failed to write batch: %v", err)
+	}
+
+	// A batch that only partly fits writes nothing
+	lag := rb.Lag()
+	big := [][]byte{make([]byte, 80), make([]byte, 80), make([]byte, 80)}
+	if err := rb.WriteMsgAll(big); err != ErrBufferFull {
+		t.Errorf("Expected ErrBufferFull, got: %v", err)
+	}
+	if err := rb.WriteMsgAll([][]byte{[]byte("ok"), nil}); err != ErrInvalidSize {
+		t.Errorf("Expected ErrInvalidSize for an empty message, got: %v", err)
+	}
+	if err := rb.WriteMsgAll([][]byte{make([]byte, rb.MaxMsgSize()+1)}); err != ErrMessageTooLarge {
+		t.Errorf("Expected ErrMessageTooLarge, got: %v", err)
+	}
+	if rb.Lag() != lag {
+		t.Errorf("Expected failed batches to leave %d bytes used, got %d", lag, rb.Lag())
+	}
+	if err := rb.WriteMsgAll(nil); err != nil {
+		t.Errorf("Expected an empty batch to succeed, got: %v", err)
+	}
+
+	// The messages and their sequence numbers follow each other
+	for i, want := range batch {
+		msg, seq, gap, err := rb.ReadMsgChecked()
+		if err != nil || !bytes.Equal(msg, want) || seq != uint64(i+1) || gap {
+			t.Errorf("Expected %q with sequence %d, got %q with %d, gap %v (%v)", want, i+1, msg, seq, gap, err)
+		}
+	}
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+}
+
+// TestRingBufferWriteMsgAllAtomic checks that a concurrent reader always
+// finds the rest of a batch once it has seen its first message.
+func TestRingBufferWriteMsgAllAtomic(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_batch.mmap", 1024, true, WithEncryption(testKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_batch.mmap")
+	defer rb.Close()
+
+	const batches, size = 500, 3
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for b := 0; b < batches; {
+			var msgs [][]byte
+			for i := 0; i < size; i++ {
+				msgs = append(msgs, []byte(fmt.Sprintf("%d/%d", b, i)))
+			}
+			err := rb.WriteMsgAll(msgs)
+			if err == ErrBufferFull {
+				runtime.Gosched()
+				continue
+			}
+			if err != nil {
+				t.Errorf("Failed to write batch: %v", err)
+				return
+			}
+			b++
+		}
+	}()
+
+	for b := 0; b < batches; b++ {
+		for i := 0; i < size; i++ {
+			want := fmt.Sprintf("%d/%d", b, i)
+			for {
+				msg, ok, err := rb.TryReadMsg()
+				if err != nil {
+					t.Fatalf("Failed to read message: %v", err)
+				}
+				if ok {
+					if string(msg) != want {
+						t.Fatalf("Expected %q, got %q", want, msg)
+					}
+					break
+				}
+				if i > 0 {
+					t.Fatalf("Batch %d was visible without message %d", b, i)
+				}
+				runtime.Gosched()
+			}
+		}
+	}
+	wg.Wait()
+}
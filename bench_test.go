@@ -0,0 +1,276 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+// benchStrategies are swept by the benchmarks that do not depend on one
+var benchStrategies = []Strategy{StrategySentinel, StrategyCount}
+
+func newBenchBuffer(b *testing.B, size int, opts ...Option) *RingBuffer {
+	b.Helper()
+	filename := "/tmp/test_rb_bench_" + sanitizeBenchName(b.Name()) + ".mmap"
+	rb, err := NewRingBuffer(filename, size, true, opts...)
+	if err != nil {
+		b.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	b.Cleanup(func() {
+		rb.Close()
+		os.Remove(filename)
+	})
+	return rb
+}
+
+// sanitizeBenchName turns sub-benchmark separators into file name characters
+func sanitizeBenchName(name string) string {
+	out := []byte(name)
+	for i, c := range out {
+		if c == '/' || c == '=' {
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
+
+// BenchmarkWriteRead measures a single goroutine writing and reading back
+// one message at a time, for small and large messages.
+func BenchmarkWriteRead(b *testing.B) {
+	for _, s := range benchStrategies {
+		for _, size := range []int{16, 256, 4096} {
+			b.Run(fmt.Sprintf("strategy=%s/msg=%d", s, size), func(b *testing.B) {
+				rb := newBenchBuffer(b, 1<<20, WithStrategy(s))
+				msg := make([]byte, size)
+				b.SetBytes(int64(size))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := rb.WriteMsg(msg); err != nil {
+						b.Fatalf("Failed to write message: %v", err)
+					}
+					if _, err := rb.ReadMsg(); err != nil {
+						b.Fatalf("Failed to read message: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkReadMsgToBuffer compares ReadMsg, which allocates every message,
+// with ReadMsgToBuffer reusing one bytes.Buffer.
+func BenchmarkReadMsgToBuffer(b *testing.B) {
+	for _, mode := range []string{"ReadMsg", "ReadMsgToBuffer"} {
+		b.Run(mode, func(b *testing.B) {
+			rb := newBenchBuffer(b, 1<<20)
+			msg := make([]byte, 1024)
+			var buf bytes.Buffer
+			b.SetBytes(int64(len(msg)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := rb.WriteMsg(msg); err != nil {
+					b.Fatalf("Failed to write message: %v", err)
+				}
+				var err error
+				if mode == "ReadMsg" {
+					_, err = rb.ReadMsg()
+				} else {
+					buf.Reset()
+					_, err = rb.ReadMsgToBuffer(&buf)
+				}
+				if err != nil {
+					b.Fatalf("Failed to read message: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEmptyPoll compares polling an empty buffer with ReadMsg, which
+// takes readMu, with checking IsEmpty first.
+func BenchmarkEmptyPoll(b *testing.B) {
+	b.Run("ReadMsg", func(b *testing.B) {
+		rb := newBenchBuffer(b, 4096)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+				b.Fatalf("Expected ErrBufferEmpty, got: %v", err)
+			}
+		}
+	})
+	b.Run("IsEmpty", func(b *testing.B) {
+		rb := newBenchBuffer(b, 4096)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if !rb.IsEmpty() {
+				b.Fatalf("Expected an empty buffer")
+			}
+		}
+	})
+}
+
+// BenchmarkWrap measures messages sized so that nearly every frame wraps
+// around the end of a small buffer: the data region holds one message but
+// not two.
+func BenchmarkWrap(b *testing.B) {
+	rb := newBenchBuffer(b, headerSize+256)
+	msg := make([]byte, 150)
+	b.SetBytes(int64(len(msg)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := rb.WriteMsg(msg); err != nil {
+			b.Fatalf("Failed to write message: %v", err)
+		}
+		if _, err := rb.ReadMsg(); err != nil {
+			b.Fatalf("Failed to read message: %v", err)
+		}
+	}
+}
+
+// BenchmarkSPSC measures one producer and one consumer goroutine running
+// at the same time, with and without WithSPSCFastPath.
+func BenchmarkSPSC(b *testing.B) {
+	for _, fast := range []bool{false, true} {
+		for _, size := range []int{16, 1024} {
+			b.Run(fmt.Sprintf("fast=%v/msg=%d", fast, size), func(b *testing.B) {
+				benchmarkSPSC(b, fast, size)
+			})
+		}
+	}
+}
+
+func benchmarkSPSC(b *testing.B, fast bool, size int) {
+	opts := []Option{WithFullPolicy(BlockOnFull)}
+	if fast {
+		opts = append(opts, WithSPSCFastPath())
+	}
+	rb := newBenchBuffer(b, 1<<20, opts...)
+	msg := make([]byte, size)
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for read := 0; read < b.N; {
+			if _, err := rb.ReadMsg(); err == nil {
+				read++
+			}
+		}
+	}()
+	for i := 0; i < b.N; i++ {
+		if _, err := rb.WriteMsg(msg); err != nil {
+			b.Errorf("Failed to write message: %v", err)
+			return
+		}
+	}
+	<-done
+}
+
+// BenchmarkMPSC measures four producers sharing one handle, serialized by
+// the write lock or claiming space with WithConcurrentWriters, while one
+// consumer drains the buffer.
+func BenchmarkMPSC(b *testing.B) {
+	const producers = 4
+	for _, concurrent := range []bool{false, true} {
+		b.Run(fmt.Sprintf("concurrent=%v", concurrent), func(b *testing.B) {
+			var opts []Option
+			if concurrent {
+				opts = append(opts, WithConcurrentWriters())
+			}
+			rb := newBenchBuffer(b, 1<<20, opts...)
+			msg := make([]byte, 256)
+			b.SetBytes(int64(len(msg)))
+			b.ResetTimer()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for read := 0; read < b.N; {
+					if _, err := rb.ReadMsg(); err == nil {
+						read++
+					}
+				}
+			}()
+			var wg sync.WaitGroup
+			for p := 0; p < producers; p++ {
+				n := b.N / producers
+				if p == 0 {
+					n += b.N % producers
+				}
+				wg.Add(1)
+				go func(n int) {
+					defer wg.Done()
+					for i := 0; i < n; {
+						if _, err := rb.WriteMsg(msg); err == nil {
+							i++
+						} else if err != ErrBufferFull {
+							b.Errorf("Failed to write message: %v", err)
+							return
+						}
+					}
+				}(n)
+			}
+			wg.Wait()
+			<-done
+		})
+	}
+}
+
+// BenchmarkWakeup measures a round trip between two goroutines that block
+// for each other's message, woken by ReadMsgBlocking's doorbell or by a
+// sync.Cond the writer signals.
+func BenchmarkWakeup(b *testing.B) {
+	for _, doorbell := range []bool{false, true} {
+		b.Run(fmt.Sprintf("doorbell=%v", doorbell), func(b *testing.B) {
+			ping := newBenchBuffer(b, 1<<16)
+			pong := newBenchBuffer(b, 1<<16)
+			var mu sync.Mutex
+			cond := sync.NewCond(&mu)
+			write := func(rb *RingBuffer, msg []byte) {
+				rb.WriteMsg(msg)
+				if !doorbell {
+					mu.Lock()
+					cond.Broadcast()
+					mu.Unlock()
+				}
+			}
+			read := func(rb *RingBuffer) error {
+				if doorbell {
+					_, err := rb.ReadMsgBlocking(context.Background())
+					return err
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for {
+					if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+						return err
+					}
+					cond.Wait()
+				}
+			}
+			msg := make([]byte, 64)
+			b.ResetTimer()
+
+			go func() {
+				for i := 0; i < b.N; i++ {
+					if err := read(ping); err != nil {
+						b.Errorf("Failed to read ping: %v", err)
+						return
+					}
+					write(pong, msg)
+				}
+			}()
+			for i := 0; i < b.N; i++ {
+				write(ping, msg)
+				if err := read(pong); err != nil {
+					b.Fatalf("Failed to read pong: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package ringbuffer
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// ReadMsgBlocking reads a message like ReadMsg, but waits for one while the
+// buffer is empty, until ctx is done or the buffer is closed. Writes through
+// this handle wake it through a one-slot channel, without the mutex handoff
+// of WaitReadableOrWritable; writes from other handles and processes are
+// noticed within blockPollInterval. Returns ctx.Err() if ctx is done first.
+func (r *RingBuffer) ReadMsgBlocking(ctx context.Context) ([]byte, error) {
+	r.readWaiters.Add(1)
+	defer r.readWaiters.Add(-1)
+
+	var timer *time.Timer
+	for {
+		msg, err := r.ReadMsg()
+		if err != ErrBufferEmpty {
+			// The doorbell woke only us; pass it on to the next waiter,
+			// which finds out for itself whether anything is left
+			if r.readWaiters.Load() > 1 {
+				r.ringDoorbell()
+			}
+			return msg, err
+		}
+
+		// A writer that is about to publish often does so if we yield
+		// once, which saves setting up the timer
+		runtime.Gosched()
+		select {
+		case <-r.doorbell:
+			continue
+		default:
+		}
+
+		if timer == nil {
+			timer = time.NewTimer(blockPollInterval)
+			defer timer.Stop()
+		} else {
+			timer.Reset(blockPollInterval)
+		}
+		// A write after ReadMsg sees readWaiters and leaves a token in
+		// the doorbell, so it cannot be missed here
+		select {
+		case <-r.doorbell:
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// ringDoorbell wakes a reader blocked in ReadMsgBlocking, if there is one.
+// It never blocks: a token already in the doorbell has not been taken yet.
+func (r *RingBuffer) ringDoorbell() {
+	if r.readWaiters.Load() == 0 {
+		return
+	}
+	select {
+	case r.doorbell <- struct{}{}:
+	default:
+	}
+}
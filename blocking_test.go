@@ -0,0 +1,128 @@
+package ringbuffer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadMsgBlocking(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_blocking.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_blocking.mmap")
+	defer rb.Close()
+
+	// A buffered message is returned at once
+	if ok, err := rb.WriteMsg([]byte("ready")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if msg, err := rb.ReadMsgBlocking(context.Background()); err != nil || string(msg) != "ready" {
+		t.Errorf("Expected \"ready\", got %q (%v)", msg, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := rb.ReadMsgBlocking(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rb.WriteMsg([]byte("later"))
+	}()
+	start := time.Now()
+	if msg, err := rb.ReadMsgBlocking(context.Background()); err != nil || string(msg) != "later" {
+		t.Errorf("Expected \"later\", got %q (%v)", msg, err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Waited %v for the write", d)
+	}
+}
+
+func TestReadMsgBlockingReaders(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_blocking_readers.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_blocking_readers.mmap")
+	defer rb.Close()
+
+	// Every waiting reader gets one of the messages
+	const readers = 4
+	got := make(chan string, readers)
+	for i := 0; i < readers; i++ {
+		go func() {
+			msg, err := rb.ReadMsgBlocking(context.Background())
+			if err != nil {
+				got <- err.Error()
+				return
+			}
+			got <- string(msg)
+		}()
+	}
+	for rb.readWaiters.Load() < readers {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < readers; i++ {
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("msg %d", i))); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < readers; i++ {
+		seen[<-got] = true
+	}
+	for i := 0; i < readers; i++ {
+		if msg := fmt.Sprintf("msg %d", i); !seen[msg] {
+			t.Errorf("Expected a reader to get %q, got %v", msg, seen)
+		}
+	}
+
+	// Close releases every waiter
+	errs := make(chan error, readers)
+	for i := 0; i < readers; i++ {
+		go func() {
+			_, err := rb.ReadMsgBlocking(context.Background())
+			errs <- err
+		}()
+	}
+	for rb.readWaiters.Load() < readers {
+		time.Sleep(time.Millisecond)
+	}
+	rb.Close()
+	for i := 0; i < readers; i++ {
+		if err := <-errs; err != ErrClosed {
+			t.Errorf("Expected ErrClosed, got: %v", err)
+		}
+	}
+}
+
+func TestReadMsgBlockingOtherHandle(t *testing.T) {
+	const filename = "/tmp/test_rb_blocking_other.mmap"
+	rb, err := NewRingBuffer(filename, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	defer rb.Close()
+	writer, err := OpenRingBuffer(filename)
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	defer writer.Close()
+
+	// Another handle cannot ring the doorbell, so the reader polls
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		writer.WriteMsg([]byte("elsewhere"))
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if msg, err := rb.ReadMsgBlocking(ctx); err != nil || string(msg) != "elsewhere" {
+		t.Errorf("Expected \"elsewhere\", got %q (%v)", msg, err)
+	}
+}
@@ -0,0 +1,49 @@
+package ringbuffer
+
+import "bytes"
+
+// ReadMsgToBuffer appends the next message to b and returns its length,
+// so a caller collecting messages in a bytes.Buffer avoids the allocation
+// ReadMsg makes per message. The message is copied straight from the
+// mapping, except with WithCompression or WithEncryption, where it is
+// decompressed or decrypted first. b is left unchanged on error.
+func (r *RingBuffer) ReadMsgToBuffer(b *bytes.Buffer) (int, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return 0, ErrClosed
+	}
+	if r.opts.groups > 0 {
+		// The tail belongs to the reader groups' Commit
+		return 0, ErrIncompatibleOptions
+	}
+
+	f, err := r.peekFrame()
+	if err != nil {
+		return 0, err
+	}
+	n := int(f.msgLen)
+	if r.opts.sealed() {
+		payload := make([]byte, f.msgLen)
+		r.copyOut(f.payload(), payload)
+		r.consume(f)
+		// A message that fails to decrypt stays consumed, as in readFrame
+		msg, err := r.open(payload)
+		if err != nil {
+			return 0, err
+		}
+		n, _ = b.Write(msg)
+	} else {
+		first, second, _ := r.span(f.payload(), f.msgLen)
+		b.Grow(n)
+		b.Write(first)
+		b.Write(second)
+		r.consume(f)
+	}
+
+	if r.opts.debugChecks {
+		return n, r.checkInvariants("read")
+	}
+	return n, nil
+}
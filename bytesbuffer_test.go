@@ -0,0 +1,49 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestReadMsgToBuffer(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"plain", nil},
+		{"encrypted", []Option{WithEncryption(testKey)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rb, err := NewRingBuffer("/tmp/test_rb_bytesbuffer.mmap", headerSize+224, true, tc.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create ring buffer: %v", err)
+			}
+			defer os.Remove("/tmp/test_rb_bytesbuffer.mmap")
+			defer rb.Close()
+
+			var b bytes.Buffer
+			b.WriteString("kept")
+			if n, err := rb.ReadMsgToBuffer(&b); err != ErrBufferEmpty || n != 0 || b.String() != "kept" {
+				t.Errorf("Expected ErrBufferEmpty and an unchanged buffer, got %d, %q (%v)", n, b.Bytes(), err)
+			}
+
+			// The second message wraps around the end of the buffer, and
+			// each one is appended after what b already holds
+			want := []byte("kept")
+			for _, size := range []int{100, 130} {
+				msg := bytes.Repeat([]byte{byte(size)}, size)
+				if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+					t.Fatalf("Failed to write message: %v", err)
+				}
+				want = append(want, msg...)
+				if n, err := rb.ReadMsgToBuffer(&b); err != nil || n != size || !bytes.Equal(b.Bytes(), want) {
+					t.Errorf("Expected the %d-byte message appended, got %d bytes and %d in total (%v)", size, n, b.Len(), err)
+				}
+			}
+			if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+				t.Errorf("Expected the messages to be consumed, got: %v", err)
+			}
+		})
+	}
+}
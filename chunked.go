@@ -0,0 +1,44 @@
+package ringbuffer
+
+// ReadMsgChunked copies up to len(dst) bytes of the message at the tail
+// into dst, continuing where the previous call left off, so a large message
+// can be read through a small fixed buffer without holding the read lock
+// between pieces. It returns done once the last piece has been copied, and
+// only then consumes the message; a signal is returned as 0 bytes and
+// done. A new message is not started until the current one is done.
+//
+// The progress belongs to the handle: any other read that consumes the
+// message, or a write dropping it under OverwriteOnFull, starts the next
+// call at the beginning of the following message. Returns
+// ErrIncompatibleOptions with reader groups, WithCompression or
+// WithEncryption, whose messages must be read whole.
+func (r *RingBuffer) ReadMsgChunked(dst []byte) (n int, done bool, err error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return 0, false, ErrClosed
+	}
+	if r.opts.groups > 0 || r.opts.sealed() {
+		return 0, false, ErrIncompatibleOptions
+	}
+
+	f, err := r.peekFrame()
+	if err != nil {
+		return 0, false, err
+	}
+	left := f.msgLen - r.chunkOff
+	n = int(min(uint32(len(dst)), left))
+	_, _, pos := r.span(f.payload(), r.chunkOff)
+	r.copyOut(pos, dst[:n])
+	if uint32(n) < left {
+		r.chunkOff += uint32(n)
+		return n, false, nil
+	}
+
+	r.consume(f)
+	if r.opts.debugChecks {
+		return n, true, r.checkInvariants("read")
+	}
+	return n, true, nil
+}
@@ -0,0 +1,111 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestRingBufferReadMsgChunked(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_chunked.mmap", headerSize+224, true, WithFlags(), WithDebugChecks())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_chunked.mmap")
+	defer rb.Close()
+
+	// Move the head close to the end so the next message wraps around
+	if ok, err := rb.WriteMsg(make([]byte, 150)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+
+	msg := make([]byte, 180)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if ok, err := rb.WriteMsgFlags(nil, 7); !ok || err != nil {
+		t.Fatalf("Failed to write signal: %v", err)
+	}
+
+	var got []byte
+	chunk := make([]byte, 16)
+	for i := 0; ; i++ {
+		n, done, err := rb.ReadMsgChunked(chunk)
+		if err != nil {
+			t.Fatalf("Failed to read chunk %d: %v", i, err)
+		}
+		got = append(got, chunk[:n]...)
+		if done {
+			if want := (len(msg) + len(chunk) - 1) / len(chunk); i+1 != want {
+				t.Errorf("Expected %d chunks, got %d", want, i+1)
+			}
+			break
+		}
+		if n != len(chunk) {
+			t.Fatalf("Expected a full chunk before the end, got %d bytes", n)
+		}
+		if lag := rb.Lag(); lag != rb.FrameSize(len(msg))+rb.FrameSize(0) {
+			t.Fatalf("Expected the message to stay buffered until done, lag is %d", lag)
+		}
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("Chunks do not add up to the message")
+	}
+
+	// A signal is done at once with no bytes
+	if n, done, err := rb.ReadMsgChunked(chunk); n != 0 || !done || err != nil {
+		t.Errorf("Expected 0, true, nil for a signal, got %d, %v, %v", n, done, err)
+	}
+	if _, _, err := rb.ReadMsgChunked(chunk); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got %v", err)
+	}
+}
+
+func TestRingBufferReadMsgChunkedInterrupted(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_chunked_interrupted.mmap", 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_chunked_interrupted.mmap")
+	defer rb.Close()
+
+	for _, msg := range []string{"first message", "second message"} {
+		if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	chunk := make([]byte, 5)
+	if n, done, err := rb.ReadMsgChunked(chunk); n != 5 || done || err != nil || string(chunk) != "first" {
+		t.Fatalf("Expected the first 5 bytes, got %q, %v, %v", chunk[:n], done, err)
+	}
+
+	// ReadMsg takes the whole message, so the next chunk starts the second
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "first message" {
+		t.Fatalf("Expected the whole first message, got %q (%v)", msg, err)
+	}
+	if n, done, err := rb.ReadMsgChunked(chunk); n != 5 || done || err != nil || string(chunk) != "secon" {
+		t.Errorf("Expected the start of the second message, got %q, %v, %v", chunk[:n], done, err)
+	}
+}
+
+func TestRingBufferReadMsgChunkedOptions(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_chunked_opts.mmap", 256, true, WithEncryption(testKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_chunked_opts.mmap")
+	defer rb.Close()
+
+	if ok, err := rb.WriteMsg([]byte("secret")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, _, err := rb.ReadMsgChunked(make([]byte, 4)); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions with encryption, got %v", err)
+	}
+}
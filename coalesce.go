@@ -0,0 +1,88 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// WithCoalescing adds a 4-byte repeat count to every frame, so that a
+// message byte-identical to the one the handle wrote last, with flags 0,
+// increments that frame's count instead of taking a new frame while the
+// frame is still unread. ReadMsgRepeated returns the message with its
+// count; ReadMsg and the other reads return it once, dropping the count.
+// A coalesced frame keeps the timestamp and sequence number of its first
+// write. The option is recorded in the file, so OpenRingBuffer may leave
+// it out.
+//
+// The writer takes the read lock to update the count, so a reader on the
+// same handle never loses a repeat; a reader in another handle or process
+// may consume the frame while its count is updated and see the old count.
+// Not available with reader groups, WithConcurrentWriters or
+// WithEncryption, whose payloads differ for identical messages.
+func WithCoalescing() Option {
+	return func(o *options) {
+		o.coalesce = true
+	}
+}
+
+// ReadMsgRepeated reads a message and the number of consecutive writes it
+// stands for. Without WithCoalescing the count is always 1.
+// Returns (msg, count, nil) if successful, (nil, 0, error) if failed
+func (r *RingBuffer) ReadMsgRepeated() ([]byte, int, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, 0, ErrClosed
+	}
+
+	msg, f, err := r.readFrame()
+	if err != nil {
+		return nil, 0, err
+	}
+	if !r.opts.coalesce {
+		return msg, 1, nil
+	}
+	return msg, int(f.repeat), nil
+}
+
+// repeatOffset returns the offset of the repeat count within the prefix
+func (o options) repeatOffset() uint32 {
+	offset := o.sequenceOffset()
+	if o.sequences {
+		offset += 8
+	}
+	return offset
+}
+
+// coalesce counts msg as a repeat of the frame this handle published last
+// and reports whether it did. That frame must still be unread, not be
+// followed by any other, and hold the same payload with flags 0. Caller
+// must hold writeMu.
+func (r *RingBuffer) coalesce(msg []byte) bool {
+	if r.last.next == 0 || r.lastGen != r.Generation() {
+		return false
+	}
+
+	// Frames are consumed oldest first, so while the buffer is not empty
+	// the newest frame is still there; holding readMu keeps it so
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil || head != r.last.next || r.full.empty(r, head, tail) {
+		return false
+	}
+	f, err := r.frameAt(r.origin(r.last))
+	if err != nil || f.next != head || f.flags != 0 || f.msgLen != uint32(len(msg)) || f.repeat == math.MaxUint32 {
+		return false
+	}
+	first, second, _ := r.span(f.payload(), f.msgLen)
+	if !bytes.Equal(first, msg[:len(first)]) || !bytes.Equal(second, msg[len(first):]) {
+		return false
+	}
+	count := f.start + r.opts.repeatOffset()
+	binary.LittleEndian.PutUint32(r.buf[count:count+4], f.repeat+1)
+	return true
+}
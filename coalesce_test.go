@@ -0,0 +1,163 @@
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestCoalescing(t *testing.T) {
+	const name = "/tmp/test_rb_coalesce.mmap"
+	rb, err := NewRingBuffer(name, 1024, true, WithCoalescing(), WithFlags(), WithSequenceNumbers())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(name)
+
+	type repeated struct {
+		msg   string
+		count int
+	}
+	for _, w := range []repeated{{"beat", 3}, {"other", 1}, {"beat", 2}} {
+		for i := 0; i < w.count; i++ {
+			if ok, err := rb.WriteMsg([]byte(w.msg)); !ok || err != nil {
+				t.Fatalf("Failed to write message: %v", err)
+			}
+		}
+	}
+	// Messages with flags are never coalesced
+	for i := 0; i < 2; i++ {
+		if ok, err := rb.WriteMsgFlags([]byte("beat"), 1); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	head, tail := rb.GetHeadTail()
+	if used, want := rb.distance(head, tail), uint32(4*rb.FrameSize(len("beat"))+rb.FrameSize(len("other"))); used != want {
+		t.Errorf("Expected %d bytes used by 5 frames, got %d", want, used)
+	}
+
+	want := []repeated{{"beat", 3}, {"other", 1}, {"beat", 2}, {"beat", 1}, {"beat", 1}}
+	for _, w := range want[:2] {
+		if msg, count, err := rb.ReadMsgRepeated(); err != nil || string(msg) != w.msg || count != w.count {
+			t.Errorf("Expected (%q, %d), got (%q, %d, %v)", w.msg, w.count, msg, count, err)
+		}
+	}
+	// Sequence numbers count frames, not repeats
+	if _, seq, gap, err := rb.ReadMsgChecked(); seq != 3 || gap || err != nil {
+		t.Errorf("Expected sequence 3 without a gap, got (%d, %v, %v)", seq, gap, err)
+	}
+	rb.Close()
+
+	// The frame format is recorded in the file
+	rb, err = OpenRingBuffer(name)
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	defer rb.Close()
+	if !rb.opts.coalesce {
+		t.Errorf("Expected coalescing to be taken from the header")
+	}
+	for _, w := range want[3:] {
+		if msg, count, err := rb.ReadMsgRepeated(); err != nil || string(msg) != w.msg || count != w.count {
+			t.Errorf("Expected (%q, %d), got (%q, %d, %v)", w.msg, w.count, msg, count, err)
+		}
+	}
+
+	// Once its frame is read, a repeat takes a new frame
+	for i := 0; i < 2; i++ {
+		if ok, err := rb.WriteMsg([]byte("beat")); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+		if msg, count, err := rb.ReadMsgRepeated(); err != nil || string(msg) != "beat" || count != 1 {
+			t.Errorf("Expected (\"beat\", 1), got (%q, %d, %v)", msg, count, err)
+		}
+	}
+}
+
+func TestCoalescingWrap(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_coalesce_wrap.mmap", headerSize+64, true, WithCoalescing())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_coalesce_wrap.mmap")
+	defer rb.Close()
+
+	// Move head near the end so the repeated payload wraps around
+	msg := []byte("0123456789abcdef")
+	if ok, err := rb.WriteMsg(make([]byte, 45)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+	if msg, count, err := rb.ReadMsgRepeated(); err != nil || string(msg) != "0123456789abcdef" || count != 10 {
+		t.Errorf("Expected (%q, 10), got (%q, %d, %v)", "0123456789abcdef", msg, count, err)
+	}
+	// ReadMsg returns the message once
+	rb.WriteMsg(msg)
+	rb.WriteMsg(msg)
+	if got, err := rb.ReadMsg(); err != nil || string(got) != string(msg) {
+		t.Errorf("Expected %q, got %q (%v)", msg, got, err)
+	}
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+}
+
+func TestCoalescingDefragment(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_coalesce_defrag.mmap", 1024, true, WithCoalescing())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_coalesce_defrag.mmap")
+	defer rb.Close()
+
+	// Moving the frames keeps their counts, and a repeat after the move
+	// takes a new frame
+	rb.WriteMsg([]byte("first"))
+	rb.ReadMsg()
+	for i := 0; i < 4; i++ {
+		if ok, err := rb.WriteMsg([]byte("beat")); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	if err := rb.Defragment(); err != nil {
+		t.Fatalf("Failed to defragment: %v", err)
+	}
+	rb.WriteMsg([]byte("beat"))
+	for _, want := range []int{4, 1} {
+		if msg, count, err := rb.ReadMsgRepeated(); err != nil || string(msg) != "beat" || count != want {
+			t.Errorf("Expected (\"beat\", %d), got (%q, %d, %v)", want, msg, count, err)
+		}
+	}
+}
+
+func TestCoalescingOptions(t *testing.T) {
+	const name = "/tmp/test_rb_coalesce_options.mmap"
+	defer os.Remove(name)
+	for _, opts := range [][]Option{
+		{WithCoalescing(), WithReaderGroups(1)},
+		{WithCoalescing(), WithConcurrentWriters()},
+		{WithCoalescing(), WithEncryption(make([]byte, 32))},
+		{WithCoalescing(), WithLatestSlot()},
+	} {
+		if _, err := NewRingBuffer(name, 1024, true, opts...); !errors.Is(err, ErrIncompatibleOptions) {
+			t.Errorf("Expected ErrIncompatibleOptions, got %v", err)
+		}
+	}
+
+	// A file without repeat counts cannot be opened for coalescing
+	rb, err := NewRingBuffer(name, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	rb.Close()
+	if _, err := OpenRingBuffer(name, WithCoalescing()); !errors.Is(err, ErrFormatMismatch) {
+		t.Errorf("Expected ErrFormatMismatch, got %v", err)
+	}
+}
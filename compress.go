@@ -0,0 +1,173 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var ErrDecompressFailed = errors.New("message failed to decompress")
+
+// Codec selects how WithCompression compresses messages.
+type Codec uint8
+
+const (
+	// CodecNone stores messages as they are. It also marks a compressed
+	// buffer's frames whose message did not get smaller.
+	CodecNone Codec = iota
+	// CodecLZ4 compresses messages as LZ4 blocks at the fastest level,
+	// for pipelines where latency matters more than ratio.
+	CodecLZ4
+)
+
+// codecOverhead is the codec byte at the start of a compressed buffer's
+// payloads, and all a message can grow by
+const codecOverhead = 1
+
+// estimateSample is how many buffered messages EstimateCompressionRatio
+// compresses at most
+const estimateSample = 64
+
+// String returns the name of the codec.
+func (c Codec) String() string {
+	switch c {
+	case CodecNone:
+		return "none"
+	case CodecLZ4:
+		return "lz4"
+	default:
+		return "unknown"
+	}
+}
+
+// WithCompression compresses every message with c before it is stored,
+// and before WithEncryption encrypts it. Each payload starts with a codec
+// byte, so a message that does not get smaller is stored as it is at the
+// cost of that one byte, which MaxMsgSize accounts for. Readers decompress
+// whatever codec a frame names. A message larger than MaxMsgSize, or with
+// WithAutoGrow than MaxMsgSize at the cap, fails with ErrMessageTooLarge
+// even if it would compress to fit. The buffer records that it is
+// compressed, so OpenRingBuffer may leave the option out, and the codec
+// may differ. Reserve, WriteFramed and ReadMsgStream, which
+// work on stored bytes, return ErrIncompatibleOptions.
+func WithCompression(c Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// compress returns msg as stored with WithCompression: a codec byte, then
+// for CodecLZ4 the message length and the block, or for CodecNone the
+// message itself if compressing would not make it smaller
+func (r *RingBuffer) compress(msg []byte) []byte {
+	return compressWith(r.opts.codec, msg)
+}
+
+// compressWith returns msg as WithCompression(c) would store it
+func compressWith(c Codec, msg []byte) []byte {
+	if c == CodecLZ4 && len(msg) > codecOverhead+4 {
+		out := make([]byte, codecOverhead+4, len(msg))
+		out[0] = byte(CodecLZ4)
+		binary.LittleEndian.PutUint32(out[1:], uint32(len(msg)))
+		if out = lz4Compress(out, msg, len(msg)); out != nil {
+			return out
+		}
+	}
+	return append([]byte{byte(CodecNone)}, msg...)
+}
+
+// EstimateCompressionRatio compresses up to the first 64 buffered
+// messages with c, without consuming them, and returns the size they would
+// be stored at, codec byte included, over their size as they are. A ratio
+// well below 1 means WithCompression(c) would pay off for this workload;
+// incompressible messages give a ratio slightly above 1. Signals are
+// skipped. Returns ErrBufferEmpty if no message is buffered.
+func (r *RingBuffer) EstimateCompressionRatio(c Codec) (float64, error) {
+	if c > CodecLZ4 || r.opts.latest {
+		return 0, ErrIncompatibleOptions
+	}
+
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return 0, ErrClosed
+	}
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return 0, err
+	}
+	var plain, stored int
+	left, pos := r.used(head, tail), tail
+	for n := 0; left > 0 && n < estimateSample; n++ {
+		f, err := r.frameBefore(pos, left)
+		if err != nil {
+			return 0, err
+		}
+		if f.msgLen > 0 {
+			msg := make([]byte, f.msgLen)
+			r.copyOut(f.payload(), msg)
+			if msg, err = r.open(msg); err != nil {
+				return 0, err
+			}
+			plain += len(msg)
+			stored += len(compressWith(c, msg))
+		}
+		left -= f.footprint()
+		pos = f.next
+	}
+	if plain == 0 {
+		return 0, ErrBufferEmpty
+	}
+	return float64(stored) / float64(plain), nil
+}
+
+// decompress returns the message stored as payload with WithCompression
+func (r *RingBuffer) decompress(payload []byte) ([]byte, error) {
+	switch Codec(payload[0]) {
+	case CodecNone:
+		return payload[codecOverhead:], nil
+	case CodecLZ4:
+		if len(payload) < codecOverhead+4 {
+			return nil, ErrDecompressFailed
+		}
+		n := binary.LittleEndian.Uint32(payload[codecOverhead:])
+		if n > uint32(r.msgLimit) {
+			return nil, ErrDecompressFailed
+		}
+		msg, err := lz4Decompress(payload[codecOverhead+4:], int(n))
+		if err != nil {
+			return nil, ErrDecompressFailed
+		}
+		return msg, nil
+	default:
+		return nil, ErrDecompressFailed
+	}
+}
+
+// storedSize returns the length of the message f holds, as ReadMsg would
+// return it. Compressed lengths are read from the payload, which is opened
+// whole if it is also encrypted. Caller must hold readMu.
+func (r *RingBuffer) storedSize(f frame) (uint32, error) {
+	if f.msgLen == 0 {
+		return 0, nil
+	}
+	if r.opts.codec == CodecNone {
+		return f.msgLen - r.opts.sealOverhead(), nil
+	}
+	if r.aead == nil {
+		var head [codecOverhead + 4]byte
+		r.copyOut(f.payload(), head[:min(f.msgLen, uint32(len(head)))])
+		if Codec(head[0]) == CodecNone {
+			return f.msgLen - codecOverhead, nil
+		}
+		if Codec(head[0]) == CodecLZ4 && f.msgLen >= uint32(len(head)) {
+			return binary.LittleEndian.Uint32(head[codecOverhead:]), nil
+		}
+		return 0, ErrDecompressFailed
+	}
+	payload := make([]byte, f.msgLen)
+	r.copyOut(f.payload(), payload)
+	msg, err := r.open(payload)
+	return uint32(len(msg)), err
+}
@@ -0,0 +1,186 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestRingBufferCompression(t *testing.T) {
+	random := make([]byte, 300)
+	rand.New(rand.NewSource(1)).Read(random)
+	compressible := bytes.Repeat([]byte("latency matters "), 60)
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"lz4", []Option{WithCompression(CodecLZ4)}},
+		{"lz4 encrypted", []Option{WithCompression(CodecLZ4), WithEncryption(testKey)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rb, err := NewRingBuffer("/tmp/test_rb_compress.mmap", headerSize+1000, true, append(tc.opts, WithDebugChecks())...)
+			if err != nil {
+				t.Fatalf("Failed to create ring buffer: %v", err)
+			}
+			defer os.Remove("/tmp/test_rb_compress.mmap")
+			defer rb.Close()
+
+			// Nearly as large as the buffer, but it compresses to a fraction
+			if ok, err := rb.WriteMsg(compressible); !ok || err != nil {
+				t.Fatalf("Failed to write compressible message: %v", err)
+			}
+			if lag := rb.Lag(); lag >= len(compressible)/4 {
+				t.Errorf("Expected the message to be stored compressed, it takes %d bytes", lag)
+			}
+
+			// Random bytes are stored as they are, one codec byte larger
+			lag := rb.Lag()
+			if ok, err := rb.WriteMsg(random); !ok || err != nil {
+				t.Fatalf("Failed to write incompressible message: %v", err)
+			}
+			if grew := rb.Lag() - lag; grew != rb.FrameSize(len(random)) {
+				t.Errorf("Expected the incompressible message to take %d bytes, it takes %d", rb.FrameSize(len(random)), grew)
+			}
+			if ok, err := rb.WriteMsg([]byte("tiny")); !ok || err != nil {
+				t.Fatalf("Failed to write short message: %v", err)
+			}
+
+			want := [][]byte{compressible, random, []byte("tiny")}
+			if sizes, err := rb.PendingSizes(3); err != nil || len(sizes) != 3 || sizes[0] != len(want[0]) || sizes[1] != len(want[1]) || sizes[2] != len(want[2]) {
+				t.Errorf("Expected the uncompressed sizes, got %v (%v)", sizes, err)
+			}
+			if length, _, err := rb.PeekHeader(); err != nil || int(length) != len(compressible) {
+				t.Errorf("Expected PeekHeader to report %d bytes, got %d (%v)", len(compressible), length, err)
+			}
+			for _, msg := range want {
+				if got, err := rb.ReadMsg(); err != nil || !bytes.Equal(got, msg) {
+					t.Errorf("Expected the %d-byte message back, got %d bytes (%v)", len(msg), len(got), err)
+				}
+			}
+		})
+	}
+}
+
+func TestRingBufferCompressionOptions(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_compress.mmap", 256, true, WithCompression(CodecLZ4))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_compress.mmap")
+	defer rb.Close()
+
+	if want := MaxMsgSizeFor(256) - codecOverhead; rb.MaxMsgSize() != want {
+		t.Errorf("Expected MaxMsgSize %d, got %d", want, rb.MaxMsgSize())
+	}
+	if _, _, err := rb.Reserve(10); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions from Reserve, got: %v", err)
+	}
+	if _, err := rb.WriteFramed([]byte("\x02\x00\x00\x00hi")); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions from WriteFramed, got: %v", err)
+	}
+	if other, err := OpenRingBuffer("/tmp/test_rb_compress.mmap"); err != nil || other.opts.codec == CodecNone {
+		t.Errorf("Expected compression to be taken from the header, got: %v", err)
+	} else {
+		other.Close()
+	}
+	if _, err := NewRingBuffer("/tmp/test_rb_compress_bad.mmap", 256, true, WithCompression(Codec(9))); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions for an unknown codec, got: %v", err)
+	}
+
+	// A message too large for the buffer is refused even if it compresses
+	// small enough to fit
+	if ok, err := rb.WriteMsg(make([]byte, rb.MaxMsgSize()+1)); ok || err != ErrMessageTooLarge {
+		t.Errorf("Expected ErrMessageTooLarge for a message of MaxMsgSize()+1 bytes, got: %v", err)
+	}
+	if ok, err := rb.WriteMsg(make([]byte, rb.MaxMsgSize())); !ok || err != nil {
+		t.Fatalf("Failed to write a message of MaxMsgSize() bytes: %v", err)
+	}
+	if msg, err := rb.ReadMsg(); err != nil || len(msg) != rb.MaxMsgSize() {
+		t.Fatalf("Expected the %d-byte message back, got %d bytes (%v)", rb.MaxMsgSize(), len(msg), err)
+	}
+
+	// A frame naming an unknown codec fails to read and is skipped
+	if ok, err := rb.WriteMsg([]byte("first")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if ok, err := rb.WriteMsg([]byte("second")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	_, tail := rb.GetHeadTail()
+	rb.buf[tail+rb.prefix] = 9
+	if _, err := rb.ReadMsg(); err != ErrDecompressFailed {
+		t.Errorf("Expected ErrDecompressFailed, got: %v", err)
+	}
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "second" {
+		t.Errorf("Expected the next message, got %q (%v)", msg, err)
+	}
+}
+
+func TestRingBufferCompressionSplice(t *testing.T) {
+	src, err := NewRingBuffer("/tmp/test_rb_compress_src.mmap", 4096, true, WithCompression(CodecLZ4))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_compress_src.mmap")
+	defer src.Close()
+	dst, err := NewRingBuffer("/tmp/test_rb_compress_dst.mmap", 256, true, WithCompression(CodecLZ4))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_compress_dst.mmap")
+	defer dst.Close()
+
+	// The compressed frame fits in dst, but the message does not
+	if ok, err := src.WriteMsg(make([]byte, 2000)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if n, err := src.Splice(dst, 1); n != 0 || err != ErrMessageTooLarge {
+		t.Errorf("Expected ErrMessageTooLarge moving nothing, got %d, %v", n, err)
+	}
+	if msg, err := src.ReadMsg(); err != nil || len(msg) != 2000 {
+		t.Errorf("Expected the message to stay in the source, got %d bytes (%v)", len(msg), err)
+	}
+}
+
+func TestEstimateCompressionRatio(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_compress_estimate.mmap", 4096, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_compress_estimate.mmap")
+	defer rb.Close()
+
+	if _, err := rb.EstimateCompressionRatio(CodecLZ4); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+
+	compressible := bytes.Repeat([]byte("latency matters "), 20)
+	for i := 0; i < 5; i++ {
+		if ok, err := rb.WriteMsg(compressible); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	ratio, err := rb.EstimateCompressionRatio(CodecLZ4)
+	if err != nil || ratio >= 0.25 {
+		t.Errorf("Expected a ratio below 0.25, got %v (%v)", ratio, err)
+	}
+	if lag := rb.Lag(); lag != 5*rb.FrameSize(len(compressible)) {
+		t.Errorf("Expected the messages to stay buffered, lag is %d", lag)
+	}
+
+	// Random bytes do not compress and only gain the codec byte
+	rb.Consume(5)
+	random := make([]byte, 300)
+	rand.New(rand.NewSource(1)).Read(random)
+	if ok, err := rb.WriteMsg(random); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if ratio, err := rb.EstimateCompressionRatio(CodecLZ4); err != nil || ratio != 301.0/300 {
+		t.Errorf("Expected a ratio of %v, got %v (%v)", 301.0/300, ratio, err)
+	}
+	if _, err := rb.EstimateCompressionRatio(Codec(9)); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions, got: %v", err)
+	}
+}
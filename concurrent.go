@@ -0,0 +1,90 @@
+package ringbuffer
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// WithConcurrentWriters lets writers in this process copy their messages in
+// parallel instead of one at a time. Each WriteMsg claims space by advancing
+// an in-memory claim counter with compare-and-swap, copies its message
+// without a lock, then waits until all earlier claims are published and
+// advances the head past its own frame, so readers see messages in claim
+// order. Only one RingBuffer handle may write to the file.
+//
+// Concurrent writers require StrategySentinel and FailOnFull, and WriteFramed
+// and Splice into the buffer return ErrIncompatibleOptions.
+func WithConcurrentWriters() Option {
+	return func(o *options) {
+		o.concurrentWriters = true
+	}
+}
+
+// writeClaimed writes msg through a claim. It holds writeMu shared, so Close
+// and Quiesce still wait for it.
+func (r *RingBuffer) writeClaimed(msg []byte, flags byte, res *Reservation) (bool, error) {
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+
+	if !r.acceptsWrites() {
+		return false, ErrClosed
+	}
+	msgLen := uint32(len(msg))
+	if msgLen > r.maxPayload() {
+		return false, ErrMessageTooLarge
+	}
+
+	// Claim the frame
+	var f frame
+	var pos uint32
+	for {
+		head, tail, err := r.loadHeadTail()
+		if err != nil {
+			return false, err
+		}
+		// The claim carries a sequence number in its upper half, so a
+		// position reused after a full lap cannot be mistaken for ours
+		claim := r.claimed.Load()
+		if pos = uint32(claim); pos == 0 {
+			// Nothing claimed yet, start at head
+			pos = head
+		}
+		if f, err = r.fit(pos, tail, msgLen, res.credit()); err != nil {
+			return false, err
+		}
+		_, _, f.next = r.span(f.payload(), msgLen+f.link)
+		if r.claimed.CompareAndSwap(claim, (claim>>32+1)<<32|uint64(f.next)) {
+			break
+		}
+	}
+	res.draw(f.footprint())
+
+	// Fill it in; readers cannot see it before head moves past it
+	f.flags = flags
+	f.time = r.stamp()
+	r.putLink(f, r.copyIn(f.payload(), msg))
+
+	// Publish in claim order: the frame before ours ends at pos. The
+	// sequence number is taken in the same order.
+	for atomic.LoadUint32(r.headPtr()) != pos {
+		runtime.Gosched()
+	}
+	f.seq = r.sequence()
+	r.putPrefix(f)
+	atomic.StoreUint32(r.headPtr(), f.next)
+	r.full.produced(r, f.footprint())
+	if r.opts.futex {
+		r.futexWake()
+	}
+	r.ringDoorbell()
+	if r.waiters.Load() > 0 {
+		r.notifySpace()
+	}
+
+	if r.opts.debugChecks {
+		if err := r.checkInvariants("write"); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
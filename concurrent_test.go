@@ -0,0 +1,100 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentWriters(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_concurrent_writers.mmap", 4096, true, WithConcurrentWriters())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_concurrent_writers.mmap")
+
+	const numWriters = 16
+	const numMessages = 500
+
+	// Each message repeats its header so torn copies are detected
+	message := func(w, i int) []byte {
+		return bytes.Repeat([]byte(fmt.Sprintf("w%02d-m%04d;", w, i)), 1+i%7)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWriters; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < numMessages; i++ {
+				for {
+					ok, err := rb.WriteMsg(message(w, i))
+					if ok && err == nil {
+						break
+					}
+					if err != ErrBufferFull {
+						t.Errorf("Unexpected write error: %v", err)
+						return
+					}
+					time.Sleep(time.Microsecond)
+				}
+			}
+		}(w)
+	}
+
+	// Messages of one writer arrive in order
+	next := make([]int, numWriters)
+	deadline := time.Now().Add(20 * time.Second)
+	for read := 0; read < numWriters*numMessages; {
+		msg, err := rb.ReadMsg()
+		if err == ErrBufferEmpty {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timeout waiting for messages. Read %d/%d", read, numWriters*numMessages)
+			}
+			time.Sleep(time.Microsecond)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+		var w, i int
+		if _, err := fmt.Sscanf(string(msg), "w%02d-m%04d;", &w, &i); err != nil || w >= numWriters {
+			t.Fatalf("Malformed message %q", msg)
+		}
+		if i != next[w] || !bytes.Equal(msg, message(w, i)) {
+			t.Fatalf("Writer %d: got message %d (%q), want message %d", w, i, msg, next[w])
+		}
+		next[w]++
+		read++
+	}
+	wg.Wait()
+
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty after reading everything, got: %v", err)
+	}
+}
+
+func TestConcurrentWritersOptions(t *testing.T) {
+	_, err := NewRingBuffer("/tmp/test_rb_concurrent_opts.mmap", 1024, true, WithConcurrentWriters(), WithStrategy(StrategyCount))
+	if err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions with StrategyCount, got: %v", err)
+	}
+	_, err = NewRingBuffer("/tmp/test_rb_concurrent_opts.mmap", 1024, true, WithConcurrentWriters(), WithFullPolicy(BlockOnFull))
+	if err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions with BlockOnFull, got: %v", err)
+	}
+
+	rb, err := NewRingBuffer("/tmp/test_rb_concurrent_opts.mmap", 1024, true, WithConcurrentWriters())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_concurrent_opts.mmap")
+	if _, err := rb.WriteFramed(frameRecords("x")); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions from WriteFramed, got: %v", err)
+	}
+}
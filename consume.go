@@ -0,0 +1,62 @@
+package ringbuffer
+
+// Consume discards the next n messages without copying them, for a reader
+// that has already inspected them and only needs to acknowledge them. Each
+// frame boundary is validated on the way. If fewer than n messages are
+// buffered it returns ErrBufferEmpty and discards nothing. Returns
+// ErrIncompatibleOptions with reader groups, which acknowledge with Commit.
+func (r *RingBuffer) Consume(n int) error {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+	if n < 0 {
+		return ErrInvalidSize
+	}
+	if r.opts.groups > 0 {
+		// The tail belongs to the reader groups' Commit
+		return ErrIncompatibleOptions
+	}
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return err
+	}
+	left, pos, total := r.used(head, tail), tail, uint32(0)
+	read := Stats{Reads: uint64(n)}
+	for i := 0; i < n; i++ {
+		if left == 0 {
+			return ErrBufferEmpty
+		}
+		f, err := r.frameBefore(pos, left)
+		if err != nil {
+			return err
+		}
+		left -= f.footprint()
+		total += f.footprint()
+		pos = f.next
+		read.ReadBytes += uint64(f.msgLen)
+	}
+	if n == 0 {
+		return nil
+	}
+	r.stats.add(read)
+	return r.release(pos, total)
+}
+
+// release moves the tail to pos, freeing the total bytes of the frames
+// before it at once, as consume does for one. Caller must hold readMu.
+func (r *RingBuffer) release(pos, total uint32) error {
+	r.setTail(pos)
+	// pos may be past the head the reader cached, see WithSPSCFastPath
+	r.headCache = 0
+	r.chunkOff = 0
+	r.full.consumed(r, total)
+	r.notifyFreed()
+	if r.opts.debugChecks {
+		return r.checkInvariants("consume")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestRingBufferConsume(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_consume.mmap", headerSize+224, true, WithStrategy(StrategyCount))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_consume.mmap")
+	defer rb.Close()
+
+	// Wrap the head so the consumed frames straddle the end
+	if ok, err := rb.WriteMsg(make([]byte, 150)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if err := rb.Consume(1); err != nil {
+		t.Fatalf("Failed to consume message: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("message-%d", i))); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+
+	// Asking for more than is buffered discards nothing
+	if err := rb.Consume(6); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty consuming 6 of 5, got: %v", err)
+	}
+	if err := rb.Consume(3); err != nil {
+		t.Fatalf("Failed to consume 3 messages: %v", err)
+	}
+	for i := 3; i < 5; i++ {
+		msg, err := rb.ReadMsg()
+		if want := fmt.Sprintf("message-%d", i); err != nil || string(msg) != want {
+			t.Errorf("Expected %q, got %q (%v)", want, msg, err)
+		}
+	}
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty after the last message, got: %v", err)
+	}
+	if err := rb.Consume(0); err != nil {
+		t.Errorf("Expected consuming nothing to succeed, got: %v", err)
+	}
+}
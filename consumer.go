@@ -0,0 +1,142 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// OffsetStore keeps a read offset in a small file, replaced atomically and
+// synced on every Save, so a consumer can resume after a crash.
+type OffsetStore struct {
+	path string
+}
+
+// NewOffsetStore returns a store keeping its offset in the file at path.
+// Nothing is written until Save.
+func NewOffsetStore(path string) *OffsetStore {
+	return &OffsetStore{path: path}
+}
+
+// Save durably records offset, writing a temporary file and renaming it
+// over the old one so a crash leaves either the old or the new offset.
+func (s *OffsetStore) Save(offset uint32) error {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("ringbuffer: open %s: %w", tmp, err)
+	}
+	_, err = f.Write(binary.LittleEndian.AppendUint32(nil, offset))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("ringbuffer: write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("ringbuffer: rename %s: %w", tmp, err)
+	}
+	return nil
+}
+
+// Load returns the saved offset. If nothing was saved yet the error
+// matches os.ErrNotExist.
+func (s *OffsetStore) Load() (uint32, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return 0, fmt.Errorf("ringbuffer: read %s: %w", s.path, err)
+	}
+	if len(data) != 4 {
+		return 0, fmt.Errorf("ringbuffer: offset file %s has %d bytes, want 4: %w", s.path, len(data), ErrInvalidOffset)
+	}
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+// PersistentConsumer reads a RingBuffer with at-least-once delivery: Next
+// returns the message at the read offset without consuming it, and Commit
+// saves the offset after it to an OffsetStore before consuming it. After a
+// crash, NewPersistentConsumer resumes from the saved offset, so a message
+// is only delivered again if it was not committed. It is not safe for
+// concurrent use and should be the buffer's only reader.
+type PersistentConsumer struct {
+	rb      *RingBuffer
+	store   *OffsetStore
+	next    uint32 // offset after the message Next returned
+	pending bool   // Next returned a message that is not committed yet
+}
+
+// NewPersistentConsumer returns a consumer of rb checkpointing to store,
+// first moving the read offset to the saved one if there is one. A saved
+// offset that is no longer buffered, because reads went on past it
+// without this consumer, returns ErrInvalidOffset.
+func NewPersistentConsumer(rb *RingBuffer, store *OffsetStore) (*PersistentConsumer, error) {
+	offset, err := store.Load()
+	if err == nil {
+		err = rb.SetReadOffset(offset)
+	} else if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &PersistentConsumer{rb: rb, store: store}, nil
+}
+
+// Next returns the message at the read offset without consuming it, so
+// calling it again before Commit returns the same message. A message that
+// fails to decrypt returns ErrDecryptFailed and can be skipped with Commit.
+func (c *PersistentConsumer) Next() ([]byte, error) {
+	msg, next, err := c.rb.peekMsg()
+	if err != nil && err != ErrDecryptFailed {
+		return nil, err
+	}
+	c.next, c.pending = next, true
+	return msg, err
+}
+
+// Commit records that the message returned by Next was processed: the
+// offset after it is saved to the store, then the message is consumed.
+// Without a message from Next it does nothing.
+func (c *PersistentConsumer) Commit() error {
+	if !c.pending {
+		return nil
+	}
+	if err := c.store.Save(c.next); err != nil {
+		return err
+	}
+	if err := c.rb.SetReadOffset(c.next); err != nil {
+		return err
+	}
+	c.pending = false
+	return nil
+}
+
+// peekMsg returns the message at tail and the offset after its frame
+// without consuming it. A message that fails to decrypt returns
+// ErrDecryptFailed together with the offset, so it can be skipped.
+func (r *RingBuffer) peekMsg() ([]byte, uint32, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, 0, ErrClosed
+	}
+	if r.opts.groups > 0 {
+		// The tail belongs to the reader groups' Commit
+		return nil, 0, ErrIncompatibleOptions
+	}
+
+	f, err := r.peekFrame()
+	if err != nil {
+		return nil, 0, err
+	}
+	msg := make([]byte, f.msgLen)
+	r.copyOut(f.payload(), msg)
+	msg, err = r.open(msg)
+	return msg, f.next, err
+}
@@ -0,0 +1,100 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestPersistentConsumer(t *testing.T) {
+	const name = "/tmp/test_rb_consumer.mmap"
+	const offsets = "/tmp/test_rb_consumer.offset"
+	os.Remove(offsets)
+	defer os.Remove(offsets)
+
+	rb, err := NewRingBuffer(name, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(name)
+	for i := 0; i < 5; i++ {
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("m%d", i))); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	rb.Close()
+
+	// open maps the file privately, so only the store carries progress
+	// from one run to the next
+	store := NewOffsetStore(offsets)
+	open := func() (*RingBuffer, *PersistentConsumer) {
+		rb, err := OpenRingBuffer(name, WithMapMode(MapPrivate))
+		if err != nil {
+			t.Fatalf("Failed to open ring buffer: %v", err)
+		}
+		c, err := NewPersistentConsumer(rb, store)
+		if err != nil {
+			t.Fatalf("Failed to create consumer: %v", err)
+		}
+		return rb, c
+	}
+	expect := func(c *PersistentConsumer, want string) {
+		t.Helper()
+		if msg, err := c.Next(); err != nil || string(msg) != want {
+			t.Fatalf("Expected %q, got %q (%v)", want, msg, err)
+		}
+	}
+
+	rb, c := open()
+	expect(c, "m0")
+	expect(c, "m0")
+	if err := c.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	expect(c, "m1")
+	if err := c.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	// Crash after reading m2 but before committing it
+	expect(c, "m2")
+	rb.Close()
+
+	rb, c = open()
+	expect(c, "m2")
+	if err := c.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	expect(c, "m3")
+	rb.Close()
+
+	rb, c = open()
+	defer rb.Close()
+	expect(c, "m3")
+}
+
+func TestOffsetStore(t *testing.T) {
+	const path = "/tmp/test_rb_offset_store.offset"
+	os.Remove(path)
+	defer os.Remove(path)
+
+	store := NewOffsetStore(path)
+	if _, err := store.Load(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist before Save, got: %v", err)
+	}
+	for _, offset := range []uint32{24, 1 << 20} {
+		if err := store.Save(offset); err != nil {
+			t.Fatalf("Failed to save: %v", err)
+		}
+		if got, err := store.Load(); err != nil || got != offset {
+			t.Errorf("Expected %d, got %d (%v)", offset, got, err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Errorf("Expected an error for a truncated offset file")
+	}
+}
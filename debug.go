@@ -0,0 +1,44 @@
+package ringbuffer
+
+import "fmt"
+
+// WithDebugChecks verifies the head and tail after every WriteMsg and
+// ReadMsg (including the flags variants): both must point into the data
+// region and the tail must sit on a frame whose length is plausible and
+// which ends no later than the head. A violation is returned as
+// ErrCorruptHeader; the operation itself has already taken effect by then.
+// The checks cost an extra header and frame read per call and are meant for
+// catching corruption early in testing, not for production use.
+func WithDebugChecks() Option {
+	return func(o *options) {
+		o.debugChecks = true
+	}
+}
+
+// checkInvariants verifies that tail points at a whole frame that fits in
+// the used part of the buffer. It needs writeMu or readMu so that the frame
+// at tail is not overwritten while it is inspected.
+func (r *RingBuffer) checkInvariants(op string) error {
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return fmt.Errorf("ringbuffer: after %s: head or tail outside data region: %w", op, err)
+	}
+	used := r.used(head, tail)
+	if used == 0 {
+		return nil
+	}
+	// A reader may have consumed the frame meanwhile; it checks the new
+	// tail itself
+	if _, now := r.headTail(); now != tail {
+		return nil
+	}
+
+	f, err := r.frameAt(tail)
+	if err != nil {
+		return fmt.Errorf("ringbuffer: after %s: no valid frame at tail %d: %w", op, tail, err)
+	}
+	if f.footprint() > used {
+		return fmt.Errorf("ringbuffer: after %s: frame at tail %d (%d bytes) runs past head %d: %w", op, tail, f.footprint(), head, ErrCorruptHeader)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestDebugChecks(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		var opts []Option
+		if debug {
+			opts = append(opts, WithDebugChecks())
+		}
+		rb, err := NewRingBuffer("/tmp/test_rb_debug.mmap", 256, true, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+
+		for _, msg := range []string{"hello", "world", "again"} {
+			if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+				t.Fatalf("Failed to write message: %v", err)
+			}
+		}
+		if _, err := rb.ReadMsg(); err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+
+		// Move the tail into the middle of a frame
+		_, tail := rb.GetHeadTail()
+		rb.setTail(tail + 2)
+
+		_, err = rb.WriteMsg([]byte("more"))
+		if debug && !errors.Is(err, ErrCorruptHeader) {
+			t.Errorf("Expected ErrCorruptHeader from WriteMsg with debug checks, got: %v", err)
+		}
+		if !debug && err != nil {
+			t.Errorf("Expected no check without debug checks, got: %v", err)
+		}
+
+		rb.Close()
+		os.Remove("/tmp/test_rb_debug.mmap")
+	}
+}
@@ -0,0 +1,74 @@
+package ringbuffer
+
+// WithDedup drops a message if keyFn returns the same key for it as for
+// one of the last window messages written through this handle, so a
+// producer that sometimes emits the same event twice does not have to
+// filter them itself. The write then returns ok false with a nil error,
+// which a failed write never does. keyFn sees the message as given, before
+// compression or encryption, and a message only counts once it was
+// written, so a retry after ErrBufferFull is not taken for a duplicate.
+// The keys are kept in memory and are not shared with other handles or
+// processes. Applies to WriteMsg, WriteMsgFlags and WriteMsgFree. Cannot
+// be combined with WithConcurrentWriters, WithCoalescing or
+// WithLatestSlot.
+func WithDedup(window int, keyFn func([]byte) uint64) Option {
+	return func(o *options) {
+		o.dedupWindow = window
+		o.dedupKey = keyFn
+	}
+}
+
+// dedupWindow holds the keys of the last messages written, oldest first
+// from next, and how often each occurs among them. Guarded by writeMu.
+type dedupWindow struct {
+	keys   []uint64
+	next   int
+	filled bool
+	counts map[uint64]int
+}
+
+func newDedupWindow(window int) *dedupWindow {
+	return &dedupWindow{keys: make([]uint64, window), counts: make(map[uint64]int, window)}
+}
+
+// seen reports whether key is among the keys in the window
+func (d *dedupWindow) seen(key uint64) bool {
+	return d.counts[key] > 0
+}
+
+// add records key, pushing the oldest key out once the window is full
+func (d *dedupWindow) add(key uint64) {
+	if d.filled {
+		old := d.keys[d.next]
+		if d.counts[old]--; d.counts[old] == 0 {
+			delete(d.counts, old)
+		}
+	}
+	d.keys[d.next] = key
+	d.counts[key]++
+	if d.next++; d.next == len(d.keys) {
+		d.next, d.filled = 0, true
+	}
+}
+
+// dedupKey returns the WithDedup key of msg, or 0 without WithDedup
+func (r *RingBuffer) dedupKey(msg []byte) uint64 {
+	if r.dedup == nil {
+		return 0
+	}
+	return r.opts.dedupKey(msg)
+}
+
+// duplicate reports whether a message with key was written recently and
+// must be dropped. Caller must hold writeMu.
+func (r *RingBuffer) duplicate(key uint64) bool {
+	return r.dedup != nil && r.dedup.seen(key)
+}
+
+// written records the key of a message just written. Caller must hold
+// writeMu.
+func (r *RingBuffer) written(key uint64) {
+	if r.dedup != nil {
+		r.dedup.add(key)
+	}
+}
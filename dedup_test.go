@@ -0,0 +1,81 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"testing"
+)
+
+// fnvKey keys messages by their FNV-1a hash
+func fnvKey(msg []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(msg)
+	return h.Sum64()
+}
+
+func TestDedup(t *testing.T) {
+	const filename = "/tmp/test_rb_dedup.mmap"
+	rb, err := NewRingBuffer(filename, headerSize+96, true, WithDedup(2, fnvKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove(filename)
+
+	// "a" is still among the last two keys the second time, but has left
+	// the window the third time
+	for _, w := range []struct {
+		msg     string
+		written bool
+	}{{"a", true}, {"b", true}, {"a", false}, {"c", true}, {"b", false}, {"d", true}, {"a", true}} {
+		ok, err := rb.WriteMsg([]byte(w.msg))
+		if err != nil || ok != w.written {
+			t.Errorf("Write %q: expected ok %v, got %v (%v)", w.msg, w.written, ok, err)
+		}
+	}
+	if _, ok, err := rb.WriteMsgFree([]byte("d")); ok || err != nil {
+		t.Errorf("Expected WriteMsgFree to drop a duplicate, got %v (%v)", ok, err)
+	}
+	for _, want := range []string{"a", "b", "c", "d", "a"} {
+		if msg, err := rb.ReadMsg(); err != nil || string(msg) != want {
+			t.Errorf("Expected %q, got %q (%v)", want, msg, err)
+		}
+	}
+
+	// A message that did not fit is not remembered, so its retry goes
+	// through
+	for i := 0; ; i++ {
+		ok, err := rb.WriteMsg([]byte(fmt.Sprintf("filler-%04d", i)))
+		if err == ErrBufferFull {
+			break
+		}
+		if !ok || err != nil {
+			t.Fatalf("Failed to write filler %d: %v", i, err)
+		}
+	}
+	if ok, err := rb.WriteMsg([]byte("late")); ok || err != ErrBufferFull {
+		t.Fatalf("Expected ErrBufferFull, got %v (%v)", ok, err)
+	}
+	rb.ReadMsg()
+	if ok, err := rb.WriteMsg([]byte("late")); !ok || err != nil {
+		t.Errorf("Expected the retry to be written, got %v (%v)", ok, err)
+	}
+}
+
+func TestDedupOptions(t *testing.T) {
+	const filename = "/tmp/test_rb_dedup_options.mmap"
+	defer os.Remove(filename)
+
+	for _, opts := range [][]Option{{WithDedup(0, fnvKey)}, {WithDedup(4, nil)}} {
+		if _, err := NewRingBuffer(filename, 1024, true, opts...); !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("Expected ErrInvalidSize, got: %v", err)
+		}
+	}
+	for _, opt := range []Option{WithConcurrentWriters(), WithCoalescing(), WithLatestSlot()} {
+		if _, err := NewRingBuffer(filename, 1024, true, WithDedup(4, fnvKey), opt); err != ErrIncompatibleOptions {
+			t.Errorf("Expected ErrIncompatibleOptions, got: %v", err)
+		}
+	}
+}
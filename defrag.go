@@ -0,0 +1,56 @@
+package ringbuffer
+
+// Defragment moves the buffered messages, in order, to the start of the
+// data region, so the free space becomes one contiguous run up to the end
+// of the buffer. A large Reserve that would otherwise wrap around the end,
+// and get a temporary instead of a slice of the mapping, can then be
+// written in place. It holds both locks while it copies the buffered bytes
+// out and back, so call it when the buffer is quiet rather than on every
+// write.
+//
+// Like growing with WithAutoGrow, moving the data under other handles or
+// processes would show them a corrupt buffer, so none may use it while
+// Defragment runs. Returns ErrIncompatibleOptions with reader groups,
+// whose committed offsets point into the data, and ErrBufferFull in the
+// rare case WithPayloadAlignment padding keeps the messages from fitting
+// once moved.
+func (r *RingBuffer) Defragment() error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+	if r.opts.groups > 0 {
+		// The tail belongs to the reader groups' Commit
+		return ErrIncompatibleOptions
+	}
+
+	frames, err := r.pendingFrames()
+	if err != nil {
+		return err
+	}
+	// Padding depends on where a frame lands, so check the new layout
+	// fits before overwriting anything
+	pos, capacity := r.dataStart, uint32(r.size)-r.full.slack()
+	for _, p := range frames {
+		f := r.frameFrom(pos)
+		f.msgLen = p.f.msgLen
+		if f.gap > 0 || pos+f.footprint() > capacity {
+			return ErrBufferFull
+		}
+		pos += f.footprint()
+	}
+
+	r.relayout(frames)
+	if r.opts.concurrentWriters {
+		// The next claim starts at the new head
+		r.claimed.Store((r.claimed.Load()>>32 + 1) << 32)
+	}
+	if r.opts.debugChecks {
+		return r.checkInvariants("defragment")
+	}
+	return nil
+}
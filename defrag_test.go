@@ -0,0 +1,102 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"unsafe"
+)
+
+func TestRingBufferDefragment(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"sentinel", nil},
+		{"count", []Option{WithStrategy(StrategyCount)}},
+		{"back-links", []Option{WithBackLinks(), WithSequenceNumbers()}},
+		{"concurrent writers", []Option{WithConcurrentWriters()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rb, err := NewRingBuffer("/tmp/test_rb_defrag.mmap", headerSize+224, true, append(tc.opts, WithDebugChecks())...)
+			if err != nil {
+				t.Fatalf("Failed to create ring buffer: %v", err)
+			}
+			defer os.Remove("/tmp/test_rb_defrag.mmap")
+			defer rb.Close()
+
+			// Leave free space on both sides of the pending messages
+			msgs := [][]byte{bytes.Repeat([]byte{'a'}, 100), bytes.Repeat([]byte{'b'}, 30), bytes.Repeat([]byte{'c'}, 20)}
+			for _, msg := range msgs {
+				if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+					t.Fatalf("Failed to write message: %v", err)
+				}
+			}
+			if _, err := rb.ReadMsg(); err != nil {
+				t.Fatalf("Failed to read message: %v", err)
+			}
+			msgs = msgs[1:]
+			lag := rb.Lag()
+
+			if err := rb.Defragment(); err != nil {
+				t.Fatalf("Failed to defragment: %v", err)
+			}
+			if head, tail := rb.GetHeadTail(); tail != rb.dataStart || head != tail+uint32(lag) || rb.Lag() != lag {
+				t.Errorf("Expected %d bytes packed at the start, got head %d tail %d", lag, head, tail)
+			}
+
+			// Without WithConcurrentWriters, a frame that would have wrapped
+			// is now built in place
+			if !rb.opts.concurrentWriters {
+				n := rb.size - int(rb.dataStart) - lag - rb.FrameSize(0) - 1
+				buf, commit, err := rb.Reserve(n)
+				if err != nil {
+					t.Fatalf("Failed to reserve %d bytes: %v", n, err)
+				}
+				addr, base := uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&rb.buf[0]))
+				if addr < base || addr >= base+uintptr(rb.size) {
+					t.Errorf("Expected the reservation to point into the mapping")
+				}
+				copy(buf, bytes.Repeat([]byte{'d'}, n))
+				if err := commit(); err != nil {
+					t.Fatalf("Failed to commit: %v", err)
+				}
+				msgs = append(msgs, bytes.Repeat([]byte{'d'}, n))
+			} else if ok, err := rb.WriteMsg([]byte("after")); !ok || err != nil {
+				t.Fatalf("Failed to write message: %v", err)
+			} else {
+				msgs = append(msgs, []byte("after"))
+			}
+
+			if rb.opts.backLinks {
+				if msg, err := rb.ReadMsgReverse(); err != nil || !bytes.Equal(msg, msgs[len(msgs)-1]) {
+					t.Errorf("Expected the newest message from ReadMsgReverse, got %d bytes (%v)", len(msg), err)
+				}
+			}
+			for _, want := range msgs {
+				if msg, err := rb.ReadMsg(); err != nil || !bytes.Equal(msg, want) {
+					t.Errorf("Expected %d bytes of %q, got %d bytes (%v)", len(want), want[0], len(msg), err)
+				}
+			}
+			if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+				t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestRingBufferDefragmentGroups(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_defrag.mmap", 256, true, WithReaderGroups(1))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_defrag.mmap")
+
+	if err := rb.Defragment(); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions with reader groups, got: %v", err)
+	}
+	rb.Close()
+	if err := rb.Defragment(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after Close, got: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package ringbuffer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+var ErrDecryptFailed = errors.New("message failed to decrypt")
+
+const (
+	nonceSize    = 12                        // AES-GCM standard nonce
+	sealOverhead = nonceSize + aes.BlockSize // nonce before and tag after the ciphertext
+)
+
+// WithEncryption encrypts message payloads at rest with AES-GCM under key,
+// which must be 32 bytes (AES-256). Every message is stored as a random
+// nonce, the ciphertext and the authentication tag, so it takes 28 bytes
+// more space and a message that was altered in the file fails to read with
+// ErrDecryptFailed. Lengths, flags, timestamps and the header stay in the
+// clear. The file records that it is encrypted, but the key itself is not
+// stored, so OpenRingBuffer needs the option and a wrong key only shows up
+// as ErrDecryptFailed.
+//
+// Nonces are random, so one key should not be used for more than about
+// 2^32 messages.
+func WithEncryption(key []byte) Option {
+	return func(o *options) {
+		o.encryptionKey = append([]byte{}, key...)
+	}
+}
+
+// newAEAD returns the AES-GCM cipher for a key checked by validate
+func newAEAD(key []byte) cipher.AEAD {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(fmt.Sprintf("ringbuffer: validated key rejected: %v", err))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(fmt.Sprintf("ringbuffer: validated key rejected: %v", err))
+	}
+	return aead
+}
+
+// seal returns msg as stored in a frame, compressed if WithCompression is
+// set and encrypted if WithEncryption is set
+func (r *RingBuffer) seal(msg []byte) ([]byte, error) {
+	if len(msg) == 0 {
+		// Signals have nothing to compress or encrypt
+		return msg, nil
+	}
+	if r.opts.codec != CodecNone {
+		if len(msg) > r.msgLimit {
+			// A message that compresses well would otherwise fit, and
+			// then be refused by decompress
+			return nil, ErrMessageTooLarge
+		}
+		msg = r.compress(msg)
+	}
+	if r.aead == nil {
+		return msg, nil
+	}
+	sealed := make([]byte, nonceSize, sealOverhead+len(msg))
+	if _, err := rand.Read(sealed); err != nil {
+		return nil, fmt.Errorf("ringbuffer: nonce: %w", err)
+	}
+	return r.aead.Seal(sealed, sealed, msg, nil), nil
+}
+
+// open returns the message stored as payload, decrypting it if
+// WithEncryption is set and decompressing it if WithCompression is set
+func (r *RingBuffer) open(payload []byte) ([]byte, error) {
+	if len(payload) == 0 {
+		return payload, nil
+	}
+	if r.aead != nil {
+		msg, err := r.aead.Open(payload[nonceSize:nonceSize], payload[:nonceSize], payload[nonceSize:], nil)
+		if err != nil {
+			return nil, ErrDecryptFailed
+		}
+		payload = msg
+	}
+	if r.opts.codec != CodecNone {
+		return r.decompress(payload)
+	}
+	return payload, nil
+}
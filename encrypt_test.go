@@ -0,0 +1,114 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+var testKey = bytes.Repeat([]byte{0x42}, 32)
+
+func TestRingBufferEncryption(t *testing.T) {
+	filename := "/tmp/test_rb_encrypt.mmap"
+	rb, err := NewRingBuffer(filename, 1024, true, WithEncryption(testKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+
+	if got, want := rb.MaxMsgSize(), MaxMsgSizeFor(1024)-sealOverhead; got != want {
+		t.Errorf("Expected MaxMsgSize %d, got %d", want, got)
+	}
+
+	msg := []byte("secret payload")
+	if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if bytes.Contains(rb.buf, msg) {
+		t.Errorf("Expected the payload to be encrypted in the mapping")
+	}
+	if length, _, err := rb.PeekHeader(); err != nil || length != uint32(len(msg)) {
+		t.Errorf("Expected PeekHeader length %d, got %d (%v)", len(msg), length, err)
+	}
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Failed to close ring buffer: %v", err)
+	}
+
+	// The header records that payloads are encrypted
+	if _, err := OpenRingBuffer(filename); !errors.Is(err, ErrIncompatibleOptions) {
+		t.Errorf("Expected ErrIncompatibleOptions opening without a key, got: %v", err)
+	}
+
+	rb, err = OpenRingBuffer(filename, WithEncryption(testKey))
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	defer rb.Close()
+	got, err := rb.ReadMsg()
+	if err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("Expected %q, got %q (%v)", msg, got, err)
+	}
+
+	if _, err := rb.WriteMsg(nil); err != ErrInvalidSize {
+		t.Errorf("Expected ErrInvalidSize for an empty message, got: %v", err)
+	}
+	if _, err := rb.WriteMsg(make([]byte, rb.MaxMsgSize()+1)); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("Expected ErrMessageTooLarge past MaxMsgSize, got: %v", err)
+	}
+	if ok, err := rb.WriteMsg(make([]byte, rb.MaxMsgSize())); !ok || err != nil {
+		t.Errorf("Failed to write a message of MaxMsgSize: %v", err)
+	}
+}
+
+func TestRingBufferEncryptionTampered(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_encrypt.mmap", 1024, true, WithEncryption(testKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_encrypt.mmap")
+	defer rb.Close()
+
+	for _, msg := range []string{"first", "second"} {
+		if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+
+	// Flip a ciphertext bit of the first message
+	rb.buf[rb.dataStart+rb.prefix+nonceSize] ^= 1
+	if _, err := rb.ReadMsg(); err != ErrDecryptFailed {
+		t.Fatalf("Expected ErrDecryptFailed for tampered ciphertext, got: %v", err)
+	}
+
+	// The tampered message was consumed and the next one still reads
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "second" {
+		t.Errorf("Expected second message after the tampered one, got %q (%v)", msg, err)
+	}
+}
+
+func TestRingBufferEncryptionWrongKey(t *testing.T) {
+	filename := "/tmp/test_rb_encrypt.mmap"
+	rb, err := NewRingBuffer(filename, 1024, true, WithEncryption(testKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	if ok, err := rb.WriteMsg([]byte("secret")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	rb.Close()
+
+	other, err := OpenRingBuffer(filename, WithEncryption(bytes.Repeat([]byte{0x24}, 32)))
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	defer other.Close()
+	if _, err := other.ReadMsg(); err != ErrDecryptFailed {
+		t.Errorf("Expected ErrDecryptFailed with the wrong key, got: %v", err)
+	}
+
+	if _, err := NewRingBuffer("/tmp/test_rb_encrypt_bad.mmap", 1024, true, WithEncryption(testKey[:16])); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Expected ErrInvalidSize for a 16-byte key, got: %v", err)
+	}
+}
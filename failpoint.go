@@ -0,0 +1,31 @@
+//go:build ringbuffer_failpoints
+
+package ringbuffer
+
+import "sync/atomic"
+
+// readFailPoint holds the function set with SetReadFailPoint
+var readFailPoint atomic.Pointer[func() error]
+
+// SetReadFailPoint makes reads call fn before touching the buffer and
+// return the error it returns, if any, so tests can drive a consumer's
+// error handling deterministically, for example with ErrCorruptHeader,
+// ErrDecompressFailed or ErrDecryptFailed, without damaging a file. The
+// read consumes nothing when fn fails it. fn applies to every buffer in
+// the process and is called from the reading goroutine; nil removes it.
+// Only available when building with the ringbuffer_failpoints tag.
+func SetReadFailPoint(fn func() error) {
+	if fn == nil {
+		readFailPoint.Store(nil)
+		return
+	}
+	readFailPoint.Store(&fn)
+}
+
+// readFault returns the error the read fail point injects, if any
+func readFault() error {
+	if fn := readFailPoint.Load(); fn != nil {
+		return (*fn)()
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build !ringbuffer_failpoints
+
+package ringbuffer
+
+// readFault injects nothing unless built with the ringbuffer_failpoints
+// tag, see failpoint.go
+func readFault() error { return nil }
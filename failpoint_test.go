@@ -0,0 +1,46 @@
+//go:build ringbuffer_failpoints
+
+package ringbuffer
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadFailPoint(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_failpoint.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_failpoint.mmap")
+	defer rb.Close()
+	if ok, err := rb.WriteMsg([]byte("intact")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+
+	// The injected error is returned and the message stays buffered
+	calls := 0
+	SetReadFailPoint(func() error {
+		calls++
+		if calls == 1 {
+			return ErrDecryptFailed
+		}
+		return nil
+	})
+	defer SetReadFailPoint(nil)
+	if _, err := rb.ReadMsg(); err != ErrDecryptFailed {
+		t.Errorf("Expected ErrDecryptFailed, got: %v", err)
+	}
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "intact" {
+		t.Errorf("Expected \"intact\", got %q (%v)", msg, err)
+	}
+
+	SetReadFailPoint(func() error { return ErrCorruptHeader })
+	if _, _, err := rb.ReadMsgFlags(); err != ErrCorruptHeader {
+		t.Errorf("Expected ErrCorruptHeader, got: %v", err)
+	}
+	SetReadFailPoint(nil)
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+}
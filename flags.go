@@ -0,0 +1,38 @@
+package ringbuffer
+
+// WithFlags adds a one-byte flags field to every frame, set by WriteMsgFlags
+// and returned by ReadMsgFlags and PeekHeader. Messages written with
+// WriteMsg carry flags 0. The option is recorded in the file, so
+// OpenRingBuffer may leave it out.
+func WithFlags() Option {
+	return func(o *options) {
+		o.flags = true
+	}
+}
+
+// WriteMsgFlags writes a message together with a flags byte. Without
+// WithFlags the flags are not stored. With WithFlags and non-zero flags the
+// message may be empty, making it a signal: a frame with no payload that
+// ReadMsgFlags returns as an empty slice and its flags.
+// Returns (true, nil) if successful, (false, error) if failed
+func (r *RingBuffer) WriteMsgFlags(msg []byte, flags byte) (bool, error) {
+	return r.write(msg, flags, nil)
+}
+
+// ReadMsgFlags reads a message and the flags byte it was written with.
+// Without WithFlags the flags are always 0.
+// Returns (msg, flags, nil) if successful, (nil, 0, error) if failed
+func (r *RingBuffer) ReadMsgFlags() ([]byte, byte, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, 0, ErrClosed
+	}
+
+	msg, f, err := r.readFrame()
+	if err != nil {
+		return nil, 0, err
+	}
+	return msg, f.flags, nil
+}
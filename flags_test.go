@@ -0,0 +1,136 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestPeekHeader(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_peek_header.mmap", 1024, true, WithFlags())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_peek_header.mmap")
+
+	if _, _, err := rb.PeekHeader(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+
+	if ok, err := rb.WriteMsgFlags([]byte("routed message"), 0x42); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+
+	// Peeking twice returns the same header and does not consume
+	for i := 0; i < 2; i++ {
+		length, flags, err := rb.PeekHeader()
+		if err != nil {
+			t.Fatalf("Failed to peek header: %v", err)
+		}
+		if length != uint32(len("routed message")) || flags != 0x42 {
+			t.Errorf("Header mismatch. Got: length=%d flags=%#x, Want: length=%d flags=0x42", length, flags, len("routed message"))
+		}
+	}
+
+	readMsg, flags, err := rb.ReadMsgFlags()
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if string(readMsg) != "routed message" || flags != 0x42 {
+		t.Errorf("Message mismatch. Got: %s flags=%#x", string(readMsg), flags)
+	}
+}
+
+func TestPeekHeaderWithoutFlags(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_peek_noflags.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_peek_noflags.mmap")
+
+	if ok, err := rb.WriteMsgFlags([]byte("hello"), 0x7f); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+
+	length, flags, err := rb.PeekHeader()
+	if err != nil {
+		t.Fatalf("Failed to peek header: %v", err)
+	}
+	if length != 5 || flags != 0 {
+		t.Errorf("Header mismatch. Got: length=%d flags=%#x, Want: length=5 flags=0", length, flags)
+	}
+}
+
+func TestFlagsWrapAround(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_flags_wrap.mmap", headerSize+68, true, WithFlags())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_flags_wrap.mmap")
+
+	// Varying sizes make the prefix land on every offset near the end
+	for i := 0; i < 60; i++ {
+		msg := []byte(fmt.Sprintf("m%d-%s", i, "xxxxxxx"[:i%7]))
+		if ok, err := rb.WriteMsgFlags(msg, byte(i)); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+		readMsg, flags, err := rb.ReadMsgFlags()
+		if err != nil {
+			t.Fatalf("Failed to read message %d: %v", i, err)
+		}
+		if string(readMsg) != string(msg) || flags != byte(i) {
+			t.Fatalf("Message %d mismatch. Got: %s flags=%d", i, string(readMsg), flags)
+		}
+	}
+}
+
+func TestFlagsSignal(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_flags_signal.mmap", 1024, true, WithFlags(), WithEncryption(testKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_flags_signal.mmap")
+
+	if _, err := rb.WriteMsgFlags(nil, 0); err != ErrInvalidSize {
+		t.Errorf("Expected ErrInvalidSize for an empty message without flags, got: %v", err)
+	}
+	if ok, err := rb.WriteMsgFlags(nil, 0x01); !ok || err != nil {
+		t.Fatalf("Failed to write signal: %v", err)
+	}
+	if ok, err := rb.WriteMsgFlags([]byte("data"), 0x02); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+
+	if length, flags, err := rb.PeekHeader(); err != nil || length != 0 || flags != 0x01 {
+		t.Errorf("Expected a signal header, got length=%d flags=%#x (%v)", length, flags, err)
+	}
+	msg, flags, err := rb.ReadMsgFlags()
+	if err != nil || msg == nil || len(msg) != 0 || flags != 0x01 {
+		t.Errorf("Expected an empty signal with flags 0x01, got %q flags=%#x (%v)", msg, flags, err)
+	}
+	msg, flags, err = rb.ReadMsgFlags()
+	if err != nil || string(msg) != "data" || flags != 0x02 {
+		t.Errorf("Expected %q with flags 0x02 after the signal, got %q flags=%#x (%v)", "data", msg, flags, err)
+	}
+}
+
+func TestFlagsSignalWithoutFlags(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_flags_signal.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_flags_signal.mmap")
+
+	// Without a stored flags byte an empty frame could not be told apart
+	if _, err := rb.WriteMsgFlags(nil, 0x01); err != ErrInvalidSize {
+		t.Errorf("Expected ErrInvalidSize for a signal without WithFlags, got: %v", err)
+	}
+	if _, err := rb.WriteMsg([]byte{}); err != ErrInvalidSize {
+		t.Errorf("Expected ErrInvalidSize for an empty message, got: %v", err)
+	}
+}
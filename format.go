@@ -0,0 +1,50 @@
+package ringbuffer
+
+// The file layout, for readers and writers in other languages. All
+// integers are little-endian. The constants and the meaning of the fields
+// they locate are fixed for a given FormatVersion: changing any of them
+// comes with a new version, so an external reader that checks the version
+// in the format word can rely on them. New header fields only take bytes
+// from the reserved region, which is zero in files that predate them, and
+// new frame fields only appear with a feature bit set in the format word or
+// the features field.
+//
+// With no per-frame options, a frame is LengthPrefixSize bytes of payload
+// length followed by the payload. The tail is the next frame to read and
+// the head is where the next one will be written; with StrategySentinel the
+// buffer is empty when they are equal. A frame starts at the start of the
+// data region instead of at the tail if its length field would not fit
+// before the end of the file; the payload itself may wrap around to the
+// start of the data region. See the README for the format word's feature
+// bits and the fields they add.
+const (
+	// FormatVersion is the layout version, stored in bits 16 to 19 of the
+	// format word.
+	FormatVersion = 4
+	// MagicValue is stored in the low 16 bits of the format word.
+	MagicValue = 0x4252
+	// FormatOffset is the offset of the 32-bit format word: MagicValue,
+	// FormatVersion, the strategy in bit 20 and the feature bits above it.
+	FormatOffset = 0
+	// HeadOffset is the offset of the 32-bit head, the file offset where
+	// the next frame will be written.
+	HeadOffset = 4
+	// TailOffset is the offset of the 32-bit tail, the file offset of the
+	// next frame to read.
+	TailOffset = 8
+	// UsedOffset is the offset of the 32-bit count of bytes in use, kept
+	// with StrategyCount.
+	UsedOffset = 12
+	// FeaturesOffset is the offset of the 32-bit feature bits that no
+	// longer fit in the format word. Bit 0 adds a 32-bit repeat count to
+	// every frame after the sequence number, see WithCoalescing.
+	FeaturesOffset = 28
+	// HeaderSize is the size of the fixed header. Reader group slots and,
+	// with WithSequenceNumbers, the last sequence number follow it before
+	// the data region; (*RingBuffer).HeaderSize returns where the data
+	// region starts.
+	HeaderSize = 128
+	// LengthPrefixSize is the size of the 32-bit payload length that starts
+	// every frame.
+	LengthPrefixSize = 4
+)
@@ -0,0 +1,93 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestFormatExternalReader reads a file the way a reader in another
+// language would, using only the exported layout constants
+func TestFormatExternalReader(t *testing.T) {
+	const filename = "/tmp/test_rb_format_external.mmap"
+	defer os.Remove(filename)
+
+	// After the first message moves head near the end, the payload of the
+	// third one is split in the first case and its length field is moved
+	// to the start of the data region in the second
+	for _, sizes := range [][]int{{150, 30, 60, 1}, {150, 40, 60, 1}} {
+		want := writeExternal(t, filename, sizes)
+		if got := readExternal(t, filename); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%v: expected %q, got %q", sizes, want, got)
+		}
+	}
+}
+
+// writeExternal writes messages of the given sizes, reading the first one
+// back, and returns the messages left in the buffer
+func writeExternal(t *testing.T, filename string, sizes []int) []string {
+	t.Helper()
+	rb, err := NewRingBuffer(filename, HeaderSize+200, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+
+	var want []string
+	for i, size := range sizes {
+		msg := fmt.Sprintf("%0*d", size, i)
+		if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+		if i == 0 {
+			if _, err := rb.ReadMsg(); err != nil {
+				t.Fatalf("Failed to read message: %v", err)
+			}
+			continue
+		}
+		want = append(want, msg)
+	}
+	return want
+}
+
+// readExternal walks the frames between tail and head in the file
+func readExternal(t *testing.T, filename string) []string {
+	t.Helper()
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	format := binary.LittleEndian.Uint32(buf[FormatOffset:])
+	if format&0xffff != MagicValue || format>>16&0xf != FormatVersion {
+		t.Fatalf("Unexpected format word %#x", format)
+	}
+	size := uint32(len(buf))
+	head := binary.LittleEndian.Uint32(buf[HeadOffset:])
+	tail := binary.LittleEndian.Uint32(buf[TailOffset:])
+
+	var got []string
+	for pos := tail; pos != head; {
+		if pos+LengthPrefixSize > size {
+			pos = HeaderSize
+		}
+		n := binary.LittleEndian.Uint32(buf[pos:])
+		pos += LengthPrefixSize
+		msg := make([]byte, 0, n)
+		for ; n > 0; n-- {
+			if pos == size {
+				pos = HeaderSize
+			}
+			msg = append(msg, buf[pos])
+			pos++
+		}
+		if pos == size {
+			pos = HeaderSize
+		}
+		got = append(got, string(msg))
+		if len(got) > 10 {
+			t.Fatalf("Walked past the messages written: %q", got)
+		}
+	}
+	return got
+}
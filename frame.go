@@ -0,0 +1,293 @@
+package ringbuffer
+
+import "encoding/binary"
+
+// frame describes a message stored in the data region.
+// Frame layout:
+// [length(4)][flags(1), WithFlags only][timestamp(8), WithTimestamps only][sequence(8), WithSequenceNumbers only][repeat(4), WithCoalescing only][padding, WithPayloadAlignment only][payload...][link(4), WithBackLinks only]
+// The prefix before the payload never straddles the end of the buffer; the payload and link may wrap around to the start of the data
+// region.
+//
+// Wrap rule: a frame starts at pos unless its prefix would run past the
+// end of the buffer, in which case the bytes from pos to the end are
+// skipped, count as used, and the frame starts at the data region instead.
+// The skip depends only on pos and the options, so frameFrom applies the
+// same rule for writers and readers and nothing is stored for it. With
+// WithNoWrapWrites the whole frame must fit instead of just the prefix, and
+// the skip is marked in the file, see place.
+type frame struct {
+	start  uint32 // offset of the length field
+	prefix uint32 // length field plus optional per-frame fields and padding
+	link   uint32 // size of the back-link after the payload
+	msgLen uint32 // payload length
+	flags  byte   // flags byte, WithFlags only
+	time   int64  // write time in Unix nanoseconds, WithTimestamps only
+	seq    uint64 // sequence number, WithSequenceNumbers only
+	repeat uint32 // writes the frame stands for, WithCoalescing only
+	gap    uint32 // bytes skipped at the end of the buffer before start
+	next   uint32 // offset right after the payload
+}
+
+// payload returns the offset of the first payload byte
+func (f frame) payload() uint32 {
+	return f.start + f.prefix
+}
+
+// footprint returns the number of data bytes the frame occupies
+func (f frame) footprint() uint32 {
+	return f.gap + f.prefix + f.msgLen + f.link
+}
+
+// origin returns where the frame was placed before skipping the gap, which
+// is also where the previous frame ends
+func (r *RingBuffer) origin(f frame) uint32 {
+	if f.gap > 0 {
+		return uint32(r.size) - f.gap
+	}
+	return f.start
+}
+
+// frameFrom returns a frame whose prefix is placed at pos, or at the start
+// of the data region if the prefix would not fit before the end
+func (r *RingBuffer) frameFrom(pos uint32) frame {
+	f := frame{start: pos, prefix: r.prefixAt(pos), link: r.opts.linkSize()}
+	if pos+f.prefix > uint32(r.size) {
+		f.gap = uint32(r.size) - pos
+		f.start = r.dataStart
+		f.prefix = r.prefixAt(r.dataStart)
+	}
+	return f
+}
+
+// span returns the n bytes at pos as up to two slices of the mapping (the
+// second is only non-empty on wrap-around) and the offset right after them.
+// pos may be at most the buffer size, which frameFrom guarantees for every
+// payload, so the first part never exceeds n; n must not exceed the data
+// region, which frameAt guarantees by bounding lengths by MaxMsgSize.
+func (r *RingBuffer) span(pos, n uint32) ([]byte, []byte, uint32) {
+	size := uint32(r.size)
+	if pos+n < size {
+		return r.buf[pos : pos+n], nil, pos + n
+	}
+	firstPart := size - pos
+	return r.buf[pos:size], r.buf[r.dataStart : r.dataStart+n-firstPart], r.dataStart + n - firstPart
+}
+
+// copyIn copies p into the data region at pos, handling wrap-around, and
+// returns the offset right after it
+func (r *RingBuffer) copyIn(pos uint32, p []byte) uint32 {
+	first, second, end := r.span(pos, uint32(len(p)))
+	n := copy(first, p)
+	copy(second, p[n:])
+	return end
+}
+
+// copyOut copies len(p) bytes at pos out of the data region, handling
+// wrap-around, and returns the offset right after them
+func (r *RingBuffer) copyOut(pos uint32, p []byte) uint32 {
+	first, second, end := r.span(pos, uint32(len(p)))
+	n := copy(p, first)
+	copy(p[n:], second)
+	return end
+}
+
+// reserve checks that a message of msgLen bytes fits at head and returns
+// where its frame starts. credit is how much of the reserved space the
+// write may use. Caller must hold writeMu.
+func (r *RingBuffer) reserve(msgLen, credit uint32) (frame, error) {
+	// A message that cannot fit even in an empty buffer is rejected before
+	// any full policy gets a chance to block or drop messages for it
+	if msgLen > r.maxPayload() {
+		return frame{}, ErrMessageTooLarge
+	}
+
+	if r.opts.spsc {
+		if f, ok := r.reserveCached(msgLen, credit); ok {
+			return f, nil
+		}
+	}
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return frame{}, err
+	}
+	if r.opts.spsc {
+		r.tailCache = tail
+	}
+	return r.fit(head, tail, msgLen, credit)
+}
+
+// fit returns the frame for a message of msgLen bytes written at pos, or
+// ErrBufferFull if it does not fit before tail
+func (r *RingBuffer) fit(pos, tail, msgLen, credit uint32) (frame, error) {
+	// The prefix never straddles the end of the buffer: if it does not
+	// fit, the remaining bytes are skipped and count as used space.
+	f := r.place(pos, msgLen)
+
+	// How much of the free space may be used depends on the strategy
+	if f.footprint() > r.free(pos, tail, credit) {
+		return frame{}, ErrBufferFull
+	}
+	return f, nil
+}
+
+// publish writes the prefix of f and advances head past its payload, which
+// the caller must have copied in already. Caller must hold writeMu.
+func (r *RingBuffer) publish(f frame) {
+	r.putPrefix(f)
+	r.advanceHead(f.next, f.footprint())
+}
+
+// putPrefix writes the prefix of f without publishing it
+func (r *RingBuffer) putPrefix(f frame) {
+	if r.opts.noWrap && f.gap > 0 && r.frameFrom(r.origin(f)).gap == 0 {
+		// Tell readers the frame moved to the start, see WithNoWrapWrites
+		gap := uint32(r.size) - f.gap
+		binary.LittleEndian.PutUint32(r.buf[gap:gap+4], noWrapMarker)
+	}
+	binary.LittleEndian.PutUint32(r.buf[f.start:f.start+4], f.msgLen)
+	if r.opts.flags {
+		r.buf[f.start+4] = f.flags
+	}
+	if r.opts.timestamps {
+		ts := f.start + r.opts.timestampOffset()
+		binary.LittleEndian.PutUint64(r.buf[ts:ts+8], uint64(f.time))
+	}
+	if r.opts.sequences {
+		seq := f.start + r.opts.sequenceOffset()
+		binary.LittleEndian.PutUint64(r.buf[seq:seq+8], f.seq)
+		if r.index != nil {
+			r.index.put(f.seq, f.start)
+		}
+	}
+	if r.opts.coalesce {
+		// A new frame stands for one write; a moved one keeps its count
+		count := f.start + r.opts.repeatOffset()
+		binary.LittleEndian.PutUint32(r.buf[count:count+4], max(f.repeat, 1))
+	}
+}
+
+// putLink writes the back-link of f after its payload, which ends at end,
+// and returns the offset right after the frame
+func (r *RingBuffer) putLink(f frame, end uint32) uint32 {
+	if !r.opts.backLinks {
+		return end
+	}
+	var link [4]byte
+	binary.LittleEndian.PutUint32(link[:], r.origin(f))
+	return r.copyIn(end, link[:])
+}
+
+// advanceHead publishes n bytes of complete frames ending at next. Caller
+// must hold writeMu.
+func (r *RingBuffer) advanceHead(next, n uint32) {
+	r.setHead(next)
+	r.full.produced(r, n)
+	if r.opts.futex {
+		r.futexWake()
+	}
+	r.ringDoorbell()
+	if r.waiters.Load() > 0 {
+		// Wake WaitReadableOrWritable
+		r.notifySpace()
+	}
+}
+
+// peekFrame locates the message at tail without consuming it. Caller must
+// hold readMu.
+func (r *RingBuffer) peekFrame() (frame, error) {
+	if r.opts.spsc {
+		if f, ok, err := r.peekCached(); ok {
+			return f, err
+		}
+	}
+	head, tail, err := r.loadHeadTail()
+	if err == ErrCorruptHeader {
+		head, tail := r.headTail()
+		r.warnf("corrupt header: head %d, tail %d", head, tail)
+	}
+	if err != nil {
+		return frame{}, err
+	}
+	if r.opts.spsc {
+		r.headCache = head
+	}
+	if r.full.empty(r, head, tail) {
+		return frame{}, ErrBufferEmpty
+	}
+	f, err := r.frameBefore(tail, r.used(head, tail))
+	if err != nil {
+		r.warnf("corrupt frame at tail %d with head %d: %v", tail, head, err)
+	}
+	return f, err
+}
+
+// frameBefore locates the message at pos like frameAt, and also returns
+// ErrCorruptHeader if it would run past the used bytes from pos to head, so
+// a damaged length can never make a copy wrap around past head.
+func (r *RingBuffer) frameBefore(pos, used uint32) (frame, error) {
+	f, err := r.frameAt(pos)
+	if err != nil {
+		return frame{}, err
+	}
+	if f.footprint() > used {
+		return frame{}, ErrCorruptHeader
+	}
+	return f, nil
+}
+
+// frameAt locates the message whose frame begins at pos, which must not be
+// the head. It returns ErrCorruptHeader if the stored length could not have
+// been written.
+func (r *RingBuffer) frameAt(pos uint32) (frame, error) {
+	// Check if we need to wrap around for the prefix
+	f := r.frameFrom(pos)
+
+	// Read message length and flags
+	f.msgLen = binary.LittleEndian.Uint32(r.buf[f.start : f.start+4])
+	if r.opts.noWrap && f.gap == 0 && f.msgLen == noWrapMarker {
+		f.gap = uint32(r.size) - f.start
+		f.start = r.dataStart
+		f.prefix = r.prefixAt(r.dataStart)
+		f.msgLen = binary.LittleEndian.Uint32(r.buf[f.start : f.start+4])
+	}
+	if r.opts.flags {
+		f.flags = r.buf[f.start+4]
+	}
+	if f.msgLen == 0 && f.flags == 0 {
+		// Only signals have no payload
+		return frame{}, ErrCorruptHeader
+	}
+	if (f.msgLen > 0 && f.msgLen <= r.opts.sealOverhead()) || f.msgLen > r.maxPayload() {
+		return frame{}, ErrCorruptHeader
+	}
+	if r.opts.timestamps {
+		ts := f.start + r.opts.timestampOffset()
+		f.time = int64(binary.LittleEndian.Uint64(r.buf[ts : ts+8]))
+	}
+	if r.opts.sequences {
+		seq := f.start + r.opts.sequenceOffset()
+		f.seq = binary.LittleEndian.Uint64(r.buf[seq : seq+8])
+	}
+	if r.opts.coalesce {
+		count := f.start + r.opts.repeatOffset()
+		if f.repeat = binary.LittleEndian.Uint32(r.buf[count : count+4]); f.repeat == 0 {
+			return frame{}, ErrCorruptHeader
+		}
+	}
+	_, _, f.next = r.span(f.payload(), f.msgLen+f.link)
+	return f, nil
+}
+
+// consume advances tail past f, which was read. Caller must hold readMu.
+func (r *RingBuffer) consume(f frame) {
+	r.advanceTail(f)
+	r.stats.add(Stats{Reads: 1, ReadBytes: uint64(f.msgLen)})
+}
+
+// advanceTail frees the space of f at tail. Caller must hold readMu.
+func (r *RingBuffer) advanceTail(f frame) {
+	r.setTail(f.next)
+	r.chunkOff = 0
+	r.full.consumed(r, f.footprint())
+	r.notifyFreed()
+}
@@ -0,0 +1,84 @@
+package ringbuffer
+
+import "encoding/binary"
+
+// WriteFramed writes a sequence of pre-framed records, each a 4-byte
+// little-endian length followed by that many payload bytes, the same
+// framing the ring uses internally. All records are validated before any
+// is copied; a malformed sequence returns ErrInvalidSize, or
+// ErrMessageTooLarge for a record larger than MaxMsgSize, and writes nothing.
+// Records are copied in order until one does not fit, and head is advanced
+// once at the end, so readers see the written records all at once.
+//
+// It returns the number of records written, with ErrBufferFull if not all
+// of them fit. The full policy is not applied. With WithFlags the records
+// are stored with flags 0, and with WithTimestamps they all share the time
+// of the call. With WithCompression or WithEncryption it returns
+// ErrIncompatibleOptions, as the records would be stored as they are.
+func (r *RingBuffer) WriteFramed(data []byte) (n int, err error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if !r.acceptsWrites() {
+		return 0, ErrClosed
+	}
+	if r.opts.concurrentWriters {
+		// Head is advanced by claims outside writeMu
+		return 0, ErrIncompatibleOptions
+	}
+	if r.opts.sealed() {
+		return 0, ErrIncompatibleOptions
+	}
+
+	maxMsgSize := uint32(r.MaxMsgSize())
+	for rest := data; len(rest) > 0; {
+		if len(rest) < 4 {
+			return 0, ErrInvalidSize
+		}
+		msgLen := binary.LittleEndian.Uint32(rest)
+		if msgLen == 0 || uint64(msgLen) > uint64(len(rest)-4) {
+			return 0, ErrInvalidSize
+		}
+		if msgLen > maxMsgSize {
+			return 0, ErrMessageTooLarge
+		}
+		rest = rest[4+msgLen:]
+	}
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return 0, err
+	}
+	avail := r.free(head, tail, 0)
+
+	pos, total, now := head, uint32(0), r.stamp()
+	written := Stats{}
+	for len(data) > 0 {
+		msgLen := binary.LittleEndian.Uint32(data)
+		f := r.place(pos, msgLen)
+		f.time = now
+		if f.footprint() > avail-total {
+			err = ErrBufferFull
+			break
+		}
+
+		f.seq = r.sequence()
+		f.next = r.putLink(f, r.copyIn(f.payload(), data[4:4+msgLen]))
+		r.putPrefix(f)
+		pos = f.next
+		total += f.footprint()
+		data = data[4+msgLen:]
+		n++
+		written.WriteBytes += uint64(msgLen)
+	}
+
+	if n > 0 {
+		r.advanceHead(pos, total)
+	}
+	written.Writes = uint64(n)
+	if err == ErrBufferFull {
+		written.FullErrors = 1
+	}
+	r.stats.add(written)
+	return n, err
+}
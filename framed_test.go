@@ -0,0 +1,110 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// frameRecords encodes msgs as [len(4)][payload] records
+func frameRecords(msgs ...string) []byte {
+	var data []byte
+	for _, msg := range msgs {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(msg)))
+		data = append(data, msg...)
+	}
+	return data
+}
+
+func TestWriteFramed(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_framed.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_framed.mmap")
+
+	messages := []string{"alpha", "beta", "gamma", "delta"}
+	n, err := rb.WriteFramed(frameRecords(messages...))
+	if err != nil {
+		t.Fatalf("Failed to write framed records: %v", err)
+	}
+	if n != len(messages) {
+		t.Fatalf("Expected %d records written, got %d", len(messages), n)
+	}
+
+	for i, expectedMsg := range messages {
+		readMsg, err := rb.ReadMsg()
+		if err != nil {
+			t.Fatalf("Failed to read message %d: %v", i, err)
+		}
+		if string(readMsg) != expectedMsg {
+			t.Errorf("Message %d mismatch. Got: %s, Want: %s", i, string(readMsg), expectedMsg)
+		}
+	}
+}
+
+func TestWriteFramedPartial(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_framed_partial.mmap", headerSize+68, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_framed_partial.mmap")
+
+	var messages []string
+	for i := 0; i < 10; i++ {
+		messages = append(messages, fmt.Sprintf("record-%d", i))
+	}
+
+	n, err := rb.WriteFramed(frameRecords(messages...))
+	if err != ErrBufferFull {
+		t.Fatalf("Expected ErrBufferFull, got: %v", err)
+	}
+	if n == 0 || n >= len(messages) {
+		t.Fatalf("Expected a partial write, got %d records", n)
+	}
+
+	for i := 0; i < n; i++ {
+		readMsg, err := rb.ReadMsg()
+		if err != nil {
+			t.Fatalf("Failed to read message %d: %v", i, err)
+		}
+		if string(readMsg) != messages[i] {
+			t.Errorf("Message %d mismatch. Got: %s, Want: %s", i, string(readMsg), messages[i])
+		}
+	}
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+}
+
+func TestWriteFramedMalformed(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_framed_bad.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_framed_bad.mmap")
+
+	good := frameRecords("ok")
+	cases := map[string][]byte{
+		"truncated length":  append(good, 1, 0),
+		"truncated payload": append(good, 9, 0, 0, 0, 'x'),
+		"zero length":       append(good, 0, 0, 0, 0),
+		"too large":         frameRecords(string(make([]byte, rb.MaxMsgSize()+1))),
+	}
+	for name, data := range cases {
+		n, err := rb.WriteFramed(data)
+		if n != 0 || !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("%s: expected (0, ErrInvalidSize), got (%d, %v)", name, n, err)
+		}
+	}
+
+	// Nothing was written by the rejected calls
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+}
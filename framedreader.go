@@ -0,0 +1,127 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// FramedReader drains a RingBuffer as a byte stream in the framing that
+// WriteFramed accepts: each message as a 4-byte little-endian length
+// followed by its payload. Flags and timestamps are not included, and a
+// signal is written as a zero length. Reads return io.EOF once the buffer
+// is empty, so io.Copy moves out what is buffered at the time and returns.
+//
+// A FramedReader is not safe for concurrent use, but other readers of the
+// buffer may run alongside it.
+type FramedReader struct {
+	rb      *RingBuffer
+	pending []byte // rest of a frame a short Read could not take
+}
+
+// FramedReader returns a FramedReader over r. With reader groups its reads
+// return ErrIncompatibleOptions.
+func (r *RingBuffer) FramedReader() *FramedReader {
+	return &FramedReader{rb: r}
+}
+
+// Read copies framed messages into p. A message is consumed as soon as its
+// frame starts being returned; the rest is kept for the next Read.
+func (fr *FramedReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(fr.pending) == 0 {
+			msg, err := fr.rb.ReadMsg()
+			if err == ErrBufferEmpty {
+				if n > 0 {
+					return n, nil
+				}
+				return 0, io.EOF
+			}
+			if err != nil {
+				return n, err
+			}
+			fr.pending = binary.LittleEndian.AppendUint32(make([]byte, 0, 4+len(msg)), uint32(len(msg)))
+			fr.pending = append(fr.pending, msg...)
+		}
+		c := copy(p[n:], fr.pending)
+		fr.pending = fr.pending[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// WriteTo implements io.WriterTo, writing every buffered message to w
+// straight from the mapping without copying it first, except for
+// compressed or encrypted messages, which are opened first. A message is consumed once w
+// has accepted its whole frame; if w fails, the message stays buffered.
+func (fr *FramedReader) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	if len(fr.pending) > 0 {
+		n, err := w.Write(fr.pending)
+		total += int64(n)
+		fr.pending = fr.pending[n:]
+		if err != nil {
+			return total, err
+		}
+	}
+	for {
+		n, err := fr.rb.writeNextTo(w)
+		total += n
+		if err == ErrBufferEmpty {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// writeNextTo writes the next message to w in framed form and consumes it
+// if w accepted all of it
+func (r *RingBuffer) writeNextTo(w io.Writer) (int64, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return 0, ErrClosed
+	}
+	if r.opts.groups > 0 {
+		// The tail belongs to the reader groups' Commit
+		return 0, ErrIncompatibleOptions
+	}
+	f, err := r.peekFrame()
+	if err != nil {
+		return 0, err
+	}
+
+	// readMu keeps writers from overwriting the frame until it is consumed
+	first, second, _ := r.span(f.payload(), f.msgLen)
+	if r.opts.sealed() {
+		msg := make([]byte, f.msgLen)
+		r.copyOut(f.payload(), msg)
+		if msg, err = r.open(msg); err != nil {
+			r.consume(f)
+			return 0, err
+		}
+		first, second = msg, nil
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(first)+len(second)))
+
+	var total int64
+	for _, part := range [][]byte{length[:], first, second} {
+		if len(part) == 0 {
+			continue
+		}
+		n, err := w.Write(part)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	r.consume(f)
+	if r.opts.debugChecks {
+		return total, r.checkInvariants("read")
+	}
+	return total, nil
+}
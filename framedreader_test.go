@@ -0,0 +1,129 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestFramedReaderWriteTo(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_framedreader.mmap", headerSize+224, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_framedreader.mmap")
+	defer rb.Close()
+	dst, err := NewRingBuffer("/tmp/test_rb_framedreader_dst.mmap", headerSize+224, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_framedreader_dst.mmap")
+	defer dst.Close()
+
+	// Wrap the head so a payload is written from two parts of the mapping
+	if ok, err := rb.WriteMsg(make([]byte, 150)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("message-%d-0123456789", i))); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+
+	var framed bytes.Buffer
+	n, err := io.Copy(&framed, rb.FramedReader())
+	if err != nil || n != int64(framed.Len()) || n != 4*(4+20) {
+		t.Fatalf("Expected 96 framed bytes, got %d (%v)", n, err)
+	}
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected the buffer to be drained, got: %v", err)
+	}
+
+	// The framing is the one WriteFramed takes
+	if written, err := dst.WriteFramed(framed.Bytes()); written != 4 || err != nil {
+		t.Fatalf("Expected WriteFramed to take 4 records, got %d (%v)", written, err)
+	}
+	for i := 0; i < 4; i++ {
+		msg, err := dst.ReadMsg()
+		if want := fmt.Sprintf("message-%d-0123456789", i); err != nil || string(msg) != want {
+			t.Errorf("Expected %q, got %q (%v)", want, msg, err)
+		}
+	}
+}
+
+// failingWriter accepts limit bytes and then fails
+type failingWriter struct {
+	limit int
+	buf   bytes.Buffer
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, errors.New("sink full")
+	}
+	return w.buf.Write(p)
+}
+
+func TestFramedReaderWriteToError(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_framedreader.mmap", 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_framedreader.mmap")
+	defer rb.Close()
+
+	for _, m := range []string{"first", "second"} {
+		if ok, err := rb.WriteMsg([]byte(m)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+
+	// The second message does not fit in the sink and stays buffered
+	w := &failingWriter{limit: 9 + 4}
+	if _, err := rb.FramedReader().WriteTo(w); err == nil {
+		t.Fatalf("Expected the sink error")
+	}
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "second" {
+		t.Errorf("Expected the unwritten message to stay buffered, got %q (%v)", msg, err)
+	}
+}
+
+func TestFramedReaderRead(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_framedreader.mmap", 256, true, WithEncryption(testKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_framedreader.mmap")
+	defer rb.Close()
+
+	for _, m := range []string{"alpha", "beta"} {
+		if ok, err := rb.WriteMsg([]byte(m)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+
+	// Three-byte reads split the frames across calls
+	fr := rb.FramedReader()
+	var got []byte
+	p := make([]byte, 3)
+	for {
+		n, err := fr.Read(p)
+		got = append(got, p[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read: %v", err)
+		}
+	}
+	want := []byte("\x05\x00\x00\x00alpha\x04\x00\x00\x00beta")
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,51 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// bytesName names buffers from FromBytes in errors
+const bytesName = "[]byte"
+
+// FromBytes uses buf, memory the caller already has, such as a mapping made
+// by another library or shared by other means, as a ring buffer without
+// mapping anything itself. With initialize it creates an empty buffer in
+// buf as NewRingBuffer does; otherwise it validates the header and takes
+// the record format from it as OpenRingBuffer does. buf must start at a
+// 4-byte aligned address, since the header words are accessed atomically,
+// and must stay valid until Close, which leaves it to the caller. Other
+// processes see the buffer only if buf is shared memory.
+//
+// WithAutoGrow, WithHugePages and WithGuardPages, which need control over
+// the mapping, return ErrIncompatibleOptions, as does Sync.
+func FromBytes(buf []byte, initialize bool, opts ...Option) (*RingBuffer, error) {
+	o, err := parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.autoGrow > 0 || o.hugePages || o.guardPages {
+		return nil, ErrIncompatibleOptions
+	}
+	if len(buf) > 0 && uintptr(unsafe.Pointer(&buf[0]))%4 != 0 {
+		return nil, fmt.Errorf("ringbuffer: %s at %p is not 4-byte aligned: %w", bytesName, &buf[0], ErrInvalidSize)
+	}
+	buf = buf[:len(buf):len(buf)]
+
+	// A nil mapping marks the memory as the caller's: Close and the error
+	// paths of openMapping leave it alone
+	if initialize {
+		if err := o.checkSize(len(buf)); err != nil {
+			return nil, err
+		}
+		return createMapping(bytesName, buf, nil, o).attachIndex()
+	}
+	if len(buf) < headerSize {
+		return nil, fmt.Errorf("ringbuffer: %s: size %d is smaller than header size %d: %w", bytesName, len(buf), headerSize, ErrInvalidSize)
+	}
+	rb, err := openMapping(bytesName, buf, nil, o)
+	if err != nil {
+		return nil, err
+	}
+	return rb.attachIndex()
+}
@@ -0,0 +1,73 @@
+package ringbuffer
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+func TestFromBytes(t *testing.T) {
+	mem, err := syscall.Mmap(-1, 0, 4096, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_SHARED)
+	if err != nil {
+		t.Fatalf("Failed to map anonymous memory: %v", err)
+	}
+	defer syscall.Munmap(mem)
+
+	rb, err := FromBytes(mem, true, WithFlags())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	for _, msg := range []string{"one", "two"} {
+		if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	if err := rb.Sync(); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions from Sync, got: %v", err)
+	}
+	if err := rb.CloseSync(); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions from CloseSync, got: %v", err)
+	}
+
+	// A second handle over the same memory takes the format from the header
+	other, err := FromBytes(mem, false)
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	if !other.opts.flags {
+		t.Errorf("Expected flags to be taken from the header")
+	}
+	if msg, err := other.ReadMsg(); err != nil || string(msg) != "one" {
+		t.Errorf("Expected \"one\", got %q (%v)", msg, err)
+	}
+
+	// Closing leaves the memory mapped for its owner
+	if err := rb.Close(); err != nil {
+		t.Errorf("Failed to close: %v", err)
+	}
+	if msg, err := other.ReadMsg(); err != nil || string(msg) != "two" {
+		t.Errorf("Expected \"two\" after the first handle closed, got %q (%v)", msg, err)
+	}
+	if err := other.Close(); err != nil {
+		t.Errorf("Failed to close: %v", err)
+	}
+	if mem[0] == 0 && mem[1] == 0 {
+		t.Errorf("Expected the header to stay readable after Close")
+	}
+}
+
+func TestFromBytesInvalid(t *testing.T) {
+	buf := make([]byte, 1024)
+	if _, err := FromBytes(buf[1:], true); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Expected ErrInvalidSize for a misaligned slice, got: %v", err)
+	}
+	if _, err := FromBytes(buf, false); !errors.Is(err, ErrUninitialized) {
+		t.Errorf("Expected ErrUninitialized for zeroed memory, got: %v", err)
+	}
+	if _, err := FromBytes(buf[:headerSize], true); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Expected ErrInvalidSize for a slice without a data region, got: %v", err)
+	}
+	if _, err := FromBytes(buf, true, WithAutoGrow(4096)); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions with WithAutoGrow, got: %v", err)
+	}
+}
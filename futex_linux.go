@@ -0,0 +1,97 @@
+//go:build linux
+
+package ringbuffer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	futexWait = 0 // FUTEX_WAIT, shared between processes
+	futexWake = 1 // FUTEX_WAKE, shared between processes
+
+	// futexPollInterval bounds a single FUTEX_WAIT so that context
+	// cancellation is noticed even if no writer ever wakes us.
+	futexPollInterval = 50 * time.Millisecond
+)
+
+// WithFutex makes every write bump the futex word in the header and wake
+// waiters blocked in WaitForDataFutex, in this or any other process mapping
+// the same file. Writers that do not use this option never wake anyone.
+func WithFutex() Option {
+	return func(o *options) {
+		o.futex = true
+	}
+}
+
+// futexWord returns the futex word in the header
+func (r *RingBuffer) futexWord() *uint32 {
+	return (*uint32)(unsafe.Pointer(&r.buf[futexOffset]))
+}
+
+// futexWake bumps the futex word and wakes all waiters
+func (r *RingBuffer) futexWake() {
+	word := r.futexWord()
+	atomic.AddUint32(word, 1)
+	syscall.Syscall6(syscall.SYS_FUTEX, uintptr(unsafe.Pointer(word)), futexWake, math.MaxInt32, 0, 0, 0)
+}
+
+// WaitForDataFutex blocks until the buffer has a message to read, ctx is
+// done, or the buffer is closed. Wakeups come from writers using WithFutex.
+// A nil return does not reserve the message: a concurrent reader may still
+// take it first.
+func (r *RingBuffer) WaitForDataFutex(ctx context.Context) error {
+	for {
+		r.readMu.Lock()
+		if r.closed {
+			r.readMu.Unlock()
+			return ErrClosed
+		}
+		word := r.futexWord()
+		// Load the word before checking for data so a write in between
+		// changes it and makes FUTEX_WAIT return immediately.
+		seq := atomic.LoadUint32(word)
+		head, tail, err := r.loadHeadTail()
+		empty := err == nil && r.full.empty(r, head, tail)
+		r.readMu.Unlock()
+
+		if err != nil {
+			return err
+		}
+		if !empty {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		wait := futexPollInterval
+		if deadline, ok := ctx.Deadline(); ok {
+			if d := time.Until(deadline); d < wait {
+				wait = d
+			}
+		}
+		if wait <= 0 {
+			continue
+		}
+		// The word is waited on outside readMu; Close waits for us
+		if !r.enter() {
+			return ErrClosed
+		}
+		ts := syscall.NsecToTimespec(int64(wait))
+		_, _, errno := syscall.Syscall6(syscall.SYS_FUTEX, uintptr(unsafe.Pointer(word)), futexWait, uintptr(seq), uintptr(unsafe.Pointer(&ts)), 0, 0)
+		r.leave()
+		switch errno {
+		case 0, syscall.EAGAIN, syscall.EINTR, syscall.ETIMEDOUT:
+			// Woken, the word changed, or the wait ran out: check again
+		default:
+			return fmt.Errorf("ringbuffer: futex wait: %w", errno)
+		}
+	}
+}
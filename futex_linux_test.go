@@ -0,0 +1,88 @@
+//go:build linux
+
+package ringbuffer
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFutexWakeAcrossHandles(t *testing.T) {
+	filename := "/tmp/test_rb_futex.mmap"
+	reader, err := NewRingBuffer(filename, 1024, true, WithFutex())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer reader.Close()
+	defer os.Remove(filename)
+
+	// A second mapping of the same file stands in for another process
+	writer, err := OpenRingBuffer(filename, WithFutex())
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	defer writer.Close()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		writer.WriteMsg([]byte("wake up"))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := reader.WaitForDataFutex(ctx); err != nil {
+		t.Fatalf("Failed waiting for data: %v", err)
+	}
+
+	readMsg, err := reader.ReadMsg()
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if string(readMsg) != "wake up" {
+		t.Errorf("Message mismatch. Got: %s, Want: wake up", string(readMsg))
+	}
+}
+
+func TestFutexWaitCancelled(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_futex_cancel.mmap", 1024, true, WithFutex())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_futex_cancel.mmap")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := rb.WaitForDataFutex(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+	}
+}
+
+func TestFutexWaitClosed(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_futex_close.mmap", 1024, true, WithFutex())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_futex_close.mmap")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- rb.WaitForDataFutex(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// Close wakes the waiter and only unmaps once it has left the futex
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Failed to close ring buffer: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != ErrClosed {
+			t.Errorf("Expected ErrClosed from the waiter, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Close to release the futex waiter")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !linux
+
+package ringbuffer
+
+// futexWake is a no-op where futexes are not available; WithFutex is Linux only.
+func (r *RingBuffer) futexWake() {}
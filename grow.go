@@ -0,0 +1,125 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"sync/atomic"
+	"syscall"
+)
+
+// WithAutoGrow lets a buffer created small grow up to maxSize bytes instead
+// of reporting it is full. A write that does not fit doubles the file
+// (capped at maxSize) and moves the pending messages to the start of the
+// new data region, repeating until the message fits or the cap is reached;
+// only then does the full policy apply. Messages larger than MaxMsgSize
+// also grow the buffer if they fit at maxSize. Only WriteMsg and
+// WriteMsgFlags grow the buffer; WriteFramed and Splice do not.
+//
+// Growing remaps the file, so the buffer must not be used by other
+// processes or other handles while it can grow: they would keep the old
+// size and see a corrupt header. A crash during growth loses the pending
+// messages. The option cannot be combined with reader groups, WithFutex,
+// WithConcurrentWriters, WithHugePages or regions.
+func WithAutoGrow(maxSize int) Option {
+	return func(o *options) {
+		o.autoGrow = maxSize
+	}
+}
+
+// canGrow reports whether the buffer is below its WithAutoGrow cap
+func (r *RingBuffer) canGrow() bool {
+	return r.file != nil && r.size < r.opts.autoGrow
+}
+
+// grow doubles the buffer, up to the WithAutoGrow cap, keeping the pending
+// messages in order. Caller must hold writeMu.
+func (r *RingBuffer) grow() error {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	// Collect the pending frames before the layout changes
+	frames, err := r.pendingFrames()
+	if err != nil {
+		return err
+	}
+
+	size := min(2*r.size, r.opts.autoGrow)
+	if err := r.file.Truncate(int64(size)); err != nil {
+		return fmt.Errorf("ringbuffer: grow %s to %d bytes: %w", r.name, size, err)
+	}
+	mem, err := mmapFile(r.file, 0, size, r.opts)
+	if err != nil {
+		// Reopening must see the old size, which the layout depends on
+		_ = r.file.Truncate(int64(r.size))
+		return mmapError(r.name, 0, size, err)
+	}
+	// Lock-free operations read the mapping without writeMu or readMu, so
+	// keep new ones out and wait for the running ones before swapping it
+	r.growing.Store(true)
+	r.awaitInflight()
+	// A private mapping does not see the header in the file
+	copy(mem[:r.dataStart], r.buf[:r.dataStart])
+	if err := syscall.Munmap(r.mem); err != nil {
+		r.growing.Store(false)
+		syscall.Munmap(mem)
+		_ = r.file.Truncate(int64(r.size))
+		return fmt.Errorf("ringbuffer: munmap %s: %w", r.name, err)
+	}
+	r.buf, r.mem, r.size = mem[:size:size], mem, size
+	r.growing.Store(false)
+
+	// The messages fit without wrapping since the buffer only grew
+	r.relayout(frames)
+	r.warnf("buffer full, grew to %d bytes", size)
+	return nil
+}
+
+// pendingFrame is a buffered frame with a copy of its payload, still
+// sealed with WithEncryption
+type pendingFrame struct {
+	f       frame
+	payload []byte
+}
+
+// pendingFrames copies out the frames between tail and head, oldest first.
+// Caller must hold readMu.
+func (r *RingBuffer) pendingFrames() ([]pendingFrame, error) {
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return nil, err
+	}
+	var frames []pendingFrame
+	for left, pos := r.used(head, tail), tail; left > 0; {
+		f, err := r.frameBefore(pos, left)
+		if err != nil {
+			return nil, err
+		}
+		payload := make([]byte, f.msgLen)
+		r.copyOut(f.payload(), payload)
+		frames = append(frames, pendingFrame{f, payload})
+		left -= f.footprint()
+		pos = f.next
+	}
+	return frames, nil
+}
+
+// relayout writes frames back from the start of the data region, which
+// they must fit in without wrapping, makes them the buffer's contents and
+// increments the generation. Caller must hold writeMu and readMu.
+func (r *RingBuffer) relayout(frames []pendingFrame) {
+	pos, total := r.dataStart, uint32(0)
+	for _, p := range frames {
+		f := r.frameFrom(pos)
+		f.msgLen, f.flags, f.time, f.seq, f.repeat = p.f.msgLen, p.f.flags, p.f.time, p.f.seq, p.f.repeat
+		f.next = r.putLink(f, r.copyIn(f.payload(), p.payload))
+		r.putPrefix(f)
+		pos = f.next
+		total += f.footprint()
+	}
+	r.setTail(r.dataStart)
+	atomic.StoreUint32(r.usedPtr(), 0)
+	r.advanceHead(pos, total)
+	r.reversePos, r.chunkOff = 0, 0
+	r.forgetCached()
+	// Offsets taken before no longer point at the same messages
+	atomic.AddUint32(r.generationPtr(), 1)
+}
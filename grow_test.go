@@ -0,0 +1,172 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestRingBufferAutoGrow(t *testing.T) {
+	filename := "/tmp/test_rb_grow.mmap"
+	rb, err := NewRingBuffer(filename, 256, true, WithAutoGrow(2048), WithBackLinks())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+
+	// Wrap the head around before growing so pending messages straddle the end
+	for i := 0; i < 8; i++ {
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("warmup-%02d-0123456789", i))); !ok || err != nil {
+			t.Fatalf("Failed to write warmup message: %v", err)
+		}
+		if _, err := rb.ReadMsg(); err != nil {
+			t.Fatalf("Failed to read warmup message: %v", err)
+		}
+	}
+
+	written := 0
+	for {
+		ok, err := rb.WriteMsg([]byte(fmt.Sprintf("message-%03d-0123456789", written)))
+		if err == ErrBufferFull {
+			break
+		}
+		if !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", written, err)
+		}
+		written++
+	}
+	if rb.size != 2048 {
+		t.Errorf("Expected the buffer to grow to the 2048-byte cap, got %d", rb.size)
+	}
+	if info, err := os.Stat(filename); err != nil || info.Size() != 2048 {
+		t.Errorf("Expected a 2048-byte file, got: %v (%v)", info, err)
+	}
+	if written*30 < 2048-256 {
+		t.Errorf("Expected growth to make room for more messages, wrote %d", written)
+	}
+
+	// Back-links were rewritten for the new layout
+	if msg, err := rb.ReadMsgReverse(); err != nil || string(msg) != fmt.Sprintf("message-%03d-0123456789", written-1) {
+		t.Errorf("Expected newest message from ReadMsgReverse, got %q (%v)", msg, err)
+	}
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Failed to close ring buffer: %v", err)
+	}
+
+	// The grown file reopens with every message in order
+	rb, err = OpenRingBuffer(filename, WithBackLinks())
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	defer rb.Close()
+	for i := 0; i < written; i++ {
+		msg, err := rb.ReadMsg()
+		if want := fmt.Sprintf("message-%03d-0123456789", i); err != nil || string(msg) != want {
+			t.Fatalf("Expected %q, got %q (%v)", want, msg, err)
+		}
+	}
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty after all messages, got: %v", err)
+	}
+}
+
+func TestRingBufferAutoGrowLargeMessage(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_grow.mmap", 256, true, WithAutoGrow(1024), WithStrategy(StrategyCount))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_grow.mmap")
+	defer rb.Close()
+
+	if ok, err := rb.WriteMsg([]byte("small")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	large := make([]byte, 600)
+	if ok, err := rb.WriteMsg(large); !ok || err != nil {
+		t.Fatalf("Failed to write message larger than the initial size: %v", err)
+	}
+	if rb.size != 1024 {
+		t.Errorf("Expected the buffer to grow to 1024 bytes, got %d", rb.size)
+	}
+	if _, err := rb.WriteMsg(make([]byte, 2000)); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("Expected ErrMessageTooLarge past the cap, got: %v", err)
+	}
+
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "small" {
+		t.Errorf("Expected the pending message to survive growth, got %q (%v)", msg, err)
+	}
+	if msg, err := rb.ReadMsg(); err != nil || len(msg) != len(large) {
+		t.Errorf("Expected the large message, got %d bytes (%v)", len(msg), err)
+	}
+}
+
+func TestRingBufferAutoGrowConcurrentPoll(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_grow.mmap", 256, true, WithAutoGrow(1<<16))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_grow.mmap")
+	defer rb.Close()
+
+	// Lock-free calls racing with growth must neither fault nor see the
+	// old mapping once it is unmapped
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			rb.IsEmpty()
+			rb.Lag()
+			rb.GetHeadTail()
+			runtime.Gosched()
+		}
+	}()
+
+	written := 0
+	for ; ; written++ {
+		ok, err := rb.WriteMsg([]byte(fmt.Sprintf("message-%04d-0123456789", written)))
+		if err == ErrBufferFull {
+			break
+		}
+		if !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", written, err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if rb.size != 1<<16 {
+		t.Errorf("Expected the buffer to grow to the %d-byte cap, got %d", 1<<16, rb.size)
+	}
+	if lag := rb.Lag(); lag == 0 || rb.IsEmpty() {
+		t.Errorf("Expected pending messages after growth, got a lag of %d", lag)
+	}
+	for i := 0; i < written; i++ {
+		msg, err := rb.ReadMsg()
+		if want := fmt.Sprintf("message-%04d-0123456789", i); err != nil || string(msg) != want {
+			t.Fatalf("Expected %q, got %q (%v)", want, msg, err)
+		}
+	}
+}
+
+func TestRingBufferAutoGrowIncompatible(t *testing.T) {
+	defer os.Remove("/tmp/test_rb_grow.mmap")
+	for _, opts := range [][]Option{
+		{WithAutoGrow(1024), WithReaderGroups(1)},
+		{WithAutoGrow(1024), WithConcurrentWriters()},
+		{WithAutoGrow(-1)},
+	} {
+		if _, err := NewRingBuffer("/tmp/test_rb_grow.mmap", 256, true, opts...); err == nil {
+			t.Errorf("Expected an error for incompatible options")
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package ringbuffer
+
+import (
+	"os"
+	"syscall"
+)
+
+// WithGuardPages maps an inaccessible page after the buffer, so a stray
+// access past its end, such as an unsafe pointer computed from a bad
+// offset, faults right where it happens instead of silently reading or
+// corrupting whatever is mapped next. Accesses through Go slices are
+// bounds checked anyway; the guard catches the rest. It covers overruns
+// that cross the page boundary after the buffer, so with a size that is a
+// multiple of the page size the first byte past the end faults.
+//
+// It is meant for development and costs one page of address space per
+// handle. Huge pages are not supported. It needs mprotect, so it works on
+// Linux and macOS; elsewhere creating the buffer fails with
+// errors.ErrUnsupported.
+func WithGuardPages() Option {
+	return func(o *options) {
+		o.guardPages = true
+	}
+}
+
+// mmapFile maps length bytes of f from offset, which must be page aligned,
+// and with WithGuardPages an inaccessible page after them, rounding length
+// up to a whole page first. Callers slice the buffer from the start of the
+// result and release all of it with syscall.Munmap.
+func mmapFile(f *os.File, offset int64, length int, o options) ([]byte, error) {
+	if !o.guardPages {
+		return syscall.Mmap(int(f.Fd()), offset, length, syscall.PROT_READ|syscall.PROT_WRITE, o.mmapFlags())
+	}
+	pageSize := os.Getpagesize()
+	mapped := (length + pageSize - 1) &^ (pageSize - 1)
+	// The guard page may lie past the end of the file, which mmap allows
+	mem, err := syscall.Mmap(int(f.Fd()), offset, mapped+pageSize, syscall.PROT_READ|syscall.PROT_WRITE, o.mmapFlags())
+	if err != nil {
+		return nil, err
+	}
+	if err := protectGuard(mem[mapped:]); err != nil {
+		syscall.Munmap(mem)
+		return nil, err
+	}
+	return mem, nil
+}
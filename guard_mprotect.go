@@ -0,0 +1,10 @@
+//go:build linux || darwin
+
+package ringbuffer
+
+import "syscall"
+
+// protectGuard makes the guard page mapped by mmapFile inaccessible
+func protectGuard(page []byte) error {
+	return syscall.Mprotect(page, syscall.PROT_NONE)
+}
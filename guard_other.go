@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package ringbuffer
+
+import "errors"
+
+// protectGuard fails where the syscall package has no mprotect;
+// WithGuardPages is Linux and macOS only.
+func protectGuard(page []byte) error {
+	return errors.ErrUnsupported
+}
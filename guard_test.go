@@ -0,0 +1,108 @@
+//go:build linux || darwin
+
+package ringbuffer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"testing"
+)
+
+// TestGuardPages runs ordinary traffic with guard pages. An access that
+// reaches a guard page crashes the test binary with SIGSEGV rather than
+// failing the test.
+func TestGuardPages(t *testing.T) {
+	const filename = "/tmp/test_rb_guard.mmap"
+	defer os.Remove(filename)
+
+	traffic := func(rb *RingBuffer) {
+		t.Helper()
+		for i := 0; i < 200; i++ {
+			msg := bytes.Repeat([]byte{byte(i)}, 1+i%90)
+			if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+				t.Fatalf("Failed to write message %d: %v", i, err)
+			}
+			if got, err := rb.ReadMsg(); err != nil || !bytes.Equal(got, msg) {
+				t.Fatalf("Expected message %d back, got %d bytes (%v)", i, len(got), err)
+			}
+		}
+	}
+
+	// Sizes below, at and past a page boundary
+	pageSize := os.Getpagesize()
+	for _, size := range []int{1000, pageSize, pageSize + 100} {
+		rb, err := NewRingBuffer(filename, size, true, WithGuardPages(), WithBackLinks())
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer of %d bytes: %v", size, err)
+		}
+		traffic(rb)
+		rb.Close()
+
+		rb, err = OpenRingBuffer(filename, WithGuardPages(), WithBackLinks())
+		if err != nil {
+			t.Fatalf("Failed to open ring buffer of %d bytes: %v", size, err)
+		}
+		traffic(rb)
+		rb.Close()
+	}
+
+	grown, err := NewRingBuffer(filename, 256, true, WithGuardPages(), WithAutoGrow(4096))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	for i := 0; grown.size < 4096; i++ {
+		if ok, err := grown.WriteMsg([]byte(fmt.Sprintf("message %d", i))); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	for {
+		if _, err := grown.ReadMsg(); err == ErrBufferEmpty {
+			break
+		} else if err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+	}
+	traffic(grown)
+	grown.Close()
+
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer f.Close()
+	if err := f.Truncate(2000); err != nil {
+		t.Fatalf("Failed to size file: %v", err)
+	}
+	region, err := NewRingBufferRegion(f, 1000, 1000, WithGuardPages())
+	if err != nil {
+		t.Fatalf("Failed to create region: %v", err)
+	}
+	traffic(region)
+	region.Close()
+}
+
+func TestGuardPagesFault(t *testing.T) {
+	const filename = "/tmp/test_rb_guard_fault.mmap"
+	rb, err := NewRingBuffer(filename, os.Getpagesize(), true, WithGuardPages())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	defer rb.Close()
+
+	// Turn the fault into a panic instead of crashing the test binary
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		// A fault carries its address, unlike a bounds check panic
+		if _, ok := recover().(interface{ Addr() uintptr }); !ok {
+			t.Errorf("Expected the byte past the buffer to fault")
+		}
+	}()
+	past := rb.mem[:len(rb.buf)+1]
+	guardSink = past[len(rb.buf)]
+}
+
+// guardSink keeps the faulting read from being optimized away
+var guardSink byte
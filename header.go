@@ -0,0 +1,88 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupportedVersion is returned when opening a file written with a
+// different layout version, older or newer. It matches ErrCorruptHeader
+// with errors.Is.
+var ErrUnsupportedVersion = fmt.Errorf("unsupported ring buffer layout version: %w", ErrCorruptHeader)
+
+// ErrBigEndian is returned by every constructor on a big-endian host. The
+// file format is little-endian, but head, tail, the used-bytes counter and
+// the generation are accessed with native atomic operations, which would
+// read and write them byte-swapped there.
+var ErrBigEndian = errors.New("big-endian hosts are not supported")
+
+// littleEndian reports whether the host stores integers little-endian
+var littleEndian = binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+
+// headerField is a named field of the header
+type headerField struct {
+	name   string
+	offset int
+	size   int // 2, 4 or 8 bytes, little-endian
+}
+
+// The header fields. Fields other processes update concurrently (head,
+// tail, used, futex, generation) are accessed atomically through pointers
+// on the hot paths; get and put are for the others, and for setting up a
+// mapping no one else uses yet.
+var (
+	fieldFormat     = headerField{"format", formatOffset, 4}
+	fieldHead       = headerField{"head", headOffset, 4}
+	fieldTail       = headerField{"tail", tailOffset, 4}
+	fieldUsed       = headerField{"used", usedOffset, 4}
+	fieldFutex      = headerField{"futex", futexOffset, 4}
+	fieldGroups     = headerField{"groups", groupsOffset, 2}
+	fieldClean      = headerField{"clean", cleanOffset, 2}
+	fieldGeneration = headerField{"generation", generationOffset, 4}
+	fieldFeatures   = headerField{"features", featuresOffset, 4}
+)
+
+// headerLayout lists the header fields in offset order. They are followed
+// by the reserved region up to headerSize, which is zero in every file. A
+// new field takes the next bytes of the reserved region and treats zero as
+// its default, so files written before it stay valid and the layout
+// version does not change.
+var headerLayout = []headerField{
+	fieldFormat,
+	fieldHead,
+	fieldTail,
+	fieldUsed,
+	fieldFutex,
+	fieldGroups,
+	fieldClean,
+	fieldGeneration,
+	fieldFeatures,
+}
+
+// get returns the field's value in the header at the start of buf
+func (f headerField) get(buf []byte) uint64 {
+	b := buf[f.offset : f.offset+f.size]
+	switch f.size {
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(b))
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(b))
+	default:
+		return binary.LittleEndian.Uint64(b)
+	}
+}
+
+// put stores v, truncated to the field's size, in the header at the start
+// of buf
+func (f headerField) put(buf []byte, v uint64) {
+	b := buf[f.offset : f.offset+f.size]
+	switch f.size {
+	case 2:
+		binary.LittleEndian.PutUint16(b, uint16(v))
+	case 4:
+		binary.LittleEndian.PutUint32(b, uint32(v))
+	default:
+		binary.LittleEndian.PutUint64(b, v)
+	}
+}
@@ -0,0 +1,141 @@
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestHeaderLayout(t *testing.T) {
+	// Fields are in offset order, do not overlap and end at the reserved
+	// region
+	next := 0
+	for _, f := range headerLayout {
+		if f.offset != next {
+			t.Errorf("Field %s at %d, expected %d", f.name, f.offset, next)
+		}
+		if f.size != 2 && f.size != 4 && f.size != 8 {
+			t.Errorf("Field %s has unsupported size %d", f.name, f.size)
+		}
+		next = f.offset + f.size
+	}
+	if next != reservedOffset {
+		t.Errorf("Fields end at %d, expected the reserved region at %d", next, reservedOffset)
+	}
+	if headerSize%8 != 0 {
+		t.Errorf("Header size %d leaves the data region unaligned", headerSize)
+	}
+}
+
+func TestHeaderRoundTrip(t *testing.T) {
+	const filename = "/tmp/test_rb_header.mmap"
+	rb, err := NewRingBuffer(filename, 1024, true, WithStrategy(StrategyCount), WithFlags())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	for _, msg := range []string{"one", "two"} {
+		if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	head, tail := rb.GetHeadTail()
+	generation := rb.Generation()
+	format := rb.opts.format()
+	rb.Close()
+
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	want := map[string]uint64{
+		"format":     uint64(format),
+		"head":       uint64(head),
+		"tail":       uint64(tail),
+		"used":       uint64(2 * (4 + 1 + 3)),
+		"groups":     0,
+		"clean":      cleanFlag,
+		"generation": uint64(generation),
+		"features":   0,
+	}
+	// The futex word counts wake-ups, which Close adds to
+	for _, f := range headerLayout {
+		if w, ok := want[f.name]; ok && f.get(buf) != w {
+			t.Errorf("Field %s is %d, expected %d", f.name, f.get(buf), w)
+		}
+	}
+	for i := reservedOffset; i < headerSize; i++ {
+		if buf[i] != 0 {
+			t.Fatalf("Reserved byte %d is %d, expected 0", i, buf[i])
+		}
+	}
+
+	// put writes what get reads, at the field's size only
+	for _, f := range headerLayout {
+		scratch := make([]byte, headerSize)
+		f.put(scratch, 1<<(8*f.size)-2)
+		if got := f.get(scratch); got != 1<<(8*f.size)-2 {
+			t.Errorf("Field %s read back %d", f.name, got)
+		}
+		for i, b := range scratch {
+			if b != 0 && (i < f.offset || i >= f.offset+f.size) {
+				t.Errorf("Field %s wrote byte %d outside it", f.name, i)
+			}
+		}
+	}
+
+	rb, err = OpenRingBuffer(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	defer rb.Close()
+	if h, tl := rb.GetHeadTail(); h != head || tl != tail {
+		t.Errorf("Expected head %d and tail %d after reopening, got %d and %d", head, tail, h, tl)
+	}
+	if !rb.WasCleanlyClosed() || rb.Generation() != generation {
+		t.Errorf("Expected a clean close and generation %d, got %v and %d", generation, rb.WasCleanlyClosed(), rb.Generation())
+	}
+}
+
+func TestHeaderUnsupportedVersion(t *testing.T) {
+	const filename = "/tmp/test_rb_header_version.mmap"
+	rb, err := NewRingBuffer(filename, 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	format := rb.opts.format()
+	rb.Close()
+
+	// An older layout and a newer one are both refused
+	for _, version := range []uint32{formatVersion - 1, formatVersion + 1} {
+		buf := make([]byte, 4)
+		fieldFormat.put(buf, uint64(format&^(0xf<<16)|version<<16))
+		f, err := os.OpenFile(filename, os.O_RDWR, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open file: %v", err)
+		}
+		if _, err := f.WriteAt(buf, formatOffset); err != nil {
+			t.Fatalf("Failed to write format: %v", err)
+		}
+		f.Close()
+
+		_, err = OpenRingBuffer(filename)
+		if !errors.Is(err, ErrUnsupportedVersion) || !errors.Is(err, ErrCorruptHeader) {
+			t.Errorf("Expected ErrUnsupportedVersion for version %d, got: %v", version, err)
+		}
+	}
+}
+
+func TestBigEndianRefused(t *testing.T) {
+	defer func(le bool) { littleEndian = le }(littleEndian)
+	littleEndian = false
+
+	defer os.Remove("/tmp/test_rb_endian.mmap")
+	if _, err := NewRingBuffer("/tmp/test_rb_endian.mmap", 1024, true); err != ErrBigEndian {
+		t.Errorf("Expected ErrBigEndian from NewRingBuffer, got: %v", err)
+	}
+	if _, err := FromBytes(make([]byte, 1024), true); err != ErrBigEndian {
+		t.Errorf("Expected ErrBigEndian from FromBytes, got: %v", err)
+	}
+}
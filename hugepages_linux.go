@@ -0,0 +1,42 @@
+//go:build linux
+
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// hugetlbfsMagic is the file system type reported by statfs for hugetlbfs.
+// Statfs_t.Type is signed on some architectures and this does not fit in
+// an int32, so it is compared as a uint32.
+const hugetlbfsMagic uint32 = 0x958458f6
+
+// WithHugePages backs the buffer with huge pages, which reduces TLB misses
+// for large buffers. The file must live on a hugetlbfs mount (for example
+// /dev/hugepages) and the size must be a multiple of the huge page size;
+// otherwise NewRingBuffer and OpenRingBuffer fail with ErrHugePages. Enough
+// huge pages must be reserved (vm.nr_hugepages) for the mapping to succeed.
+// Regions do not support huge pages.
+func WithHugePages() Option {
+	return func(o *options) {
+		o.hugePages = true
+	}
+}
+
+// checkHugePages verifies that f is on hugetlbfs and that size is a whole
+// number of huge pages
+func checkHugePages(f *os.File, size int64) error {
+	var st syscall.Statfs_t
+	if err := syscall.Fstatfs(int(f.Fd()), &st); err != nil {
+		return fmt.Errorf("ringbuffer: statfs %s: %w", f.Name(), err)
+	}
+	if uint32(st.Type) != hugetlbfsMagic {
+		return fmt.Errorf("ringbuffer: %s is not on a hugetlbfs mount: %w", f.Name(), ErrHugePages)
+	}
+	if size%int64(st.Bsize) != 0 {
+		return fmt.Errorf("ringbuffer: size %d of %s is not a multiple of the huge page size %d: %w", size, f.Name(), st.Bsize, ErrHugePages)
+	}
+	return nil
+}
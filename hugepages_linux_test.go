@@ -0,0 +1,43 @@
+//go:build linux
+
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestHugePagesRejectsRegularFile(t *testing.T) {
+	_, err := NewRingBuffer("/tmp/test_rb_hugepages.mmap", 2<<20, true, WithHugePages())
+	defer os.Remove("/tmp/test_rb_hugepages.mmap")
+	if !errors.Is(err, ErrHugePages) {
+		t.Errorf("Expected ErrHugePages for a file outside hugetlbfs, got: %v", err)
+	}
+}
+
+func TestHugePagesGuardPages(t *testing.T) {
+	defer os.Remove("/tmp/test_rb_hugepages.mmap")
+	if _, err := NewRingBuffer("/tmp/test_rb_hugepages.mmap", 4096, true, WithGuardPages(), WithHugePages()); !errors.Is(err, ErrIncompatibleOptions) {
+		t.Errorf("Expected ErrIncompatibleOptions with guard pages, got %v", err)
+	}
+}
+
+func TestHugePages(t *testing.T) {
+	// Needs a hugetlbfs mount with at least one free huge page
+	filename := "/dev/hugepages/test_rb_hugepages.mmap"
+	rb, err := NewRingBuffer(filename, 2<<20, true, WithHugePages())
+	if err != nil {
+		t.Skipf("Huge pages not available: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove(filename)
+
+	if ok, err := rb.WriteMsg([]byte("hello")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	readMsg, err := rb.ReadMsg()
+	if err != nil || string(readMsg) != "hello" {
+		t.Errorf("Failed to read message back: %s (%v)", string(readMsg), err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package ringbuffer
+
+import "os"
+
+// checkHugePages is never reached where huge pages are not available;
+// WithHugePages is Linux only.
+func checkHugePages(f *os.File, size int64) error {
+	return ErrHugePages
+}
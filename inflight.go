@@ -0,0 +1,41 @@
+package ringbuffer
+
+import "time"
+
+// inflightPollInterval is how often Close and grow check whether the last
+// lock-free operation has left, and how often enter checks whether grow is
+// done
+const inflightPollInterval = 100 * time.Microsecond
+
+// enter registers an operation that touches the mapping without holding
+// writeMu or readMu, so that Close or grow does not unmap it underneath. It
+// waits while grow swaps the mapping and returns false once Close has
+// started; otherwise the caller must call leave.
+func (r *RingBuffer) enter() bool {
+	for {
+		r.inflight.Add(1)
+		if r.closing.Load() {
+			r.leave()
+			return false
+		}
+		if !r.growing.Load() {
+			return true
+		}
+		r.leave()
+		time.Sleep(inflightPollInterval)
+	}
+}
+
+// leave ends an operation started with enter
+func (r *RingBuffer) leave() {
+	r.inflight.Add(-1)
+}
+
+// awaitInflight waits for the operations registered with enter to leave.
+// Close and grow call it after setting closing or growing, so no new ones
+// can start.
+func (r *RingBuffer) awaitInflight() {
+	for r.inflight.Load() > 0 {
+		time.Sleep(inflightPollInterval)
+	}
+}
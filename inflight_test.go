@@ -0,0 +1,132 @@
+package ringbuffer
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRingBufferCloseWaitsForInflight(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		rb, err := NewRingBuffer("/tmp/test_rb_inflight.mmap", 1024, true)
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+
+		// Lock-free and locked calls racing with Close must neither fault
+		// nor see a torn mapping
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for j := 0; j < 200; j++ {
+					rb.GetHeadTail()
+					if _, err := rb.WriteMsg([]byte("x")); err == ErrClosed {
+						return
+					}
+					rb.ReadMsg()
+				}
+			}()
+		}
+		close(start)
+		if err := rb.Close(); err != nil {
+			t.Fatalf("Failed to close ring buffer: %v", err)
+		}
+		wg.Wait()
+
+		if head, tail := rb.GetHeadTail(); head != 0 || tail != 0 {
+			t.Errorf("Expected 0, 0 from GetHeadTail after Close, got %d, %d", head, tail)
+		}
+	}
+	os.Remove("/tmp/test_rb_inflight.mmap")
+}
+
+func TestRingBufferCloseDuringOperations(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"concurrent writers", []Option{WithConcurrentWriters()}},
+		{"block on full", []Option{WithFullPolicy(BlockOnFull)}},
+		{"overwrite on full", []Option{WithFullPolicy(OverwriteOnFull)}},
+		{"latest slot", []Option{WithLatestSlot()}},
+		{"spsc", []Option{WithSPSCFastPath()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				rb, err := NewRingBuffer("/tmp/test_rb_close_race.mmap", 512, true, tc.opts...)
+				if err != nil {
+					t.Fatalf("Failed to create ring buffer: %v", err)
+				}
+
+				// Every call racing with Close either completes or reports
+				// ErrClosed; none may touch the mapping once it is gone
+				expected := func(err error) bool {
+					return err == nil || err == ErrClosed || err == ErrBufferFull || err == ErrBufferEmpty || err == ErrIncompatibleOptions
+				}
+				var wg sync.WaitGroup
+				start := make(chan struct{})
+				errs := make(chan error, 64)
+				var ops atomic.Int32
+				run := func(op func() error) {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						<-start
+						for j := 0; j < 200; j++ {
+							ops.Add(1)
+							if err := op(); err == ErrClosed {
+								return
+							} else if !expected(err) {
+								errs <- err
+								return
+							}
+						}
+					}()
+				}
+				for w := 0; w < 2; w++ {
+					run(func() error {
+						_, err := rb.WriteMsg([]byte("message"))
+						return err
+					})
+				}
+				run(func() error {
+					_, err := rb.ReadMsg()
+					return err
+				})
+				run(func() error {
+					_, err := rb.PendingSizes(4)
+					return err
+				})
+				run(func() error {
+					rb.Lag()
+					rb.Generation()
+					if _, err := rb.Stat(); err != nil {
+						return err
+					}
+					return rb.Sync()
+				})
+				close(start)
+				// Close at a different point of the traffic every round
+				for ops.Load() < int32(10*i) {
+					runtime.Gosched()
+				}
+				if err := rb.Close(); err != nil {
+					t.Fatalf("Failed to close ring buffer: %v", err)
+				}
+				wg.Wait()
+				close(errs)
+				for err := range errs {
+					t.Errorf("Unexpected error racing with Close: %v", err)
+				}
+			}
+			os.Remove("/tmp/test_rb_close_race.mmap")
+		})
+	}
+}
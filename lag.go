@@ -0,0 +1,80 @@
+package ringbuffer
+
+import (
+	"sync"
+	"time"
+)
+
+// Lag returns how far the consumer is behind the producer: the bytes
+// between tail and head, frame prefixes included, that are still waiting
+// to be read. It takes no lock, so a write or read running at the same
+// time may or may not be counted. Returns 0 once the buffer is closed or if
+// the header is corrupt.
+func (r *RingBuffer) Lag() int {
+	if !r.enter() {
+		return 0
+	}
+	defer r.leave()
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return 0
+	}
+	return int(r.used(head, tail))
+}
+
+// IsEmpty reports whether the buffer has no message to read, from one
+// atomic load each of head and tail and without taking readMu, so a reader
+// polling a mostly idle buffer can skip ReadMsg while it is empty. The
+// answer is an estimate: a message written right after the check is only
+// seen by the next call, and another reader may empty the buffer right
+// after false, so ReadMsg can still return ErrBufferEmpty.
+// Returns false once the buffer is closed or if the header is corrupt, so
+// the caller goes on to ReadMsg and sees the error. Ignores reader groups,
+// whose readers track their own offsets.
+func (r *RingBuffer) IsEmpty() bool {
+	if !r.enter() {
+		return false
+	}
+	defer r.leave()
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return false
+	}
+	return r.full.empty(r, head, tail)
+}
+
+// StartLagWatchdog samples Lag every interval in a new goroutine and calls
+// cb with the lag when it rises above threshold, so alerting can catch a
+// stalled consumer before the buffer fills. cb is not called again until
+// the lag has dropped back to threshold or below and risen above it once
+// more. The watchdog runs until the returned stop function is called or
+// the buffer is closed. interval must be positive.
+func (r *RingBuffer) StartLagWatchdog(threshold int, interval time.Duration, cb func(lag int)) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		above := false
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if r.closing.Load() {
+				return
+			}
+			lag := r.Lag()
+			if lag > threshold && !above {
+				cb(lag)
+			}
+			above = lag > threshold
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
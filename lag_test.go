@@ -0,0 +1,142 @@
+package ringbuffer
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLag(t *testing.T) {
+	for _, s := range []Strategy{StrategySentinel, StrategyCount} {
+		rb, err := NewRingBuffer("/tmp/test_rb_lag.mmap", headerSize+224, true, WithStrategy(s))
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+		if lag := rb.Lag(); lag != 0 {
+			t.Errorf("Expected no lag on an empty buffer, got %d", lag)
+		}
+		for i := 0; i < 3; i++ {
+			if ok, err := rb.WriteMsg(make([]byte, 50)); !ok || err != nil {
+				t.Fatalf("Failed to write message: %v", err)
+			}
+		}
+		if lag, want := rb.Lag(), 3*rb.FrameSize(50); lag != want {
+			t.Errorf("Expected lag %d after three writes, got %d", want, lag)
+		}
+		if _, err := rb.ReadMsg(); err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+		if lag, want := rb.Lag(), 2*rb.FrameSize(50); lag != want {
+			t.Errorf("Expected lag %d after a read, got %d", want, lag)
+		}
+		rb.Close()
+		os.Remove("/tmp/test_rb_lag.mmap")
+		if lag := rb.Lag(); lag != 0 {
+			t.Errorf("Expected no lag once closed, got %d", lag)
+		}
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	for _, s := range []Strategy{StrategySentinel, StrategyCount} {
+		rb, err := NewRingBuffer("/tmp/test_rb_isempty.mmap", headerSize+64, true, WithStrategy(s))
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+		if !rb.IsEmpty() {
+			t.Errorf("%v: expected a new buffer to be empty", s)
+		}
+		// Fill it completely, which the sentinel strategy must not take
+		// for empty either
+		for {
+			if ok, _ := rb.WriteMsg([]byte("message")); !ok {
+				break
+			}
+			if rb.IsEmpty() {
+				t.Errorf("%v: expected a buffer with messages not to be empty", s)
+			}
+		}
+		for !rb.IsEmpty() {
+			if _, err := rb.ReadMsg(); err != nil {
+				t.Fatalf("%v: expected a message while not empty, got: %v", s, err)
+			}
+		}
+		if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+			t.Errorf("%v: expected ErrBufferEmpty once empty, got: %v", s, err)
+		}
+		rb.Close()
+		if rb.IsEmpty() {
+			t.Errorf("%v: expected false after Close", s)
+		}
+		os.Remove("/tmp/test_rb_isempty.mmap")
+	}
+}
+
+func TestLagWatchdog(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_lag.mmap", headerSize+224, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_lag.mmap")
+	defer rb.Close()
+
+	fired := make(chan int, 10)
+	stop := rb.StartLagWatchdog(100, time.Millisecond, func(lag int) { fired <- lag })
+	defer stop()
+
+	expectFire := func() {
+		t.Helper()
+		select {
+		case lag := <-fired:
+			if lag <= 100 {
+				t.Errorf("Expected a lag above the threshold, got %d", lag)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the watchdog to fire")
+		}
+	}
+	expectQuiet := func() {
+		t.Helper()
+		select {
+		case lag := <-fired:
+			t.Errorf("Expected no callback, got one with lag %d", lag)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	// Below the threshold nothing fires
+	if ok, err := rb.WriteMsg(make([]byte, 50)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	expectQuiet()
+
+	// Crossing it fires once while the consumer stays stalled
+	if ok, err := rb.WriteMsg(make([]byte, 80)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	expectFire()
+	expectQuiet()
+
+	// Catching up rearms it
+	for i := 0; i < 2; i++ {
+		if _, err := rb.ReadMsg(); err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+	}
+	expectQuiet()
+	if ok, err := rb.WriteMsg(make([]byte, 150)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	expectFire()
+
+	// Stopped, it stays quiet however far behind the consumer falls
+	stop()
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if ok, err := rb.WriteMsg(make([]byte, 150)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	expectQuiet()
+}
@@ -0,0 +1,131 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// latestHeaderSize is the size of the generation and writing counters at
+// the start of the data region with WithLatestSlot
+const latestHeaderSize = 8
+
+// WithLatestSlot turns the buffer into a single-value store for the latest
+// state of something, such as a configuration or a price. WriteMsg replaces
+// the value and ReadMsg returns it without consuming it, so repeated reads
+// return the same value until the next write, and ReadMsg returns
+// ErrBufferEmpty only before the first write. The value is double-buffered:
+// a write fills the slot readers are not using and then publishes it, so a
+// reader never sees a torn value and only retries if two writes complete
+// while it copies. Readers take no lock.
+//
+// Other operations on the buffer, such as Consume or Reserve, return
+// ErrIncompatibleOptions, and the option cannot be combined with options
+// that change the frame layout or the write path. The option is recorded
+// in the file, so OpenRingBuffer may leave it out.
+func WithLatestSlot() Option {
+	return func(o *options) {
+		o.latest = true
+	}
+}
+
+// latestSlotSize returns the size of each of the two value slots in a
+// buffer of bufSize bytes, length field included, rounded down to whole
+// words so every slot access can be atomic
+func (o options) latestSlotSize(bufSize int) int {
+	n := (bufSize - int(o.dataStart()) - latestHeaderSize) / 2 &^ 3
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Slot layout at the start of the data region:
+// [generation(4)][writing(4)][slot 0][slot 1], each slot [length(4)][payload...]
+// generation counts published writes and selects the slot holding the
+// current value; writing is the generation a write in progress will publish.
+
+// genPtr returns the generation counter
+func (r *RingBuffer) genPtr() *uint32 {
+	return r.wordAt(r.dataStart)
+}
+
+// writingPtr returns the generation of the write in progress, or of the
+// last write if none is
+func (r *RingBuffer) writingPtr() *uint32 {
+	return r.wordAt(r.dataStart + 4)
+}
+
+// wordAt returns the 4-byte word at off in the mapping
+func (r *RingBuffer) wordAt(off uint32) *uint32 {
+	return (*uint32)(unsafe.Pointer(&r.buf[off]))
+}
+
+// latestSlot returns the offset of the slot that generation gen is
+// written to
+func (r *RingBuffer) latestSlot(gen uint32) uint32 {
+	return r.dataStart + latestHeaderSize + (gen&1)*uint32(r.opts.latestSlotSize(r.size))
+}
+
+// writeLatest replaces the value with msg
+func (r *RingBuffer) writeLatest(msg []byte) (bool, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if !r.acceptsWrites() {
+		return false, ErrClosed
+	}
+	if uint32(len(msg)) > r.maxPayload() {
+		return false, ErrMessageTooLarge
+	}
+
+	// Announce the write before touching the slot, which readers of the
+	// generation before the current one may still be copying
+	gen := atomic.LoadUint32(r.genPtr()) + 1
+	atomic.StoreUint32(r.writingPtr(), gen)
+	slot := r.latestSlot(gen)
+	atomic.StoreUint32(r.wordAt(slot), uint32(len(msg)))
+	for i := 0; i < len(msg); i += 4 {
+		var w [4]byte
+		copy(w[:], msg[i:])
+		atomic.StoreUint32(r.wordAt(slot+4+uint32(i)), binary.NativeEndian.Uint32(w[:]))
+	}
+	atomic.StoreUint32(r.genPtr(), gen)
+	r.ringDoorbell()
+	return true, nil
+}
+
+// readLatest returns a copy of the current value
+func (r *RingBuffer) readLatest() ([]byte, error) {
+	if !r.enter() {
+		return nil, ErrClosed
+	}
+	defer r.leave()
+
+	for {
+		gen := atomic.LoadUint32(r.genPtr())
+		if gen == 0 {
+			return nil, ErrBufferEmpty
+		}
+		slot := r.latestSlot(gen)
+		n := atomic.LoadUint32(r.wordAt(slot))
+		var msg []byte
+		if n <= r.maxPayload() {
+			msg = make([]byte, (n+3)&^3)
+			for i := uint32(0); i < n; i += 4 {
+				binary.NativeEndian.PutUint32(msg[i:], atomic.LoadUint32(r.wordAt(slot+4+i)))
+			}
+		}
+
+		// The slot is only written again for generation gen+2, so the copy
+		// is intact unless that write has started
+		if atomic.LoadUint32(r.writingPtr())-gen < 2 {
+			if n > r.maxPayload() {
+				return nil, ErrCorruptHeader
+			}
+			return msg[:n], nil
+		}
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,140 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLatestSlot(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_latest.mmap", 256, true, WithLatestSlot())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_latest.mmap")
+	defer rb.Close()
+
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty before the first write, got: %v", err)
+	}
+
+	// Reads do not consume, and each write replaces the value
+	for _, v := range []string{"first value", "2nd", "a longer third value"} {
+		if ok, err := rb.WriteMsg([]byte(v)); !ok || err != nil {
+			t.Fatalf("Failed to write value: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if msg, err := rb.ReadMsg(); err != nil || string(msg) != v {
+				t.Errorf("Expected %q, got %q (%v)", v, msg, err)
+			}
+		}
+	}
+
+	// Each slot takes half of what is left after the counters
+	max := rb.MaxMsgSize()
+	if want := (256-headerSize-latestHeaderSize)/2&^3 - 4; max != want {
+		t.Errorf("Expected MaxMsgSize %d, got %d", want, max)
+	}
+	full := bytes.Repeat([]byte{'m'}, max)
+	if ok, err := rb.WriteMsg(full); !ok || err != nil {
+		t.Fatalf("Failed to write a value of MaxMsgSize: %v", err)
+	}
+	if _, err := rb.WriteMsg(make([]byte, max+1)); err != ErrMessageTooLarge {
+		t.Errorf("Expected ErrMessageTooLarge, got: %v", err)
+	}
+	if msg, err := rb.ReadMsg(); err != nil || !bytes.Equal(msg, full) {
+		t.Errorf("Expected the %d-byte value, got %d bytes (%v)", max, len(msg), err)
+	}
+
+	// Ring operations do not apply to the slot
+	if err := rb.Consume(1); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions from Consume, got: %v", err)
+	}
+	if _, _, err := rb.PeekHeader(); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions from PeekHeader, got: %v", err)
+	}
+
+	// The value is in the file for other handles
+	other, err := OpenRingBuffer("/tmp/test_rb_latest.mmap", WithLatestSlot())
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	defer other.Close()
+	if msg, err := other.ReadMsg(); err != nil || !bytes.Equal(msg, full) {
+		t.Errorf("Expected the value through another handle, got %d bytes (%v)", len(msg), err)
+	}
+	if plain, err := OpenRingBuffer("/tmp/test_rb_latest.mmap"); err != nil {
+		t.Errorf("Expected the latest slot to be taken from the header, got: %v", err)
+	} else {
+		if msg, err := plain.ReadMsg(); err != nil || !bytes.Equal(msg, full) {
+			t.Errorf("Expected the value without WithLatestSlot, got %d bytes (%v)", len(msg), err)
+		}
+		plain.Close()
+	}
+
+	rb.Close()
+	if _, err := rb.ReadMsg(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after Close, got: %v", err)
+	}
+}
+
+func TestLatestSlotOptions(t *testing.T) {
+	defer os.Remove("/tmp/test_rb_latest.mmap")
+	for _, opt := range []Option{WithFlags(), WithReaderGroups(1), WithConcurrentWriters(), WithEncryption(testKey), WithFullPolicy(BlockOnFull)} {
+		if _, err := NewRingBuffer("/tmp/test_rb_latest.mmap", 256, true, WithLatestSlot(), opt); err != ErrIncompatibleOptions {
+			t.Errorf("Expected ErrIncompatibleOptions, got: %v", err)
+		}
+	}
+}
+
+// TestLatestSlotConcurrent checks that readers on another mapping of the
+// file never see a torn value while a writer keeps replacing it. Every
+// value is one byte repeated as many times as it says.
+func TestLatestSlotConcurrent(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_latest.mmap", 1024, true, WithLatestSlot())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_latest.mmap")
+	defer rb.Close()
+	reader, err := OpenRingBuffer("/tmp/test_rb_latest.mmap", WithLatestSlot())
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	defer reader.Close()
+
+	if ok, err := rb.WriteMsg([]byte{1}); !ok || err != nil {
+		t.Fatalf("Failed to write value: %v", err)
+	}
+
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !done.Load() {
+				msg, err := reader.ReadMsg()
+				if err != nil {
+					t.Errorf("Failed to read value: %v", err)
+					return
+				}
+				if len(msg) != int(msg[0]) || !bytes.Equal(msg, bytes.Repeat(msg[:1], len(msg))) {
+					t.Errorf("Read a torn value of %d bytes starting with %d", len(msg), msg[0])
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 20000; i++ {
+		n := i%255 + 1
+		if ok, err := rb.WriteMsg(bytes.Repeat([]byte{byte(n)}, n)); !ok || err != nil {
+			t.Fatalf("Failed to write value: %v", err)
+		}
+	}
+	done.Store(true)
+	wg.Wait()
+}
@@ -0,0 +1,23 @@
+package ringbuffer
+
+// SetLogger sets fn, with the signature of log.Printf, to receive warnings
+// about events the buffer otherwise handles without a trace: a corrupt
+// header or frame met by a read, a message dropped by OverwriteOnFull and
+// growth under WithAutoGrow. nil, the default, discards them. fn is called
+// with the buffer's locks held, so it must be quick and must not use the
+// buffer. Safe to call at any time.
+func (r *RingBuffer) SetLogger(fn func(format string, args ...any)) {
+	if fn == nil {
+		r.logger.Store(nil)
+		return
+	}
+	r.logger.Store(&fn)
+}
+
+// warnf passes a warning about this buffer to the SetLogger function, if
+// any
+func (r *RingBuffer) warnf(format string, args ...any) {
+	if fn := r.logger.Load(); fn != nil {
+		(*fn)("ringbuffer: %s: "+format, append([]any{r.name}, args...)...)
+	}
+}
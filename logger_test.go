@@ -0,0 +1,53 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetLogger(t *testing.T) {
+	const filename = "/tmp/test_rb_logger.mmap"
+	rb, err := NewRingBuffer(filename, headerSize+64, true, WithFullPolicy(OverwriteOnFull))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove(filename)
+
+	var logged []string
+	rb.SetLogger(func(format string, args ...any) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+
+	// Three 16-byte frames fit, so the last two writes evict the oldest
+	for i := 0; i < 5; i++ {
+		if ok, err := rb.WriteMsg([]byte("twelve bytes")); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+	if len(logged) != 2 || !strings.Contains(logged[0], "dropped the oldest message of 12 bytes") {
+		t.Errorf("Expected two eviction warnings, got %q", logged)
+	}
+
+	// A damaged length is reported as well as returned
+	logged = nil
+	_, tail := rb.GetHeadTail()
+	binary.LittleEndian.PutUint32(rb.buf[tail:], 1<<20)
+	if _, err := rb.ReadMsg(); err != ErrCorruptHeader {
+		t.Fatalf("Expected ErrCorruptHeader, got: %v", err)
+	}
+	if len(logged) != 1 || !strings.HasPrefix(logged[0], "ringbuffer: "+filename+": corrupt frame at tail") {
+		t.Errorf("Expected a corruption warning, got %q", logged)
+	}
+
+	// Removing the logger silences it
+	logged = nil
+	rb.SetLogger(nil)
+	rb.ReadMsg()
+	if len(logged) != 0 {
+		t.Errorf("Expected no warnings without a logger, got %q", logged)
+	}
+}
@@ -0,0 +1,151 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// The LZ4 block format, as produced by the reference implementation's
+// fastest level: a sequence of [token][literal length...][literals]
+// [offset(2)][match length...], where the token holds the literal length
+// and the match length minus 4 in its high and low nibbles, and a nibble
+// of 15 continues in extra bytes. The last sequence has literals only.
+const (
+	lz4MinMatch     = 4      // shortest match the format can encode
+	lz4HashLog      = 12     // log2 of the match finder's table size
+	lz4MFLimit      = 12     // a match must start at least this far from the end
+	lz4LastLiterals = 5      // the last bytes are always literals
+	lz4MaxOffset    = 0xffff // farthest a match can reach back
+)
+
+var errLZ4Corrupt = errors.New("corrupt lz4 block")
+
+// lz4Compress appends src compressed as an LZ4 block to dst. It gives up
+// and returns nil as soon as dst would exceed limit bytes, so
+// incompressible data costs as little as possible.
+func lz4Compress(dst, src []byte, limit int) []byte {
+	var table [1 << lz4HashLog]int32 // position+1 of the last 4 bytes with each hash
+	anchor := 0
+	for i := 0; i+lz4MFLimit <= len(src); {
+		seq := binary.LittleEndian.Uint32(src[i:])
+		h := seq * 2654435761 >> (32 - lz4HashLog)
+		cand := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if cand < 0 || i-cand > lz4MaxOffset || binary.LittleEndian.Uint32(src[cand:]) != seq {
+			i++
+			continue
+		}
+
+		// Extend the match, leaving the last bytes for literals
+		end := i + lz4MinMatch
+		for end < len(src)-lz4LastLiterals && src[end] == src[cand+end-i] {
+			end++
+		}
+		dst = lz4AppendSequence(dst, src[anchor:i], i-cand, end-i)
+		if len(dst) > limit {
+			return nil
+		}
+		i, anchor = end, end
+	}
+	dst = lz4AppendSequence(dst, src[anchor:], 0, 0)
+	if len(dst) > limit {
+		return nil
+	}
+	return dst
+}
+
+// lz4AppendSequence appends a sequence of literals followed by a match of
+// matchLen bytes at offset back, or by nothing if matchLen is 0
+func lz4AppendSequence(dst, literals []byte, offset, matchLen int) []byte {
+	token := byte(min(len(literals), 15)) << 4
+	if matchLen > 0 {
+		token |= byte(min(matchLen-lz4MinMatch, 15))
+	}
+	dst = append(dst, token)
+	dst = lz4AppendLength(dst, len(literals))
+	dst = append(dst, literals...)
+	if matchLen == 0 {
+		return dst
+	}
+	dst = append(dst, byte(offset), byte(offset>>8))
+	return lz4AppendLength(dst, matchLen-lz4MinMatch)
+}
+
+// lz4AppendLength appends the bytes continuing a length of n that did not
+// fit in its token nibble
+func lz4AppendLength(dst []byte, n int) []byte {
+	if n < 15 {
+		return dst
+	}
+	for n -= 15; n >= 255; n -= 255 {
+		dst = append(dst, 255)
+	}
+	return append(dst, byte(n))
+}
+
+// lz4Decompress decompresses the LZ4 block src, which must expand to
+// exactly n bytes, and returns errLZ4Corrupt if it does not or if a length
+// or offset points outside the data
+func lz4Decompress(src []byte, n int) ([]byte, error) {
+	dst := make([]byte, 0, n)
+	for i := 0; ; {
+		if i >= len(src) {
+			return nil, errLZ4Corrupt
+		}
+		token := src[i]
+		lit, next, ok := lz4ReadLength(src, i+1, int(token>>4))
+		i = next
+		if !ok || lit > len(src)-i || lit > n-len(dst) {
+			return nil, errLZ4Corrupt
+		}
+		dst = append(dst, src[i:i+lit]...)
+		if i += lit; i == len(src) {
+			// The last sequence has no match
+			if len(dst) != n {
+				return nil, errLZ4Corrupt
+			}
+			return dst, nil
+		}
+
+		if len(src)-i < 2 {
+			return nil, errLZ4Corrupt
+		}
+		offset := int(binary.LittleEndian.Uint16(src[i:]))
+		i += 2
+		match, next, ok := lz4ReadLength(src, i, int(token&15))
+		match += lz4MinMatch
+		if !ok || offset == 0 || offset > len(dst) || match > n-len(dst) {
+			return nil, errLZ4Corrupt
+		}
+		i = next
+
+		// A match may overlap the bytes it produces, repeating a pattern
+		start := len(dst) - offset
+		if offset >= match {
+			dst = append(dst, dst[start:start+match]...)
+		} else {
+			for k := 0; k < match; k++ {
+				dst = append(dst, dst[start+k])
+			}
+		}
+	}
+}
+
+// lz4ReadLength reads the continuation of a length whose token nibble was
+// n, starting at src[i], and returns the length and the offset after it
+func lz4ReadLength(src []byte, i, n int) (int, int, bool) {
+	if n < 15 {
+		return n, i, true
+	}
+	for {
+		if i >= len(src) || n > 1<<30 {
+			return 0, 0, false
+		}
+		b := src[i]
+		i++
+		n += int(b)
+		if b != 255 {
+			return n, i, true
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestLZ4RoundTrip(t *testing.T) {
+	random := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(random)
+	inputs := map[string][]byte{
+		"empty":   nil,
+		"short":   []byte("abc"),
+		"run":     bytes.Repeat([]byte{'a'}, 1000),
+		"pattern": bytes.Repeat([]byte("0123456789abcdef"), 300),
+		"text":    []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 50)),
+		"random":  random,
+		"mixed":   append(append([]byte{}, random[:500]...), bytes.Repeat([]byte("xyz"), 400)...),
+		"long":    bytes.Repeat([]byte{'z'}, 100000),
+	}
+	for name, in := range inputs {
+		block := lz4Compress(nil, in, 2*len(in)+16)
+		if block == nil {
+			t.Fatalf("%s: compression gave up below its limit", name)
+		}
+		out, err := lz4Decompress(block, len(in))
+		if err != nil || !bytes.Equal(out, in) {
+			t.Errorf("%s: round trip failed (%v)", name, err)
+		}
+		if name != "random" && name != "short" && name != "empty" && len(block) >= len(in)/2 {
+			t.Errorf("%s: expected at least 2x compression, got %d of %d bytes", name, len(block), len(in))
+		}
+	}
+
+	// Compression stops once the output passes the limit
+	if block := lz4Compress(nil, random, len(random)); block != nil {
+		t.Errorf("Expected random data not to compress within its own size, got %d bytes", len(block))
+	}
+}
+
+func TestLZ4Decompress(t *testing.T) {
+	// A block as the reference implementation writes it: "abc", a 15-byte
+	// match at offset 3 overlapping its own output, and 5 final literals
+	block := []byte{0x3b, 'a', 'b', 'c', 3, 0, 0x50, 'd', 'e', 'f', 'g', 'h'}
+	want := "abc" + strings.Repeat("abc", 5) + "defgh"
+	if out, err := lz4Decompress(block, len(want)); err != nil || string(out) != want {
+		t.Errorf("Expected %q, got %q (%v)", want, out, err)
+	}
+
+	for name, tc := range map[string]struct {
+		block []byte
+		n     int
+	}{
+		"empty block":       {nil, 0},
+		"wrong size":        {block, len(want) + 1},
+		"too much output":   {block, len(want) - 1},
+		"truncated literal": {[]byte{0x50, 'a', 'b'}, 5},
+		"truncated offset":  {[]byte{0x1b, 'a', 3}, 20},
+		"offset zero":       {[]byte{0x1b, 'a', 0, 0, 0x00}, 20},
+		"offset too far":    {[]byte{0x1b, 'a', 2, 0, 0x00}, 20},
+		"truncated length":  {[]byte{0xf0, 255}, 300},
+	} {
+		if _, err := lz4Decompress(tc.block, tc.n); err != errLZ4Corrupt {
+			t.Errorf("%s: expected errLZ4Corrupt, got: %v", name, err)
+		}
+	}
+}
+
+func FuzzLZ4Decompress(f *testing.F) {
+	f.Add([]byte{0x3b, 'a', 'b', 'c', 3, 0, 0x50, 'd', 'e', 'f', 'g', 'h'}, 23)
+	f.Add(lz4Compress(nil, bytes.Repeat([]byte("ring"), 100), 1000), 400)
+	f.Fuzz(func(t *testing.T, block []byte, n int) {
+		if n < 0 || n > 1<<20 {
+			return
+		}
+		// Corrupt blocks must fail cleanly, and valid ones re-encode
+		out, err := lz4Decompress(block, n)
+		if err != nil {
+			return
+		}
+		if len(out) != n {
+			t.Fatalf("Expected %d bytes, got %d", n, len(out))
+		}
+		if again, err := lz4Decompress(lz4Compress(nil, out, 2*n+16), n); err != nil || !bytes.Equal(again, out) {
+			t.Fatalf("Re-encoding failed (%v)", err)
+		}
+	})
+}
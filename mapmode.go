@@ -0,0 +1,47 @@
+package ringbuffer
+
+import "syscall"
+
+// MapMode selects how the file is mapped into memory.
+type MapMode int
+
+const (
+	// MapShared maps the file with MAP_SHARED: writes reach the file and
+	// are visible to every process mapping it. This is the default.
+	MapShared MapMode = iota
+	// MapPrivate maps the file with MAP_PRIVATE: writes stay in this
+	// process's copy-on-write pages and are never written back, so the
+	// buffer is private to the handle and nothing is persisted to the file.
+	MapPrivate
+)
+
+// String returns the name of the mapping mode.
+func (m MapMode) String() string {
+	switch m {
+	case MapShared:
+		return "shared"
+	case MapPrivate:
+		return "private"
+	default:
+		return "unknown"
+	}
+}
+
+// WithMapMode selects how the file is mapped. MapPrivate suits a buffer
+// used by a single handle that does not need the file, avoiding write-back
+// of dirty pages; a buffer created that way leaves only a zeroed file behind,
+// and one opened that way starts from the file's contents but never changes
+// them. Sync does nothing useful on a private mapping.
+func WithMapMode(m MapMode) Option {
+	return func(o *options) {
+		o.mapMode = m
+	}
+}
+
+// mmapFlags returns the syscall.Mmap flags for these settings
+func (o options) mmapFlags() int {
+	if o.mapMode == MapPrivate {
+		return syscall.MAP_PRIVATE
+	}
+	return syscall.MAP_SHARED
+}
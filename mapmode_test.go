@@ -0,0 +1,68 @@
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestRingBufferMapPrivate(t *testing.T) {
+	filename := "/tmp/test_rb_private.mmap"
+	rb, err := NewRingBuffer(filename, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	if ok, err := rb.WriteMsg([]byte("persisted")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	rb.Close()
+
+	// A private handle sees the file but its changes stay in memory
+	private, err := OpenRingBuffer(filename, WithMapMode(MapPrivate))
+	if err != nil {
+		t.Fatalf("Failed to open private ring buffer: %v", err)
+	}
+	if ok, err := private.WriteMsg([]byte("private")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	for _, want := range []string{"persisted", "private"} {
+		if msg, err := private.ReadMsg(); err != nil || string(msg) != want {
+			t.Errorf("Expected %q from the private handle, got %q (%v)", want, msg, err)
+		}
+	}
+	private.Close()
+
+	rb, err = OpenRingBuffer(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	defer rb.Close()
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "persisted" {
+		t.Errorf("Expected the shared message to be unread, got %q (%v)", msg, err)
+	}
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected the private write not to reach the file, got: %v", err)
+	}
+}
+
+func TestRingBufferMapPrivateCreate(t *testing.T) {
+	filename := "/tmp/test_rb_private.mmap"
+	rb, err := NewRingBuffer(filename, 1024, true, WithMapMode(MapPrivate))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	if ok, err := rb.WriteMsg([]byte("gone")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "gone" {
+		t.Errorf("Expected %q, got %q (%v)", "gone", msg, err)
+	}
+	rb.Close()
+
+	// Not even the header was written to the file
+	if _, err := OpenRingBuffer(filename); !errors.Is(err, ErrCorruptHeader) {
+		t.Errorf("Expected ErrCorruptHeader for a file only mapped privately, got: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Sync flushes the mapping to the backing file with msync(MS_SYNC) and then
+// fsyncs the file, so messages written before the call and the file's
+// metadata survive a power failure. Writes other than WithConcurrentWriters
+// ones wait while it runs, so the file holds a consistent snapshot. Regions
+// only flush the mapping, since their file is not kept open, and buffers
+// from FromBytes return ErrIncompatibleOptions.
+func (r *RingBuffer) Sync() error {
+	r.writeMu.RLock()
+	defer r.writeMu.RUnlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+	if r.mem == nil {
+		// FromBytes memory is flushed, if at all, by its owner
+		return ErrIncompatibleOptions
+	}
+
+	return r.flush()
+}
+
+// flush implements Sync and CloseSync. Caller must hold writeMu, and the
+// mapping must be its own.
+func (r *RingBuffer) flush() error {
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&r.mem[0])), uintptr(len(r.mem)), syscall.MS_SYNC)
+	if errno != 0 {
+		return fmt.Errorf("ringbuffer: msync %s: %w", r.name, errno)
+	}
+	if r.file != nil {
+		if err := r.file.Sync(); err != nil {
+			return fmt.Errorf("ringbuffer: fsync %s: %w", r.name, err)
+		}
+	}
+	return nil
+}
+
+// File returns the backing file, which stays open until Close, or nil for
+// a buffer created on a region. The caller must not close it.
+func (r *RingBuffer) File() *os.File {
+	return r.file
+}
+
+// FileName returns the path of the backing file as given when the buffer
+// was created or opened, for diagnostics and for opening it again. For a
+// region it is the name of the file the region lies in.
+func (r *RingBuffer) FileName() string {
+	return r.name
+}
+
+// Stat returns the FileInfo of the backing file. A region's file is not
+// kept open, so it is looked up by name instead. Returns ErrClosed after
+// Close.
+func (r *RingBuffer) Stat() (os.FileInfo, error) {
+	if !r.enter() {
+		return nil, ErrClosed
+	}
+	defer r.leave()
+
+	if r.file == nil {
+		return os.Stat(r.name)
+	}
+	return r.file.Stat()
+}
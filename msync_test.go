@@ -0,0 +1,95 @@
+package ringbuffer
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRingBufferSync(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_sync.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_sync.mmap")
+
+	if ok, err := rb.WriteMsg([]byte("durable")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if err := rb.Sync(); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	// The retained file can be queried until Close
+	f := rb.File()
+	if f == nil {
+		t.Fatalf("Expected the backing file to be retained")
+	}
+	if info, err := f.Stat(); err != nil || info.Size() != 1024 {
+		t.Errorf("Expected a 1024-byte backing file, got: %v (%v)", info, err)
+	}
+
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Failed to close ring buffer: %v", err)
+	}
+	if _, err := f.Stat(); err == nil {
+		t.Errorf("Expected Close to close the backing file")
+	}
+	if err := rb.Sync(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from Sync after Close, got: %v", err)
+	}
+}
+
+func TestRingBufferCloseSync(t *testing.T) {
+	const filename = "/tmp/test_rb_close_sync.mmap"
+	rb, err := NewRingBuffer(filename, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+
+	if ok, err := rb.WriteMsg([]byte("handed off")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if err := rb.CloseSync(); err != nil {
+		t.Fatalf("Failed to close ring buffer: %v", err)
+	}
+	if err := rb.CloseSync(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from a second CloseSync, got: %v", err)
+	}
+
+	// The next user finds the message and a clean shutdown
+	rb, err = OpenRingBuffer(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	defer rb.Close()
+	if !rb.WasCleanlyClosed() {
+		t.Errorf("Expected CloseSync to record a clean shutdown")
+	}
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "handed off" {
+		t.Errorf("Expected \"handed off\", got %q (%v)", msg, err)
+	}
+}
+
+func TestRingBufferFileName(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_sync.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_sync.mmap")
+
+	if name := rb.FileName(); name != "/tmp/test_rb_sync.mmap" {
+		t.Errorf("Expected the path passed to NewRingBuffer, got %q", name)
+	}
+	if info, err := rb.Stat(); err != nil || info.Size() != 1024 {
+		t.Errorf("Expected a 1024-byte backing file, got: %v (%v)", info, err)
+	}
+
+	rb.Close()
+	if name := rb.FileName(); name != "/tmp/test_rb_sync.mmap" {
+		t.Errorf("Expected the path to outlive Close, got %q", name)
+	}
+	if _, err := rb.Stat(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from Stat after Close, got: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package ringbuffer
+
+// noWrapMarker is stored in place of a length where WithNoWrapWrites skipped
+// the rest of the buffer; no real length comes close to it
+const noWrapMarker = 0xffffffff
+
+// WithNoWrapWrites keeps every frame physically contiguous, for readers of
+// the file that cannot handle a payload split across the end of the buffer.
+// A frame that does not fit before the end is written at the start of the
+// data region instead, the skipped bytes count as used, and a marker in
+// place of the length tells readers to skip them. If the frame does not
+// fit at the start either, the write fails with ErrBufferFull, or whatever
+// the full policy does, rather than wrapping. The option is recorded in
+// the file, so OpenRingBuffer may leave it out.
+func WithNoWrapWrites() Option {
+	return func(o *options) {
+		o.noWrap = true
+	}
+}
+
+// place returns the frame for a message of msgLen bytes written at pos,
+// moved to the start of the data region if its prefix, or with
+// WithNoWrapWrites any part of it, would not fit before the end
+func (r *RingBuffer) place(pos, msgLen uint32) frame {
+	f := r.frameFrom(pos)
+	f.msgLen = msgLen
+	if r.opts.noWrap && f.gap == 0 && pos+f.prefix+msgLen+f.link > uint32(r.size) {
+		f.gap = uint32(r.size) - pos
+		f.start = r.dataStart
+		f.prefix = r.prefixAt(r.dataStart)
+	}
+	return f
+}
@@ -0,0 +1,86 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestRingBufferNoWrapWrites(t *testing.T) {
+	filename := "/tmp/test_rb_nowrap.mmap"
+	rb, err := NewRingBuffer(filename, 256, true, WithNoWrapWrites(), WithFlags(), WithBackLinks())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+
+	// Varying sizes make frames end at every offset near the end
+	for i := 0; i < 100; i++ {
+		msg := []byte(fmt.Sprintf("m%d-%s", i, "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"[:i%41]))
+		if ok, err := rb.WriteMsgFlags(msg, byte(i)); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+
+		rb.readMu.Lock()
+		f, err := rb.peekFrame()
+		rb.readMu.Unlock()
+		if err != nil {
+			t.Fatalf("Failed to locate message %d: %v", i, err)
+		}
+		if end := f.payload() + f.msgLen + f.link; end > uint32(rb.size) {
+			t.Fatalf("Message %d wraps: payload at %d ends at %d past %d", i, f.payload(), end, rb.size)
+		}
+
+		if reversed, err := rb.ReadMsgReverse(); err != nil || string(reversed) != string(msg) {
+			t.Fatalf("Expected %q from ReadMsgReverse, got %q (%v)", msg, reversed, err)
+		}
+		rb.reversePos = 0
+		got, flags, err := rb.ReadMsgFlags()
+		if err != nil || string(got) != string(msg) || flags != byte(i) {
+			t.Fatalf("Expected %q flags %d, got %q flags %d (%v)", msg, i, got, flags, err)
+		}
+	}
+	rb.Close()
+
+	// The header records the option
+	if rb, err := OpenRingBuffer(filename, WithFlags(), WithBackLinks()); err != nil || !rb.opts.noWrap {
+		t.Errorf("Expected WithNoWrapWrites to be taken from the header, got: %v", err)
+	} else {
+		rb.Close()
+	}
+}
+
+func TestRingBufferNoWrapWritesFull(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_nowrap.mmap", headerSize+224, true, WithNoWrapWrites())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_nowrap.mmap")
+	defer rb.Close()
+
+	// Leave 100 bytes at the start and 60 before the end free
+	if ok, err := rb.WriteMsg(make([]byte, 96)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if ok, err := rb.WriteMsg(make([]byte, 68)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+
+	// 97 bytes would fit in total but neither before the end nor at the
+	// start while the second message is there
+	if ok, err := rb.WriteMsg(make([]byte, 97)); ok || err != ErrBufferFull {
+		t.Fatalf("Expected ErrBufferFull for a message that cannot be contiguous, got: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if ok, err := rb.WriteMsg(make([]byte, 97)); !ok || err != nil {
+		t.Fatalf("Failed to write message once the start is free: %v", err)
+	}
+	if msg, err := rb.ReadMsg(); err != nil || len(msg) != 97 {
+		t.Errorf("Expected the 97-byte message, got %d bytes (%v)", len(msg), err)
+	}
+}
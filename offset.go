@@ -0,0 +1,96 @@
+package ringbuffer
+
+import "errors"
+
+var ErrInvalidOffset = errors.New("offset is not a frame boundary in the buffered data")
+
+// ReadOffset returns the offset of the next message to read, for a reader
+// that keeps its own checkpoints. It returns 0 after Close.
+func (r *RingBuffer) ReadOffset() uint32 {
+	_, tail := r.GetHeadTail()
+	return tail
+}
+
+// SetReadOffset resumes reading at pos, an offset from ReadOffset, skipping
+// the messages before it. pos must be the start of a buffered message or
+// the head; anything else, including an offset that was already read past,
+// returns ErrInvalidOffset and changes nothing. This suits a reader with
+// WithMapMode(MapPrivate), whose reads never reach the file, restoring its
+// own checkpoint after a restart. Returns ErrIncompatibleOptions with
+// reader groups, which keep their offsets in the file.
+func (r *RingBuffer) SetReadOffset(pos uint32) error {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+	if r.opts.groups > 0 {
+		// The tail belongs to the reader groups' Commit
+		return ErrIncompatibleOptions
+	}
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return err
+	}
+	// Walk the frames from tail, validating each, until pos is reached
+	left, at, total := r.used(head, tail), tail, uint32(0)
+	var read Stats
+	for at != pos {
+		if left == 0 {
+			return ErrInvalidOffset
+		}
+		f, err := r.frameBefore(at, left)
+		if err != nil {
+			return err
+		}
+		left -= f.footprint()
+		total += f.footprint()
+		at = f.next
+		read.Reads++
+		read.ReadBytes += uint64(f.msgLen)
+	}
+	if total == 0 {
+		return nil
+	}
+	r.stats.add(read)
+	return r.release(at, total)
+}
+
+// ForEachFrame calls fn for each buffered message, oldest first, without
+// consuming anything, until fn returns false. offset is where the message's
+// frame starts, which SetReadOffset accepts to resume at that message, and
+// msg is a copy of the message as ReadMsg would return it. fn runs with the
+// read lock held, so it must not read from the buffer itself.
+func (r *RingBuffer) ForEachFrame(fn func(offset uint32, msg []byte) bool) error {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return err
+	}
+	left, pos := r.used(head, tail), tail
+	for left > 0 {
+		f, err := r.frameBefore(pos, left)
+		if err != nil {
+			return err
+		}
+		msg := make([]byte, f.msgLen)
+		r.copyOut(f.payload(), msg)
+		if msg, err = r.open(msg); err != nil {
+			return err
+		}
+		if !fn(pos, msg) {
+			return nil
+		}
+		left -= f.footprint()
+		pos = f.next
+	}
+	return nil
+}
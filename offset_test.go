@@ -0,0 +1,122 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestRingBufferReadOffset(t *testing.T) {
+	const name = "/tmp/test_rb_offset.mmap"
+	rb, err := NewRingBuffer(name, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(name)
+	for i := 0; i < 5; i++ {
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("message %d", i))); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	rb.Close()
+
+	// A private mapping keeps the reads out of the file, so the reader
+	// checkpoints the offset itself
+	rb, err = OpenRingBuffer(name, WithMapMode(MapPrivate))
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := rb.ReadMsg(); err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+	}
+	saved := rb.ReadOffset()
+	rb.Close()
+
+	rb, err = OpenRingBuffer(name, WithMapMode(MapPrivate))
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	defer rb.Close()
+	if err := rb.SetReadOffset(saved + 1); err != ErrInvalidOffset {
+		t.Errorf("Expected ErrInvalidOffset inside a frame, got: %v", err)
+	}
+	if err := rb.SetReadOffset(saved); err != nil {
+		t.Fatalf("Failed to restore offset: %v", err)
+	}
+	for i := 2; i < 5; i++ {
+		msg, err := rb.ReadMsg()
+		if want := fmt.Sprintf("message %d", i); err != nil || string(msg) != want {
+			t.Errorf("Expected %q, got %q (%v)", want, msg, err)
+		}
+	}
+
+	// Offsets already read past cannot be restored
+	if err := rb.SetReadOffset(saved); err != ErrInvalidOffset {
+		t.Errorf("Expected ErrInvalidOffset behind the tail, got: %v", err)
+	}
+}
+
+func TestRingBufferForEachFrame(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_foreach.mmap", headerSize+200, true, WithFlags())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_foreach.mmap")
+	defer rb.Close()
+
+	// Wrap around so one frame starts past a skipped gap
+	if ok, err := rb.WriteMsg(make([]byte, 150)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	msgs := []string{"alpha", string(make([]byte, 27)), "gamma", ""}
+	for _, msg := range msgs[:3] {
+		if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	if ok, err := rb.WriteMsgFlags(nil, 1); !ok || err != nil {
+		t.Fatalf("Failed to write signal: %v", err)
+	}
+
+	var offsets []uint32
+	var got []string
+	err = rb.ForEachFrame(func(offset uint32, msg []byte) bool {
+		offsets = append(offsets, offset)
+		got = append(got, string(msg))
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk frames: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(msgs) {
+		t.Fatalf("Expected %q, got %q", msgs, got)
+	}
+	if offsets[0] != rb.ReadOffset() {
+		t.Errorf("Expected the first offset to be the read offset %d, got %d", rb.ReadOffset(), offsets[0])
+	}
+
+	// Stopping early
+	calls := 0
+	rb.ForEachFrame(func(uint32, []byte) bool {
+		calls++
+		return calls < 2
+	})
+	if calls != 2 {
+		t.Errorf("Expected the walk to stop after 2 calls, got %d", calls)
+	}
+
+	// Each offset resumes reading at its message
+	for i, offset := range offsets {
+		if err := rb.SetReadOffset(offset); err != nil {
+			t.Fatalf("Failed to set read offset %d: %v", offset, err)
+		}
+		if msg, _, err := rb.ReadMsgFlags(); err != nil || string(msg) != msgs[i] {
+			t.Errorf("Expected %q at offset %d, got %q (%v)", msgs[i], offset, msg, err)
+		}
+	}
+}
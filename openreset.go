@@ -0,0 +1,34 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// OpenOrReset maps an existing ring buffer file like OpenRingBuffer. If the
+// file does not hold a usable ring buffer header, for example after a
+// writer crashed while creating it, and resetIfInvalid is set, the file is
+// reinitialized in place as an empty buffer of the same size instead, so
+// it never has to be removed and recreated. Other errors, including
+// ErrIncompatibleOptions for a valid file written with different options,
+// are returned as they are.
+func OpenOrReset(mmapFileName string, resetIfInvalid bool, opts ...Option) (*RingBuffer, error) {
+	rb, err := OpenRingBuffer(mmapFileName, opts...)
+	if err == nil {
+		// Head and tail are only checked when used, so check them now
+		if _, _, err = rb.loadHeadTail(); err == nil || rb.opts.latest {
+			return rb, nil
+		}
+		rb.Close()
+	}
+	if !resetIfInvalid || !errors.Is(err, ErrCorruptHeader) {
+		return nil, err
+	}
+
+	fileInfo, statErr := os.Stat(mmapFileName)
+	if statErr != nil {
+		return nil, fmt.Errorf("ringbuffer: stat %s: %w", mmapFileName, statErr)
+	}
+	return NewRingBuffer(mmapFileName, int(fileInfo.Size()), false, opts...)
+}
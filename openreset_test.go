@@ -0,0 +1,82 @@
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestOpenOrReset(t *testing.T) {
+	const name = "/tmp/test_rb_openreset.mmap"
+	defer os.Remove(name)
+
+	// A file left behind half-written by a crashed creator
+	junk := make([]byte, 256)
+	for i := range junk {
+		junk[i] = 0xa5
+	}
+	if err := os.WriteFile(name, junk, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if _, err := OpenOrReset(name, false); !errors.Is(err, ErrCorruptHeader) {
+		t.Fatalf("Expected ErrCorruptHeader without reset, got: %v", err)
+	}
+
+	rb, err := OpenOrReset(name, true)
+	if err != nil {
+		t.Fatalf("Failed to reset corrupt file: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected an empty buffer after reset, got: %v", err)
+	}
+	if ok, err := rb.WriteMsg([]byte("kept")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	rb.Close()
+
+	// A valid file is opened as it is
+	rb, err = OpenOrReset(name, true)
+	if err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	defer rb.Close()
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "kept" {
+		t.Errorf("Expected the message to survive reopening, got %q (%v)", msg, err)
+	}
+}
+
+func TestOpenOrResetHeadOutOfRange(t *testing.T) {
+	const name = "/tmp/test_rb_openreset.mmap"
+	rb, err := NewRingBuffer(name, 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(name)
+	rb.setHead(1 << 20)
+	rb.Close()
+
+	rb, err = OpenOrReset(name, true)
+	if err != nil {
+		t.Fatalf("Failed to reset: %v", err)
+	}
+	defer rb.Close()
+	if head, tail := rb.GetHeadTail(); head != tail {
+		t.Errorf("Expected an empty buffer, got head %d tail %d", head, tail)
+	}
+}
+
+func TestOpenOrResetIncompatible(t *testing.T) {
+	const name = "/tmp/test_rb_openreset.mmap"
+	rb, err := NewRingBuffer(name, 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(name)
+	rb.Close()
+
+	// Different options are a caller error, not corruption
+	if _, err := OpenOrReset(name, true, WithFlags()); !errors.Is(err, ErrIncompatibleOptions) {
+		t.Errorf("Expected ErrIncompatibleOptions, got: %v", err)
+	}
+}
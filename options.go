@@ -0,0 +1,374 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+	"time"
+)
+
+// Option configures a RingBuffer at construction time.
+type Option func(*options)
+
+// options holds the settings collected from Option values.
+type options struct {
+	strategy    Strategy
+	strategySet bool // WithStrategy was given, see adoptFormat
+	futex       bool
+	groups      int
+	flags       bool
+	debugChecks bool
+	hugePages   bool
+	guardPages  bool
+	timestamps  bool
+	sequences   bool
+	backLinks   bool
+	noWrap      bool
+	align       uint32 // payload alignment, 0 for none
+	latest      bool
+	codec       Codec
+	spsc        bool
+	startAt     StartAt
+	coalesce    bool
+	stats       bool
+
+	concurrentWriters bool
+	encryptionKey     []byte
+	seqIndexPath      string // WithSequenceIndex file, "" for none
+	seqIndexEntries   int
+	autoGrow          int // maximum size, 0 to never grow
+	dedupWindow       int // WithDedup messages remembered, 0 for none
+	dedupKey          func([]byte) uint64
+	mapMode           MapMode
+
+	fullPolicy   FullPolicy
+	blockTimeout time.Duration
+}
+
+// defaultOptions returns the settings used when no Option is given.
+func defaultOptions() options {
+	return options{
+		strategy: StrategySentinel,
+	}
+}
+
+// parseOptions applies opts over the defaults and validates the result
+func parseOptions(opts []Option) (options, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o, o.validate()
+}
+
+// validate checks that the settings can be used together
+func (o options) validate() error {
+	if !littleEndian {
+		return ErrBigEndian
+	}
+	if newFullStrategy(o.strategy) == nil {
+		return ErrStrategy
+	}
+	if o.groups < 0 || o.groups > maxReaderGroups {
+		return ErrInvalidGroup
+	}
+	if o.groups > 0 && o.strategy != StrategySentinel {
+		// A committed offset equal to head must unambiguously mean "caught up"
+		return ErrIncompatibleOptions
+	}
+	if o.fullPolicy < FailOnFull || o.fullPolicy > OverwriteOnFull {
+		return ErrFullPolicy
+	}
+	if o.groups > 0 && o.fullPolicy == OverwriteOnFull {
+		// Dropping messages would move the tail past committed offsets
+		return ErrIncompatibleOptions
+	}
+	if o.concurrentWriters && (o.strategy != StrategySentinel || o.fullPolicy != FailOnFull) {
+		// Claims are checked against the tail alone and never wait
+		return ErrIncompatibleOptions
+	}
+	if o.guardPages && o.hugePages {
+		// The guard is a normal page, which cannot follow a huge page mapping
+		return ErrIncompatibleOptions
+	}
+	if o.mapMode < MapShared || o.mapMode > MapPrivate {
+		return ErrIncompatibleOptions
+	}
+	if o.autoGrow < 0 {
+		return ErrInvalidSize
+	}
+	if o.autoGrow > 0 && (o.groups > 0 || o.futex || o.concurrentWriters || o.hugePages) {
+		// Committed offsets and futex waiters would outlive the remap;
+		// claims are made without writeMu; huge page files cannot double freely
+		return ErrIncompatibleOptions
+	}
+	if o.align != 0 && (o.align < 2 || o.align > maxAlign || o.align&(o.align-1) != 0) {
+		return fmt.Errorf("ringbuffer: payload alignment %d is not a power of two from 2 to %d: %w", o.align, maxAlign, ErrInvalidSize)
+	}
+	if o.codec > CodecLZ4 || o.startAt < StartAtOldest || o.startAt > StartAtNewest {
+		return ErrIncompatibleOptions
+	}
+	if o.latest && (o.codec != CodecNone || o.groups > 0 || o.futex || o.flags || o.timestamps || o.sequences || o.backLinks || o.noWrap || o.align != 0 || o.coalesce ||
+		o.concurrentWriters || o.encryptionKey != nil || o.autoGrow > 0 || o.fullPolicy != FailOnFull || o.spsc || o.startAt != StartAtOldest) {
+		// The data region holds the value slots instead of frames, and a
+		// write always fits
+		return ErrIncompatibleOptions
+	}
+	if o.coalesce && (o.groups > 0 || o.concurrentWriters || o.encryptionKey != nil) {
+		// A group may have read the frame already; claims are made without
+		// writeMu; sealing the same message twice gives different payloads
+		return ErrIncompatibleOptions
+	}
+	if o.spsc && (o.concurrentWriters || o.groups > 0) {
+		// The cached head and tail assume one writer and one reader
+		return ErrIncompatibleOptions
+	}
+	if (o.dedupWindow != 0 || o.dedupKey != nil) && (o.dedupWindow <= 0 || o.dedupKey == nil) {
+		return fmt.Errorf("ringbuffer: dedup needs a key function and a window of at least one message, got %d: %w", o.dedupWindow, ErrInvalidSize)
+	}
+	if o.dedupWindow > 0 && (o.concurrentWriters || o.coalesce || o.latest) {
+		// Claims are made without writeMu; a repeat would be counted by
+		// coalescing rather than dropped; a value store has no history
+		return ErrIncompatibleOptions
+	}
+	if o.stats && (o.groups > 0 || o.latest) {
+		// Group readers and the latest slot read without consuming
+		return ErrIncompatibleOptions
+	}
+	if o.seqIndexPath != "" && o.seqIndexEntries <= 0 {
+		return fmt.Errorf("ringbuffer: sequence index needs at least one entry, got %d: %w", o.seqIndexEntries, ErrInvalidSize)
+	}
+	if o.encryptionKey != nil && len(o.encryptionKey) != 32 {
+		return fmt.Errorf("ringbuffer: encryption key is %d bytes, want 32: %w", len(o.encryptionKey), ErrInvalidSize)
+	}
+	return nil
+}
+
+// prefixSize returns the size of the per-frame prefix for these settings
+func (o options) prefixSize() uint32 {
+	size := uint32(LengthPrefixSize)
+	if o.flags {
+		size++
+	}
+	if o.timestamps {
+		size += 8
+	}
+	if o.sequences {
+		size += 8
+	}
+	if o.coalesce {
+		size += 4
+	}
+	return size
+}
+
+// maxPrefixSize returns the largest prefix a frame can have, including the
+// padding WithPayloadAlignment may insert
+func (o options) maxPrefixSize() uint32 {
+	if o.align == 0 {
+		return o.prefixSize()
+	}
+	return o.prefixSize() + o.align - 1
+}
+
+// linkSize returns the size of the back-link stored after the payload
+func (o options) linkSize() uint32 {
+	if o.backLinks {
+		return 4
+	}
+	return 0
+}
+
+// timestampOffset returns the offset of the timestamp within the prefix
+func (o options) timestampOffset() uint32 {
+	if o.flags {
+		return 5
+	}
+	return 4
+}
+
+// sealOverhead returns how much larger WithCompression and WithEncryption
+// can make a payload
+func (o options) sealOverhead() uint32 {
+	var n uint32
+	if o.codec != CodecNone {
+		n += codecOverhead
+	}
+	if o.encryptionKey != nil {
+		n += sealOverhead
+	}
+	return n
+}
+
+// sealed reports whether payloads are stored differently from the messages
+// they hold, so they cannot be handed out or taken in as they are
+func (o options) sealed() bool {
+	return o.codec != CodecNone || o.encryptionKey != nil
+}
+
+// maxPayload returns the largest payload that fits in an empty buffer of
+// bufSize bytes, or 0 if none does
+func (o options) maxPayload(bufSize int) int {
+	n := bufSize - int(o.dataStart()) - int(o.maxPrefixSize()) - int(o.linkSize()) - int(newFullStrategy(o.strategy).slack())
+	if o.latest {
+		n = o.latestSlotSize(bufSize) - 4
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// maxMsgSize returns the largest message that fits in an empty buffer of
+// bufSize bytes, or 0 if none does
+func (o options) maxMsgSize(bufSize int) int {
+	n := o.maxPayload(bufSize) - int(o.sealOverhead())
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// checkSize verifies that a buffer of size bytes with these settings can
+// hold at least a 1-byte message, returning ErrInvalidSize otherwise
+func (o options) checkSize(size int) error {
+	if size <= int(o.dataStart()) {
+		return fmt.Errorf("ringbuffer: size %d must be larger than header and reader group slots %d: %w", size, o.dataStart(), ErrInvalidSize)
+	}
+	if o.maxMsgSize(size) > 0 {
+		return nil
+	}
+	smallest := size + 1
+	for o.maxMsgSize(smallest) == 0 {
+		smallest++
+	}
+	return fmt.Errorf("ringbuffer: size %d leaves no room for a message after the header and per-frame overhead, use at least %d bytes: %w", size, smallest, ErrInvalidSize)
+}
+
+// format returns the format word stored in the header for these settings:
+// the magic number in the low half, then 4 bits of layout version, 1 bit of
+// strategy and the compression, sequence and latest slot bits, and the
+// feature bits in the top byte
+func (o options) format() uint32 {
+	var features uint32
+	if o.flags {
+		features |= featureFlags
+	}
+	if o.timestamps {
+		features |= featureTimestamps
+	}
+	if o.backLinks {
+		features |= featureBackLinks
+	}
+	if o.encryptionKey != nil {
+		features |= featureEncryption
+	}
+	if o.noWrap {
+		features |= featureNoWrap
+	}
+	if o.align != 0 {
+		features |= uint32(bits.TrailingZeros32(o.align)) << featureAlignShift
+	}
+	format := magicValue | formatVersion<<16 | uint32(o.strategy)<<20 | features<<24
+	if o.sequences {
+		format |= formatSequence
+	}
+	if o.latest {
+		format |= formatLatest
+	}
+	if o.codec != CodecNone {
+		format |= formatCompression
+	}
+	return format
+}
+
+// extraFeatures returns the features field stored in the header for these
+// settings, the feature bits that do not fit in the format word
+func (o options) extraFeatures() uint32 {
+	var features uint32
+	if o.coalesce {
+		features |= extraCoalescing
+	}
+	return features
+}
+
+// adoptFormat verifies that buf holds a ring buffer with the same layout
+// version and returns these settings completed with the strategy and record
+// format recorded in it. Settings given explicitly must agree with the file.
+func (o options) adoptFormat(buf []byte) (options, error) {
+	stored := uint32(fieldFormat.get(buf))
+	var zero [headerSize]byte
+	if bytes.Equal(buf[:headerSize], zero[:]) {
+		return o, fmt.Errorf("ringbuffer: header is all zeros, create the file with NewRingBuffer: %w", ErrUninitialized)
+	}
+	if magic := stored & 0xffff; magic != magicValue {
+		return o, fmt.Errorf("ringbuffer: bad magic %#x, not a ring buffer file: %w", magic, ErrCorruptHeader)
+	}
+	if version := stored >> 16 & 0xf; version != formatVersion {
+		return o, fmt.Errorf("ringbuffer: layout version %d, want %d: %w", version, formatVersion, ErrUnsupportedVersion)
+	}
+
+	extra := uint32(fieldFeatures.get(buf))
+	if extra&^extraKnown != 0 {
+		return o, fmt.Errorf("ringbuffer: unknown feature bits %#x: %w", extra&^extraKnown, ErrUnsupportedVersion)
+	}
+
+	features := stored >> 24
+	file := o
+	file.strategy = Strategy(stored >> 20 & strategyMask)
+	file.flags = features&featureFlags != 0
+	file.timestamps = features&featureTimestamps != 0
+	file.backLinks = features&featureBackLinks != 0
+	file.noWrap = features&featureNoWrap != 0
+	file.align = 0
+	if shift := features >> featureAlignShift & 7; shift != 0 {
+		file.align = 1 << shift
+	}
+	file.sequences = stored&formatSequence != 0
+	file.latest = stored&formatLatest != 0
+	file.coalesce = extra&extraCoalescing != 0
+	switch {
+	case stored&formatCompression == 0:
+		file.codec = CodecNone
+	case o.codec == CodecNone:
+		// Frames name their codec, so any will do for reading
+		file.codec = CodecLZ4
+	}
+
+	// Everything requested must be in the file; the strategy and alignment
+	// only when given, and the key cannot be adopted
+	const adopted = strategyMask<<20 | 7<<(24+featureAlignShift)
+	mismatch := o.format()&^stored&^adopted != 0 || o.extraFeatures()&^extra != 0 ||
+		o.strategySet && o.strategy != file.strategy ||
+		o.align != 0 && o.align != file.align ||
+		features&featureEncryption != 0 && o.encryptionKey == nil
+	if mismatch {
+		return o, fmt.Errorf("ringbuffer: file format %#x does not match options %#x: %w", stored, o.format(), ErrFormatMismatch)
+	}
+	return file, nil
+}
+
+// dataStart returns the offset of the data region for these settings
+func (o options) dataStart() uint32 {
+	if o.sequences {
+		return o.sequenceSlot() + 8
+	}
+	return headerSize + uint32(o.groups)*groupSlotSize
+}
+
+// sequenceSlot returns the offset of the last assigned sequence number,
+// stored after the reader group slots with WithSequenceNumbers
+func (o options) sequenceSlot() uint32 {
+	return headerSize + uint32(o.groups)*groupSlotSize
+}
+
+// WithStrategy selects how a full buffer is told apart from an empty one.
+// The strategy is recorded in the file, and opening it without WithStrategy
+// uses the recorded one; with WithStrategy it must match, or the open fails
+// with ErrFormatMismatch.
+func WithStrategy(s Strategy) Option {
+	return func(o *options) {
+		o.strategy = s
+		o.strategySet = true
+	}
+}
@@ -0,0 +1,67 @@
+package ringbuffer
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrSequence = errors.New("sequence number already written")
+
+// OrderedWriter writes messages from many goroutines in sequence-number
+// order rather than in whatever order they win writeMu. A writer whose
+// number comes up writes its message itself; the others wait their turn.
+//
+// Every sequence number from the first one on must be written exactly
+// once, since a missing number stalls every writer after it. Ticket hands
+// out numbers in call order for writers that only need arrival order.
+type OrderedWriter struct {
+	rb     *RingBuffer
+	mu     sync.Mutex
+	turn   *sync.Cond
+	next   uint64 // next sequence number to write
+	ticket uint64 // next sequence number Ticket hands out
+}
+
+// OrderedWriter returns an OrderedWriter whose first message has sequence
+// number first.
+func (r *RingBuffer) OrderedWriter(first uint64) *OrderedWriter {
+	w := &OrderedWriter{rb: r, next: first, ticket: first}
+	w.turn = sync.NewCond(&w.mu)
+	return w
+}
+
+// Ticket returns the next sequence number not yet handed out, so callers
+// that take a ticket on arrival are written in arrival order.
+func (w *OrderedWriter) Ticket() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	seq := w.ticket
+	w.ticket++
+	return seq
+}
+
+// WriteMsg waits until every message numbered before seq has been written,
+// then writes msg like RingBuffer.WriteMsg. The turn passes on even if the
+// write fails, so a failed write loses only its own message. A seq that was
+// already written returns ErrSequence.
+// Returns (true, nil) if successful, (false, error) if failed
+func (w *OrderedWriter) WriteMsg(seq uint64, msg []byte) (bool, error) {
+	w.mu.Lock()
+	for w.next < seq {
+		w.turn.Wait()
+	}
+	if w.next > seq {
+		w.mu.Unlock()
+		return false, ErrSequence
+	}
+	w.mu.Unlock()
+
+	// Only this writer holds the turn, so the write needs no lock of ours
+	ok, err := w.rb.WriteMsg(msg)
+
+	w.mu.Lock()
+	w.next++
+	w.turn.Broadcast()
+	w.mu.Unlock()
+	return ok, err
+}
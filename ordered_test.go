@@ -0,0 +1,75 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestOrderedWriter(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_ordered.mmap", 4096, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_ordered.mmap")
+	defer rb.Close()
+
+	// Start the writers in reverse so lock order alone would scramble them
+	const n = 50
+	w := rb.OrderedWriter(100)
+	var wg sync.WaitGroup
+	for i := n - 1; i >= 0; i-- {
+		wg.Add(1)
+		go func(seq uint64) {
+			defer wg.Done()
+			msg := binary.LittleEndian.AppendUint64(nil, seq)
+			if ok, err := w.WriteMsg(seq, msg); !ok || err != nil {
+				t.Errorf("Failed to write %d: %v", seq, err)
+			}
+		}(uint64(100 + i))
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		msg, err := rb.ReadMsg()
+		if err != nil {
+			t.Fatalf("Failed to read message %d: %v", i, err)
+		}
+		if seq := binary.LittleEndian.Uint64(msg); seq != uint64(100+i) {
+			t.Fatalf("Expected sequence %d, got %d", 100+i, seq)
+		}
+	}
+
+	if ok, err := w.WriteMsg(120, []byte("again")); ok || err != ErrSequence {
+		t.Errorf("Expected ErrSequence for a written number, got: (%v, %v)", ok, err)
+	}
+}
+
+func TestOrderedWriterFailedWritePassesTurn(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_ordered.mmap", 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_ordered.mmap")
+	defer rb.Close()
+
+	w := rb.OrderedWriter(0)
+	first, second := w.Ticket(), w.Ticket()
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.WriteMsg(second, []byte("second"))
+		done <- err
+	}()
+
+	// The first write is too large but must not stall the second
+	if _, err := w.WriteMsg(first, make([]byte, 1024)); err != ErrMessageTooLarge {
+		t.Errorf("Expected ErrMessageTooLarge, got: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Failed to write after a failed turn: %v", err)
+	}
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "second" {
+		t.Errorf("Expected second, got %q (%v)", msg, err)
+	}
+}
@@ -0,0 +1,58 @@
+package ringbuffer
+
+import "math"
+
+// PendingSizes returns the lengths of up to n buffered messages, oldest
+// first, without consuming them, so a reader can size its destination
+// before reading a batch. With WithCompression or WithEncryption the
+// lengths are those of the opened messages, and signals have length 0. An
+// empty buffer returns an empty slice. With both options every message is
+// decrypted to find its length.
+func (r *RingBuffer) PendingSizes(n int) ([]int, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+	if n < 0 {
+		return nil, ErrInvalidSize
+	}
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return nil, err
+	}
+	var sizes []int
+	left, pos := r.used(head, tail), tail
+	for len(sizes) < n && left > 0 {
+		f, err := r.frameBefore(pos, left)
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.storedSize(f)
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, int(size))
+		left -= f.footprint()
+		pos = f.next
+	}
+	return sizes, nil
+}
+
+// LargestMsgSize returns the length of the largest buffered message, as
+// ReadMsg would return it, without consuming anything, or 0 for an empty
+// buffer. Every frame is visited, and a stored length that runs past head
+// or beyond what the buffer can hold returns ErrCorruptHeader.
+func (r *RingBuffer) LargestMsgSize() (int, error) {
+	sizes, err := r.PendingSizes(math.MaxInt)
+	if err != nil {
+		return 0, err
+	}
+	largest := 0
+	for _, size := range sizes {
+		largest = max(largest, size)
+	}
+	return largest, nil
+}
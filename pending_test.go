@@ -0,0 +1,88 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+func TestPendingSizes(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_pending.mmap", headerSize+224, true, WithFlags(), WithEncryption(testKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_pending.mmap")
+	defer rb.Close()
+
+	if sizes, err := rb.PendingSizes(10); err != nil || len(sizes) != 0 {
+		t.Errorf("Expected no sizes on an empty buffer, got %v (%v)", sizes, err)
+	}
+
+	// Wrap the head so the walk crosses the end of the buffer
+	if ok, err := rb.WriteMsg(make([]byte, 100)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	for _, n := range []int{30, 0, 40, 7} {
+		if ok, err := rb.WriteMsgFlags(make([]byte, n), 1); !ok || err != nil {
+			t.Fatalf("Failed to write %d bytes: %v", n, err)
+		}
+	}
+
+	sizes, err := rb.PendingSizes(3)
+	if err != nil || len(sizes) != 3 || sizes[0] != 30 || sizes[1] != 0 || sizes[2] != 40 {
+		t.Errorf("Expected [30 0 40], got %v (%v)", sizes, err)
+	}
+	if sizes, err := rb.PendingSizes(10); err != nil || len(sizes) != 4 || sizes[3] != 7 {
+		t.Errorf("Expected 4 sizes ending in 7, got %v (%v)", sizes, err)
+	}
+
+	// Nothing was consumed
+	if msg, err := rb.ReadMsg(); err != nil || len(msg) != 30 {
+		t.Errorf("Expected the 30-byte message first, got %d bytes (%v)", len(msg), err)
+	}
+	if _, err := rb.PendingSizes(-1); err != ErrInvalidSize {
+		t.Errorf("Expected ErrInvalidSize, got: %v", err)
+	}
+}
+
+func TestLargestMsgSize(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_largest.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_largest.mmap")
+	defer rb.Close()
+
+	if n, err := rb.LargestMsgSize(); n != 0 || err != nil {
+		t.Errorf("Expected 0 on an empty buffer, got %d (%v)", n, err)
+	}
+	for _, n := range []int{12, 300, 5, 120} {
+		if ok, err := rb.WriteMsg(make([]byte, n)); !ok || err != nil {
+			t.Fatalf("Failed to write %d bytes: %v", n, err)
+		}
+	}
+	if n, err := rb.LargestMsgSize(); n != 300 || err != nil {
+		t.Errorf("Expected 300, got %d (%v)", n, err)
+	}
+
+	// Nothing was consumed; once the largest is read the next one counts
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if n, err := rb.LargestMsgSize(); n != 120 || err != nil {
+		t.Errorf("Expected 120, got %d (%v)", n, err)
+	}
+
+	// A damaged length is reported rather than trusted
+	_, tail := rb.GetHeadTail()
+	binary.LittleEndian.PutUint32(rb.buf[tail:], 5000)
+	if _, err := rb.LargestMsgSize(); err != ErrCorruptHeader {
+		t.Errorf("Expected ErrCorruptHeader, got: %v", err)
+	}
+}
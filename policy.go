@@ -0,0 +1,192 @@
+package ringbuffer
+
+import (
+	"time"
+)
+
+// FullPolicy selects what WriteMsg does when the message does not fit.
+type FullPolicy int
+
+const (
+	// FailOnFull returns ErrBufferFull immediately. This is the default.
+	FailOnFull FullPolicy = iota
+	// BlockOnFull waits for a reader to free enough space, up to the
+	// timeout set with WithBlockTimeout, then returns ErrBufferFull.
+	BlockOnFull
+	// OverwriteOnFull drops the oldest messages until the new one fits.
+	OverwriteOnFull
+)
+
+// blockPollInterval bounds a single wait for space, so writers also notice
+// readers in other processes, which cannot signal them directly.
+const blockPollInterval = 10 * time.Millisecond
+
+// String returns the name of the policy.
+func (p FullPolicy) String() string {
+	switch p {
+	case FailOnFull:
+		return "fail"
+	case BlockOnFull:
+		return "block"
+	case OverwriteOnFull:
+		return "overwrite"
+	default:
+		return "unknown"
+	}
+}
+
+// WithFullPolicy selects what WriteMsg does when the buffer is full.
+// OverwriteOnFull cannot be combined with reader groups. A message larger
+// than MaxMsgSize fails with ErrMessageTooLarge under every policy.
+func WithFullPolicy(p FullPolicy) Option {
+	return func(o *options) {
+		o.fullPolicy = p
+	}
+}
+
+// WithBlockTimeout sets how long BlockOnFull waits for space. Zero, the
+// default, waits until space is freed or the buffer is closed.
+func WithBlockTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.blockTimeout = d
+	}
+}
+
+// FullPolicy returns the full policy in use.
+func (r *RingBuffer) FullPolicy() FullPolicy {
+	return r.opts.fullPolicy
+}
+
+// makeRoom is called by a writer whose message of msgLen bytes did not fit.
+// It applies the full policy and returns nil if the write should be retried.
+// Caller must hold writeMu.
+func (r *RingBuffer) makeRoom(msgLen, credit uint32, deadline time.Time) error {
+	switch r.opts.fullPolicy {
+	case BlockOnFull:
+		return r.waitForSpace(msgLen, credit, deadline)
+	case OverwriteOnFull:
+		return r.dropOldest()
+	default:
+		return ErrBufferFull
+	}
+}
+
+// waitForSpace blocks until a message of msgLen bytes fits, the deadline
+// passes or the buffer is being closed or quiesced. A zero deadline waits
+// forever. Readers in this process wake it only once the message fits,
+// see notifyFreed; the poll interval only serves readers elsewhere.
+// Caller must hold writeMu.
+func (r *RingBuffer) waitForSpace(msgLen, credit uint32, deadline time.Time) error {
+	r.notifyMu.Lock()
+	defer r.notifyMu.Unlock()
+
+	r.wantSpace(msgLen, credit)
+	defer r.wanted.Store(0)
+
+	for {
+		if r.closing.Load() || r.draining.Load() {
+			return ErrClosed
+		}
+		// Checked under notifyMu, so a read freeing enough space after
+		// this point is guaranteed to wake us
+		if _, err := r.reserve(msgLen, credit); err != ErrBufferFull {
+			return nil
+		}
+
+		wait := blockPollInterval
+		if !deadline.IsZero() {
+			left := time.Until(deadline)
+			if left <= 0 {
+				return ErrBufferFull
+			}
+			if left < wait {
+				wait = left
+			}
+		}
+		timer := time.AfterFunc(wait, r.notifySpace)
+		r.spaceFreed.Wait()
+		timer.Stop()
+	}
+}
+
+// wantSpace records the message the writer blocked in waitForSpace needs
+// room for. writeMu admits one such writer at a time; zero means none.
+// The length is stored plus one so an empty signal still counts.
+func (r *RingBuffer) wantSpace(msgLen, credit uint32) {
+	r.wanted.Store(uint64(msgLen+1)<<32 | uint64(credit))
+}
+
+// notifyFreed is called by readers after moving the tail. It wakes
+// WaitReadableOrWritable callers, and the blocked writer only once its
+// message fits, so a slow reader freeing a frame at a time does not wake
+// it over and over. Caller must hold readMu.
+func (r *RingBuffer) notifyFreed() {
+	if r.waiters.Load() > 0 || r.wantedFits() {
+		r.notifySpace()
+	}
+}
+
+// wantedFits reports whether the message the blocked writer waits for
+// fits now. A corrupt header also wakes it, to see the error.
+func (r *RingBuffer) wantedFits() bool {
+	w := r.wanted.Load()
+	if w == 0 {
+		return false
+	}
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return true
+	}
+	_, err = r.fit(head, tail, uint32(w>>32)-1, uint32(w))
+	return err != ErrBufferFull
+}
+
+// CanWrite reports whether a message of n bytes fits without waiting,
+// growing or dropping anything, the predicate BlockOnFull writers wait on.
+// It is only a hint: another writer may take the space first.
+func (r *RingBuffer) CanWrite(n int) bool {
+	if n < 0 || r.opts.latest {
+		return false
+	}
+	msgLen := uint32(n)
+	if n > 0 {
+		msgLen += r.opts.sealOverhead()
+	}
+	if msgLen > r.maxPayload() || !r.enter() {
+		return false
+	}
+	defer r.leave()
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return false
+	}
+	_, err = r.fit(head, tail, msgLen, 0)
+	return err == nil
+}
+
+// dropOldest discards the message at tail to make room for a new one.
+// Caller must hold writeMu.
+func (r *RingBuffer) dropOldest() error {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	f, err := r.peekFrame()
+	if err == ErrBufferEmpty {
+		// Nothing left to drop and it still does not fit
+		return ErrBufferFull
+	}
+	if err != nil {
+		return err
+	}
+	r.advanceTail(f)
+	r.stats.add(Stats{Dropped: 1})
+	r.warnf("buffer full, dropped the oldest message of %d bytes", f.msgLen)
+	return nil
+}
+
+// notifySpace wakes writers blocked in waitForSpace
+func (r *RingBuffer) notifySpace() {
+	r.notifyMu.Lock()
+	r.spaceFreed.Broadcast()
+	r.notifyMu.Unlock()
+}
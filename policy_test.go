@@ -0,0 +1,269 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// fillBuffer writes 10-byte messages through a second, FailOnFull handle on
+// the same file until it reports full, and returns how many were written
+func fillBuffer(t *testing.T, filename string) int {
+	t.Helper()
+	filler, err := OpenRingBuffer(filename)
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	defer filler.Close()
+
+	count := 0
+	for {
+		ok, err := filler.WriteMsg([]byte(fmt.Sprintf("msg-%06d", count)))
+		if err == ErrBufferFull {
+			return count
+		}
+		if !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", count, err)
+		}
+		count++
+	}
+}
+
+func TestFullPolicyFail(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_policy_fail.mmap", headerSize+96, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_policy_fail.mmap")
+
+	if rb.FullPolicy() != FailOnFull {
+		t.Errorf("Expected default policy %v, got: %v", FailOnFull, rb.FullPolicy())
+	}
+	fillBuffer(t, "/tmp/test_rb_policy_fail.mmap")
+
+	start := time.Now()
+	if ok, err := rb.WriteMsg([]byte("msg-overflow")); ok || err != ErrBufferFull {
+		t.Errorf("Expected ErrBufferFull, got: %v", err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Errorf("FailOnFull should not wait")
+	}
+}
+
+func TestFullPolicyBlockTimeout(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_policy_block_to.mmap", headerSize+96, true,
+		WithFullPolicy(BlockOnFull), WithBlockTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_policy_block_to.mmap")
+
+	fillBuffer(t, "/tmp/test_rb_policy_block_to.mmap")
+
+	start := time.Now()
+	ok, err := rb.WriteMsg([]byte("msg-blocked"))
+	if ok || err != ErrBufferFull {
+		t.Errorf("Expected ErrBufferFull after timeout, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected write to wait for the timeout, returned after %v", elapsed)
+	}
+}
+
+func TestFullPolicyBlockUntilRead(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_policy_block.mmap", headerSize+96, true, WithFullPolicy(BlockOnFull))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_policy_block.mmap")
+
+	count := fillBuffer(t, "/tmp/test_rb_policy_block.mmap")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rb.ReadMsg()
+	}()
+
+	ok, err := rb.WriteMsg([]byte("msg-blocked"))
+	if !ok || err != nil {
+		t.Fatalf("Expected blocked write to succeed once space was freed, got: %v", err)
+	}
+
+	// The first message was read, the rest plus the new one remain
+	for i := 1; i < count; i++ {
+		readMsg, err := rb.ReadMsg()
+		if err != nil {
+			t.Fatalf("Failed to read message %d: %v", i, err)
+		}
+		if want := fmt.Sprintf("msg-%06d", i); string(readMsg) != want {
+			t.Errorf("Message mismatch. Got: %s, Want: %s", string(readMsg), want)
+		}
+	}
+	readMsg, err := rb.ReadMsg()
+	if err != nil || string(readMsg) != "msg-blocked" {
+		t.Errorf("Expected the blocked message last, got: %s (%v)", string(readMsg), err)
+	}
+}
+
+func TestFullPolicyBlockUntilFits(t *testing.T) {
+	const filename = "/tmp/test_rb_policy_fits.mmap"
+	rb, err := NewRingBuffer(filename, headerSize+96, true, WithFullPolicy(BlockOnFull))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove(filename)
+
+	fillBuffer(t, filename)
+
+	// Needs the space of more than one buffered message
+	msg := make([]byte, 30)
+	if rb.CanWrite(len(msg)) {
+		t.Fatalf("Expected a full buffer")
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := rb.WriteMsg(msg)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// Readers wake the writer only once its message fits
+	for reads := 1; ; reads++ {
+		rb.readMu.Lock()
+		fitsBefore := rb.wantedFits()
+		rb.readMu.Unlock()
+		if fitsBefore {
+			t.Fatalf("Writer woken before its message fits")
+		}
+		if _, err := rb.ReadMsg(); err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+		freed := time.Now()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Expected blocked write to succeed, got: %v", err)
+			}
+			if reads < 2 {
+				t.Errorf("Expected the write to wait for two reads, it finished after %d", reads)
+			}
+			if elapsed := time.Since(freed); elapsed > time.Second {
+				t.Errorf("Write finished %v after enough space was freed", elapsed)
+			}
+			return
+		case <-time.After(3 * blockPollInterval):
+			if rb.CanWrite(len(msg)) {
+				t.Fatalf("Writer still blocked after enough space was freed")
+			}
+		}
+	}
+}
+
+func TestFullPolicyBlockReleasedByClose(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_policy_close.mmap", headerSize+96, true, WithFullPolicy(BlockOnFull))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_policy_close.mmap")
+
+	fillBuffer(t, "/tmp/test_rb_policy_close.mmap")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := rb.WriteMsg([]byte("msg-blocked"))
+		done <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != ErrClosed {
+			t.Errorf("Expected ErrClosed for the blocked writer, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Blocked writer was not released by Close")
+	}
+}
+
+func TestFullPolicyOverwrite(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_policy_overwrite.mmap", headerSize+96, true, WithFullPolicy(OverwriteOnFull))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_policy_overwrite.mmap")
+
+	count := fillBuffer(t, "/tmp/test_rb_policy_overwrite.mmap")
+
+	// Each new message evicts exactly one old one of the same size
+	for i := 0; i < 3; i++ {
+		msg := []byte(fmt.Sprintf("new-%06d", i))
+		if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+			t.Fatalf("Failed to overwrite: %v", err)
+		}
+	}
+
+	var got []string
+	for {
+		readMsg, err := rb.ReadMsg()
+		if err == ErrBufferEmpty {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read: %v", err)
+		}
+		got = append(got, string(readMsg))
+	}
+
+	if len(got) != count {
+		t.Fatalf("Expected %d messages, got %d: %v", count, len(got), got)
+	}
+	if got[0] != "msg-000003" {
+		t.Errorf("Expected the three oldest messages dropped, first is %s", got[0])
+	}
+	if got[len(got)-1] != "new-000002" {
+		t.Errorf("Expected the newest message last, got %s", got[len(got)-1])
+	}
+}
+
+func TestFullPolicyOversizeMessage(t *testing.T) {
+	for _, policy := range []FullPolicy{BlockOnFull, OverwriteOnFull} {
+		rb, err := NewRingBuffer("/tmp/test_rb_policy_oversize.mmap", headerSize+96, true, WithFullPolicy(policy))
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+		count := fillBuffer(t, "/tmp/test_rb_policy_oversize.mmap")
+
+		// A message that never fits must neither wait nor evict anything
+		start := time.Now()
+		if ok, err := rb.WriteMsg(make([]byte, rb.MaxMsgSize()+1)); ok || err != ErrMessageTooLarge {
+			t.Errorf("%v: expected ErrMessageTooLarge, got: %v", policy, err)
+		}
+		if time.Since(start) > 100*time.Millisecond {
+			t.Errorf("%v: oversize message should not wait", policy)
+		}
+
+		read := 0
+		for {
+			if _, err := rb.ReadMsg(); err != nil {
+				break
+			}
+			read++
+		}
+		if read != count {
+			t.Errorf("%v: expected %d messages left, got %d", policy, count, read)
+		}
+
+		rb.Close()
+		os.Remove("/tmp/test_rb_policy_oversize.mmap")
+	}
+}
@@ -0,0 +1,45 @@
+package ringbuffer
+
+import (
+	"errors"
+	"time"
+)
+
+// quiescePollInterval is how often Quiesce checks whether the last writer
+// has left
+const quiescePollInterval = time.Millisecond
+
+var ErrQuiesceTimeout = errors.New("timed out waiting for writers to finish")
+
+// Quiesce stops the buffer from accepting writes and waits up to timeout
+// for writes already in progress to finish. From the moment it is called,
+// new writes and writers blocked by BlockOnFull return ErrClosed. Reads are
+// not affected, so consumers can drain the buffer before Close. A zero
+// timeout waits until the last writer is gone. It returns ErrQuiesceTimeout
+// if a write is still in progress when the timeout expires.
+func (r *RingBuffer) Quiesce(timeout time.Duration) error {
+	r.draining.Store(true)
+	// Release writers waiting for space, they hold writeMu
+	r.notifySpace()
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	// Writers queued on writeMu see draining and return at once, so once
+	// the lock is free no write can be mid-flight
+	for !r.writeMu.TryLock() {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return ErrQuiesceTimeout
+		}
+		time.Sleep(quiescePollInterval)
+	}
+	r.writeMu.Unlock()
+	return nil
+}
+
+// acceptsWrites reports whether new writes may start. Caller must hold
+// writeMu.
+func (r *RingBuffer) acceptsWrites() bool {
+	return !r.closed && !r.draining.Load()
+}
@@ -0,0 +1,48 @@
+package ringbuffer
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestQuiesceReleasesBlockedWriter(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_quiesce.mmap", headerSize+96, true, WithFullPolicy(BlockOnFull))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_quiesce.mmap")
+
+	count := fillBuffer(t, "/tmp/test_rb_quiesce.mmap")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := rb.WriteMsg([]byte("msg-blocked"))
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	if err := rb.Quiesce(time.Second); err != nil {
+		t.Fatalf("Failed to quiesce: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != ErrClosed {
+			t.Errorf("Expected ErrClosed for the blocked writer, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Blocked writer was not released by Quiesce")
+	}
+
+	if ok, err := rb.WriteMsg([]byte("msg-late")); ok || err != ErrClosed {
+		t.Errorf("Expected ErrClosed after Quiesce, got: %v", err)
+	}
+
+	// Messages already written can still be read
+	for i := 0; i < count; i++ {
+		if _, err := rb.ReadMsg(); err != nil {
+			t.Fatalf("Failed to read message %d after Quiesce: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package ringbuffer
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = errors.New("write rate limit exceeded")
+
+// clock is the time source of the rate limiter, replaced in tests
+type clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// realClock is the wall clock
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// rateLimiter is a token bucket holding up to one second's worth of bytes.
+// A write larger than the bucket is let through once the bucket is full and
+// leaves it in debt, so the average rate still holds.
+type rateLimiter struct {
+	mu     sync.Mutex
+	clock  clock
+	rate   float64 // bytes per second, 0 for no limit
+	block  bool    // wait for tokens instead of failing
+	tokens float64
+	last   time.Time
+}
+
+// SetWriteRateLimit caps the payload bytes per second accepted by WriteMsg
+// and WriteMsgFlags on this handle, allowing bursts of up to one second's
+// worth. Writes over the budget return ErrRateLimited, or wait for it with
+// SetWriteRateLimitBlocking. Zero or less removes the limit.
+func (r *RingBuffer) SetWriteRateLimit(bytesPerSec int) {
+	r.limiter.setRate(bytesPerSec)
+}
+
+// SetWriteRateLimitBlocking selects whether a write over the rate limit
+// waits until the budget allows it (true) or returns ErrRateLimited (false,
+// the default). A waiting write does not hold the buffer's write lock.
+func (r *RingBuffer) SetWriteRateLimitBlocking(block bool) {
+	r.limiter.setBlocking(block)
+}
+
+// limitWrite takes n bytes from the write budget
+func (r *RingBuffer) limitWrite(n int) error {
+	return r.limiter.take(n)
+}
+
+// setRate sets the rate in units per second and refills the bucket; zero
+// or less removes the limit
+func (l *rateLimiter) setRate(perSec int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if perSec <= 0 {
+		l.rate = 0
+		return
+	}
+	if l.clock == nil {
+		l.clock = realClock{}
+	}
+	l.rate = float64(perSec)
+	l.tokens = l.rate
+	l.last = l.clock.Now()
+}
+
+// setBlocking selects whether take waits for tokens
+func (l *rateLimiter) setBlocking(block bool) {
+	l.mu.Lock()
+	l.block = block
+	l.mu.Unlock()
+}
+
+// take takes n units from the bucket, waiting for them if blocking is on
+func (l *rateLimiter) take(n int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate == 0 {
+		return nil
+	}
+	now := l.clock.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+
+	need := float64(n)
+	if need > l.rate {
+		// Larger than the bucket: wait for a full bucket, then go into debt
+		need = l.rate
+	}
+	if l.tokens < need {
+		if !l.block {
+			return ErrRateLimited
+		}
+		// Holding mu makes later writers queue behind this one
+		wait := time.Duration((need - l.tokens) / l.rate * float64(time.Second))
+		l.clock.Sleep(wait)
+		l.tokens = need
+		l.last = l.clock.Now()
+	}
+	l.tokens -= float64(n)
+	return nil
+}
+
+// refund returns n units taken by a write that did not go ahead
+func (l *rateLimiter) refund(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate != 0 {
+		l.tokens = min(l.tokens+float64(n), l.rate)
+	}
+}
+
+// RateLimitedWriter writes to a RingBuffer under its own limits on
+// messages and bytes per second, so that one producer sharing a handle
+// with others cannot monopolize the buffer. Each limit is a token bucket
+// holding one second's worth, like SetWriteRateLimit, and the handle's own
+// limit still applies on top. It is safe for concurrent use.
+type RateLimitedWriter struct {
+	rb    *RingBuffer
+	msgs  rateLimiter
+	bytes rateLimiter
+}
+
+// NewRateLimitedWriter returns a writer to rb limited to msgsPerSec messages
+// and bytesPerSec payload bytes per second; zero or less leaves that limit
+// off. Writes over a limit return ErrRateLimited, or wait if block is set.
+func NewRateLimitedWriter(rb *RingBuffer, msgsPerSec, bytesPerSec int, block bool) *RateLimitedWriter {
+	w := &RateLimitedWriter{rb: rb}
+	w.SetLimits(msgsPerSec, bytesPerSec)
+	w.SetBlocking(block)
+	return w
+}
+
+// SetLimits changes both limits at runtime and refills their buckets; zero
+// or less removes a limit.
+func (w *RateLimitedWriter) SetLimits(msgsPerSec, bytesPerSec int) {
+	w.msgs.setRate(msgsPerSec)
+	w.bytes.setRate(bytesPerSec)
+}
+
+// SetBlocking selects whether a write over a limit waits (true) or returns
+// ErrRateLimited (false).
+func (w *RateLimitedWriter) SetBlocking(block bool) {
+	w.msgs.setBlocking(block)
+	w.bytes.setBlocking(block)
+}
+
+// WriteMsg writes a message once both limits allow it, see
+// RingBuffer.WriteMsg.
+// Returns (true, nil) if successful, (false, error) if failed
+func (w *RateLimitedWriter) WriteMsg(msg []byte) (bool, error) {
+	return w.WriteMsgFlags(msg, 0)
+}
+
+// WriteMsgFlags writes a message together with a flags byte once both
+// limits allow it, see RingBuffer.WriteMsgFlags.
+// Returns (true, nil) if successful, (false, error) if failed
+func (w *RateLimitedWriter) WriteMsgFlags(msg []byte, flags byte) (bool, error) {
+	if err := w.msgs.take(1); err != nil {
+		return false, err
+	}
+	if err := w.bytes.take(len(msg)); err != nil {
+		w.msgs.refund(1)
+		return false, err
+	}
+	return w.rb.WriteMsgFlags(msg, flags)
+}
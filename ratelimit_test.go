@@ -0,0 +1,154 @@
+package ringbuffer
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// fakeClock only advances when a rate-limited write sleeps or the test moves it
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestRingBufferRateLimitBlocking(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_ratelimit.mmap", 1<<16, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_ratelimit.mmap")
+	defer rb.Close()
+
+	clk := &fakeClock{now: time.Unix(0, 0)}
+	rb.limiter.clock = clk
+	rb.SetWriteRateLimit(1000)
+	rb.SetWriteRateLimitBlocking(true)
+
+	msg := make([]byte, 100)
+	start := clk.Now()
+	for i := 0; i < 50; i++ {
+		if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+
+	// The first second's worth is a burst, the rest must take the time the
+	// limit allows
+	elapsed := clk.Now().Sub(start)
+	if rate := float64(50*len(msg)-1000) / elapsed.Seconds(); rate > 1000 {
+		t.Errorf("Expected throughput at most 1000 bytes/s, got %.0f over %v", rate, elapsed)
+	}
+	if elapsed < 4*time.Second {
+		t.Errorf("Expected 5000 bytes to take at least 4s, took %v", elapsed)
+	}
+}
+
+func TestRingBufferRateLimitError(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_ratelimit.mmap", 1<<16, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_ratelimit.mmap")
+	defer rb.Close()
+
+	clk := &fakeClock{now: time.Unix(0, 0)}
+	rb.limiter.clock = clk
+	rb.SetWriteRateLimit(1000)
+
+	msg := make([]byte, 100)
+	for i := 0; i < 10; i++ {
+		if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+			t.Fatalf("Failed to write message %d within the burst: %v", i, err)
+		}
+	}
+	if _, err := rb.WriteMsg(msg); err != ErrRateLimited {
+		t.Fatalf("Expected ErrRateLimited after the burst, got: %v", err)
+	}
+
+	// A tenth of a second refills 100 bytes
+	clk.Sleep(100 * time.Millisecond)
+	if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+		t.Fatalf("Failed to write message after refill: %v", err)
+	}
+	if _, err := rb.WriteMsg(msg); err != ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited with the bucket empty, got: %v", err)
+	}
+
+	// Rejected writes did not consume anything
+	count := 0
+	for {
+		if _, err := rb.ReadMsg(); err != nil {
+			break
+		}
+		count++
+	}
+	if count != 11 {
+		t.Errorf("Expected 11 messages written, got %d", count)
+	}
+
+	// Removing the limit lets writes through again
+	rb.SetWriteRateLimit(0)
+	if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+		t.Errorf("Failed to write message without a limit: %v", err)
+	}
+}
+
+func TestRateLimitedWriter(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_ratelimit.mmap", 1<<16, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_ratelimit.mmap")
+	defer rb.Close()
+
+	clk := &fakeClock{now: time.Unix(0, 0)}
+	w := NewRateLimitedWriter(rb, 0, 0, false)
+	w.msgs.clock = clk
+	w.bytes.clock = clk
+	w.SetLimits(5, 1000)
+
+	// The message limit is hit first for small messages
+	for i := 0; i < 5; i++ {
+		if ok, err := w.WriteMsg([]byte("tiny")); !ok || err != nil {
+			t.Fatalf("Failed to write message %d within the burst: %v", i, err)
+		}
+	}
+	if _, err := w.WriteMsg([]byte("tiny")); err != ErrRateLimited {
+		t.Fatalf("Expected ErrRateLimited after 5 messages, got: %v", err)
+	}
+
+	// And the byte limit for large ones, without using up a message token
+	clk.now = clk.now.Add(time.Second)
+	if ok, err := w.WriteMsg(make([]byte, 900)); !ok || err != nil {
+		t.Fatalf("Failed to write within the byte budget: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := w.WriteMsg(make([]byte, 200)); err != ErrRateLimited {
+			t.Fatalf("Expected ErrRateLimited over the byte budget, got: %v", err)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		if ok, err := w.WriteMsg([]byte("x")); !ok || err != nil {
+			t.Fatalf("Failed to write small message %d: %v", i, err)
+		}
+	}
+
+	// Blocking waits instead, and removing the limits lets everything through
+	w.SetBlocking(true)
+	start := clk.Now()
+	if ok, err := w.WriteMsg([]byte("waits")); !ok || err != nil {
+		t.Fatalf("Failed to write when blocking: %v", err)
+	}
+	if clk.Now() == start {
+		t.Errorf("Expected the blocking write to wait")
+	}
+	w.SetLimits(0, 0)
+	for i := 0; i < 100; i++ {
+		if ok, err := w.WriteMsg([]byte("free")); !ok || err != nil {
+			t.Fatalf("Failed to write without limits: %v", err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	groupNameSize   = 28                // bytes reserved for a group name
+	groupSlotSize   = groupNameSize + 4 // name followed by the committed offset
+	maxReaderGroups = 64                // upper bound on WithReaderGroups
+)
+
+var (
+	ErrInvalidGroup        = errors.New("invalid reader group")
+	ErrNoGroupSlot         = errors.New("no free reader group slot")
+	ErrIncompatibleOptions = errors.New("incompatible options")
+)
+
+// WithReaderGroups reserves n named reader group slots in the header. Each
+// group keeps its own committed offset in the file and the writer only
+// reclaims space that every group has committed past. Reader groups require
+// StrategySentinel. OpenRingBuffer reads the slot count from the file.
+func WithReaderGroups(n int) Option {
+	return func(o *options) {
+		o.groups = n
+	}
+}
+
+// Reader consumes messages on behalf of a named reader group. Messages read
+// are only released to the writer once Commit is called, and a Reader
+// reopened for the same group resumes from the last committed offset.
+//
+// Once reader groups are in use the tail is owned by Commit, so the buffer's
+// own ReadMsg, ReadMsgFlags and Splice return ErrIncompatibleOptions.
+type Reader struct {
+	rb   *RingBuffer
+	slot uint32 // offset of the group's slot in the header
+	pos  uint32 // next frame to read, at or ahead of the committed offset
+}
+
+// ReaderGroup returns a Reader for the named group, creating the group at
+// the current tail, or head with StartAtNewest, if it does not exist yet. Names are at most 28 bytes.
+// Creating groups from several processes at once is not synchronized.
+func (r *RingBuffer) ReaderGroup(name string) (*Reader, error) {
+	if name == "" || len(name) > groupNameSize {
+		return nil, ErrInvalidGroup
+	}
+
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	var free uint32
+	for i := 0; i < r.opts.groups; i++ {
+		slot := headerSize + uint32(i)*groupSlotSize
+		stored := r.buf[slot : slot+groupNameSize]
+		if string(bytes.TrimRight(stored, "\x00")) == name {
+			return &Reader{rb: r, slot: slot, pos: r.groupOffset(slot)}, nil
+		}
+		if free == 0 && stored[0] == 0 {
+			free = slot
+		}
+	}
+	if free == 0 {
+		return nil, ErrNoGroupSlot
+	}
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return nil, err
+	}
+	start := tail
+	if r.opts.startAt == StartAtNewest {
+		start = head
+	}
+	binary.LittleEndian.PutUint32(r.buf[free+groupNameSize:free+groupSlotSize], start)
+	copy(r.buf[free:free+groupNameSize], name)
+	return &Reader{rb: r, slot: free, pos: start}, nil
+}
+
+// groupOffset returns the committed offset stored in a group slot
+func (r *RingBuffer) groupOffset(slot uint32) uint32 {
+	return binary.LittleEndian.Uint32(r.buf[slot+groupNameSize : slot+groupSlotSize])
+}
+
+// ReadMsg reads the group's next message without releasing it
+// Returns (msg, nil) if successful, (nil, error) if failed
+func (g *Reader) ReadMsg() ([]byte, error) {
+	r := g.rb
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+	if err := readFault(); err != nil {
+		return nil, err
+	}
+
+	head, _, err := r.loadHeadTail()
+	if err != nil {
+		return nil, err
+	}
+	if !r.inData(g.pos) {
+		return nil, ErrCorruptHeader
+	}
+	if r.full.empty(r, head, g.pos) {
+		return nil, ErrBufferEmpty
+	}
+
+	f, err := r.frameBefore(g.pos, r.distance(head, g.pos))
+	if err != nil {
+		return nil, err
+	}
+	msg := make([]byte, f.msgLen)
+	r.copyOut(f.payload(), msg)
+	g.pos = f.next
+	return r.open(msg)
+}
+
+// Commit persists the group's read position and lets the writer reclaim
+// everything all groups have committed past
+func (g *Reader) Commit() error {
+	r := g.rb
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+
+	binary.LittleEndian.PutUint32(r.buf[g.slot+groupNameSize:g.slot+groupSlotSize], g.pos)
+
+	// The reclaim point is the committed offset furthest behind head
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return err
+	}
+	reclaim, lag := head, uint32(0)
+	for i := 0; i < r.opts.groups; i++ {
+		slot := headerSize + uint32(i)*groupSlotSize
+		if r.buf[slot] == 0 {
+			continue
+		}
+		off := r.groupOffset(slot)
+		if !r.inData(off) {
+			return ErrCorruptHeader
+		}
+		if d := r.distance(head, off); d > lag {
+			reclaim, lag = off, d
+		}
+	}
+
+	// Never move the tail backwards over space already released
+	if r.distance(head, reclaim) <= r.distance(head, tail) {
+		r.setTail(reclaim)
+		r.notifyFreed()
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestReaderGroupsResumeAfterReopen(t *testing.T) {
+	filename := "/tmp/test_rb_groups.mmap"
+	rb, err := NewRingBuffer(filename, 1024, true, WithReaderGroups(4))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+
+	fast, err := rb.ReaderGroup("fast")
+	if err != nil {
+		t.Fatalf("Failed to create reader group: %v", err)
+	}
+	slow, err := rb.ReaderGroup("slow")
+	if err != nil {
+		t.Fatalf("Failed to create reader group: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("msg-%d", i))); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+
+	// The fast group reads and commits everything, the slow one only two
+	for i := 0; i < 6; i++ {
+		if _, err := fast.ReadMsg(); err != nil {
+			t.Fatalf("Fast group failed to read message %d: %v", i, err)
+		}
+	}
+	if err := fast.Commit(); err != nil {
+		t.Fatalf("Fast group failed to commit: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := slow.ReadMsg(); err != nil {
+			t.Fatalf("Slow group failed to read message %d: %v", i, err)
+		}
+	}
+	if err := slow.Commit(); err != nil {
+		t.Fatalf("Slow group failed to commit: %v", err)
+	}
+	// Read but not committed: must be delivered again after reopen
+	if _, err := slow.ReadMsg(); err != nil {
+		t.Fatalf("Slow group failed to read message 2: %v", err)
+	}
+
+	// The writer may only reclaim what the slow group committed
+	head, tail := rb.GetHeadTail()
+	if tail != rb.groupOffset(slow.slot) || head == tail {
+		t.Errorf("Unexpected reclaim point: head=%d tail=%d", head, tail)
+	}
+	rb.Close()
+
+	rb, err = OpenRingBuffer(filename)
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	defer rb.Close()
+
+	fast, err = rb.ReaderGroup("fast")
+	if err != nil {
+		t.Fatalf("Failed to reopen reader group: %v", err)
+	}
+	slow, err = rb.ReaderGroup("slow")
+	if err != nil {
+		t.Fatalf("Failed to reopen reader group: %v", err)
+	}
+
+	if _, err := fast.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty for fast group, got: %v", err)
+	}
+	for i := 2; i < 6; i++ {
+		readMsg, err := slow.ReadMsg()
+		if err != nil {
+			t.Fatalf("Slow group failed to read message %d: %v", i, err)
+		}
+		if want := fmt.Sprintf("msg-%d", i); string(readMsg) != want {
+			t.Errorf("Slow group message mismatch. Got: %s, Want: %s", string(readMsg), want)
+		}
+	}
+	if err := slow.Commit(); err != nil {
+		t.Fatalf("Slow group failed to commit: %v", err)
+	}
+
+	// Both groups caught up, so everything is reclaimed
+	head, tail = rb.GetHeadTail()
+	if head != tail {
+		t.Errorf("Expected head == tail after all groups committed, got head=%d tail=%d", head, tail)
+	}
+}
+
+func TestReaderGroupsBlockWriter(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_groups_full.mmap", headerSize+96, true, WithReaderGroups(1))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_groups_full.mmap")
+
+	g, err := rb.ReaderGroup("only")
+	if err != nil {
+		t.Fatalf("Failed to create reader group: %v", err)
+	}
+
+	msg := []byte("0123456789")
+	for {
+		ok, err := rb.WriteMsg(msg)
+		if err == ErrBufferFull {
+			break
+		}
+		if !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+
+	// Reading without committing frees nothing. Two messages are read so the
+	// next one fits even if it has to skip the bytes at the end of the buffer.
+	for i := 0; i < 2; i++ {
+		if _, err := g.ReadMsg(); err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+	}
+	if ok, err := rb.WriteMsg(msg); ok || err != ErrBufferFull {
+		t.Errorf("Expected ErrBufferFull before commit, got: %v", err)
+	}
+
+	if err := g.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+		t.Errorf("Expected write to succeed after commit, got: %v", err)
+	}
+}
+
+func TestReaderGroupsErrors(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_groups_err.mmap", 1024, true, WithReaderGroups(1))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_groups_err.mmap")
+
+	if _, err := rb.ReaderGroup(""); err != ErrInvalidGroup {
+		t.Errorf("Expected ErrInvalidGroup for empty name, got: %v", err)
+	}
+	if _, err := rb.ReaderGroup("a"); err != nil {
+		t.Fatalf("Failed to create reader group: %v", err)
+	}
+	if _, err := rb.ReaderGroup("b"); err != ErrNoGroupSlot {
+		t.Errorf("Expected ErrNoGroupSlot, got: %v", err)
+	}
+
+	// The tail belongs to the groups, so the buffer's own readers are refused
+	if ok, err := rb.WriteMsg([]byte("hello")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions from ReadMsg, got: %v", err)
+	}
+	dst, err := NewRingBuffer("/tmp/test_rb_groups_err_dst.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer dst.Close()
+	defer os.Remove("/tmp/test_rb_groups_err_dst.mmap")
+	if _, err := rb.Splice(dst, 1); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions from Splice, got: %v", err)
+	}
+
+	_, err = NewRingBuffer("/tmp/test_rb_groups_err2.mmap", 1024, true, WithReaderGroups(1), WithStrategy(StrategyCount))
+	defer os.Remove("/tmp/test_rb_groups_err2.mmap")
+	if err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions, got: %v", err)
+	}
+}
+
+func TestReaderGroupsOpenBadLayout(t *testing.T) {
+	filename := "/tmp/test_rb_groups_layout.mmap"
+	defer os.Remove(filename)
+
+	// A file shorter than the header
+	if err := os.WriteFile(filename, make([]byte, 10), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := OpenRingBuffer(filename); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Expected ErrInvalidSize for a 10-byte file, got: %v", err)
+	}
+
+	// A group count whose slots do not fit in the file
+	rb, err := NewRingBuffer(filename, headerSize+96, true, WithReaderGroups(1))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	binary.LittleEndian.PutUint32(rb.buf[groupsOffset:groupsOffset+4], 10)
+	rb.Close()
+
+	if _, err := OpenRingBuffer(filename); !errors.Is(err, ErrCorruptHeader) {
+		t.Errorf("Expected ErrCorruptHeader for a group count too large for the file, got: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+)
+
+// NewRingBufferRegion initializes an empty ring buffer in the byte range
+// [offset, offset+size) of f, which must already be at least that large.
+// Several buffers can be packed into one file this way. Header offsets are
+// relative to the start of the region. f may be closed once this returns.
+func NewRingBufferRegion(f *os.File, offset, size int64, opts ...Option) (*RingBuffer, error) {
+	o, err := parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.hugePages {
+		// The region offset would have to be huge page aligned
+		return nil, ErrIncompatibleOptions
+	}
+	if o.autoGrow > 0 || o.seqIndexPath != "" {
+		// Growing would overwrite whatever follows the region; the index
+		// would need a file of its own next to f
+		return nil, ErrIncompatibleOptions
+	}
+	if err := o.checkSize(int(size)); err != nil {
+		return nil, fmt.Errorf("ringbuffer: region in %s: %w", f.Name(), err)
+	}
+
+	buf, mem, err := mmapRegion(f, offset, size, o)
+	if err != nil {
+		return nil, err
+	}
+	return createMapping(f.Name(), buf, mem, o), nil
+}
+
+// OpenRingBufferRegion maps an existing ring buffer stored in the byte range
+// [offset, offset+size) of f, as created by NewRingBufferRegion. The offset
+// does not need to be page-aligned but must be a multiple of 4. f may be
+// closed once this returns.
+func OpenRingBufferRegion(f *os.File, offset, size int64, opts ...Option) (*RingBuffer, error) {
+	o, err := parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.hugePages {
+		// The region offset would have to be huge page aligned
+		return nil, ErrIncompatibleOptions
+	}
+	if o.autoGrow > 0 || o.seqIndexPath != "" {
+		// Growing would overwrite whatever follows the region; the index
+		// would need a file of its own next to f
+		return nil, ErrIncompatibleOptions
+	}
+	if size <= headerSize {
+		return nil, fmt.Errorf("ringbuffer: region size %d in %s must be larger than header size %d: %w", size, f.Name(), headerSize, ErrInvalidSize)
+	}
+
+	buf, mem, err := mmapRegion(f, offset, size, o)
+	if err != nil {
+		return nil, err
+	}
+	return openMapping(f.Name(), buf, mem, o)
+}
+
+// mmapRegion maps [offset, offset+size) of f. mmap needs a page-aligned
+// offset, so the mapping mem may start up to a page earlier than buf.
+func mmapRegion(f *os.File, offset, size int64, o options) (buf, mem []byte, err error) {
+	if offset < 0 || size <= 0 {
+		return nil, nil, fmt.Errorf("ringbuffer: invalid region offset %d size %d in %s: %w", offset, size, f.Name(), ErrInvalidSize)
+	}
+	if offset%4 != 0 {
+		// The header words are accessed atomically and must stay aligned
+		return nil, nil, fmt.Errorf("ringbuffer: region offset %d in %s is not 4-byte aligned: %w", offset, f.Name(), ErrInvalidSize)
+	}
+
+	// Mapping past the end of the file would fault on first access
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("ringbuffer: stat %s: %w", f.Name(), err)
+	}
+	if offset+size > fileInfo.Size() {
+		return nil, nil, fmt.Errorf("ringbuffer: region offset %d size %d exceeds %s (%d bytes): %w", offset, size, f.Name(), fileInfo.Size(), ErrInvalidSize)
+	}
+
+	pageSize := int64(os.Getpagesize())
+	aligned := offset &^ (pageSize - 1)
+	delta := offset - aligned
+
+	mem, err = mmapFile(f, aligned, int(delta+size), o)
+	if err != nil {
+		return nil, nil, mmapError(f.Name(), offset, int(size), err)
+	}
+	return mem[delta : delta+size : delta+size], mem, nil
+}
@@ -0,0 +1,85 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRingBufferRegions(t *testing.T) {
+	filename := "/tmp/test_rb_regions.mmap"
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer os.Remove(filename)
+	defer f.Close()
+
+	// Three buffers, deliberately not page-aligned
+	const regionSize = 1000
+	if err := f.Truncate(3 * regionSize); err != nil {
+		t.Fatalf("Failed to size file: %v", err)
+	}
+
+	var rbs []*RingBuffer
+	for i := 0; i < 3; i++ {
+		rb, err := NewRingBufferRegion(f, int64(i*regionSize), regionSize)
+		if err != nil {
+			t.Fatalf("Failed to create region %d: %v", i, err)
+		}
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("region-%d", i))); !ok || err != nil {
+			t.Fatalf("Failed to write to region %d: %v", i, err)
+		}
+		rbs = append(rbs, rb)
+	}
+	for _, rb := range rbs {
+		if err := rb.Close(); err != nil {
+			t.Fatalf("Failed to close region: %v", err)
+		}
+	}
+
+	// Reopen in reverse order and check the regions did not overlap
+	for i := 2; i >= 0; i-- {
+		rb, err := OpenRingBufferRegion(f, int64(i*regionSize), regionSize)
+		if err != nil {
+			t.Fatalf("Failed to open region %d: %v", i, err)
+		}
+		readMsg, err := rb.ReadMsg()
+		if err != nil {
+			t.Fatalf("Failed to read region %d: %v", i, err)
+		}
+		if want := fmt.Sprintf("region-%d", i); string(readMsg) != want {
+			t.Errorf("Region %d message mismatch. Got: %s, Want: %s", i, string(readMsg), want)
+		}
+		if err := rb.Close(); err != nil {
+			t.Fatalf("Failed to close region: %v", err)
+		}
+	}
+}
+
+func TestRingBufferRegionOutOfRange(t *testing.T) {
+	filename := "/tmp/test_rb_region_range.mmap"
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer os.Remove(filename)
+	defer f.Close()
+
+	if err := f.Truncate(1024); err != nil {
+		t.Fatalf("Failed to size file: %v", err)
+	}
+
+	_, err = NewRingBufferRegion(f, 512, 1024)
+	if !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Expected ErrInvalidSize for region past end of file, got: %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), filename) {
+		t.Errorf("Expected the file name in the error, got: %v", err)
+	}
+	if _, err := NewRingBufferRegion(f, 2, 256); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Expected ErrInvalidSize for a misaligned offset, got: %v", err)
+	}
+}
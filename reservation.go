@@ -0,0 +1,172 @@
+package ringbuffer
+
+// Reservation holds free space in the buffer for a burst of messages, so
+// that other writers cannot take it. It is returned by ReserveBytes and is
+// not safe for concurrent use.
+type Reservation struct {
+	rb   *RingBuffer
+	left uint32 // reserved bytes not yet drawn down
+}
+
+// ReserveBytes sets aside b bytes of free space for writes made through the
+// returned Reservation and reports whether that much space was free. Other
+// writes only get the space that is left unreserved, so a burst whose
+// frames add up to b bytes is guaranteed to fit: each message takes
+// FrameSize bytes of it. Writes through the Reservation draw it down and may
+// go on past it into unreserved space. Release returns what is left.
+//
+// Reservations are held by this handle and do not bind other handles or
+// processes writing to the same file.
+func (r *RingBuffer) ReserveBytes(b int) (*Reservation, bool) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if !r.acceptsWrites() || b <= 0 || b > r.size {
+		return nil, false
+	}
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return nil, false
+	}
+
+	// A burst wraps around at most once, skipping less than a prefix
+	n := uint32(b) + r.opts.maxPrefixSize() - 1
+	if n > r.free(head, tail, 0) {
+		return nil, false
+	}
+	r.reserved.Add(n)
+	return &Reservation{rb: r, left: n}, true
+}
+
+// FrameSize returns how many bytes of the buffer a message of msgLen bytes
+// takes, including its frame prefix, back-link and encryption overhead. With
+// WithPayloadAlignment or WithCompression it is the most the message can
+// take.
+func (r *RingBuffer) FrameSize(msgLen int) int {
+	return int(r.opts.maxPrefixSize()+r.opts.linkSize()+r.opts.sealOverhead()) + msgLen
+}
+
+// WriteMsg writes a message using the reserved space first.
+// Returns (true, nil) if successful, (false, error) if failed
+func (res *Reservation) WriteMsg(msg []byte) (bool, error) {
+	return res.rb.write(msg, 0, res)
+}
+
+// WriteMsgFlags writes a message together with a flags byte using the
+// reserved space first, see RingBuffer.WriteMsgFlags.
+// Returns (true, nil) if successful, (false, error) if failed
+func (res *Reservation) WriteMsgFlags(msg []byte, flags byte) (bool, error) {
+	return res.rb.write(msg, flags, res)
+}
+
+// Release gives the rest of the reserved space back to other writers. It
+// is safe to call more than once.
+func (res *Reservation) Release() {
+	res.draw(res.left)
+}
+
+// credit returns the reserved bytes a write through res may use, 0 for a
+// write without a reservation
+func (res *Reservation) credit() uint32 {
+	if res == nil {
+		return 0
+	}
+	return res.left
+}
+
+// draw takes up to n bytes off the reservation after a write through it
+func (res *Reservation) draw(n uint32) {
+	if res == nil || res.left == 0 {
+		return
+	}
+	n = min(n, res.left)
+	res.left -= n
+	res.rb.reserved.Add(^(n - 1))
+}
+
+// free returns the space a write may use at pos: what the strategy leaves
+// available, minus the reservations other than the writer's own credit
+func (r *RingBuffer) free(pos, tail, credit uint32) uint32 {
+	avail := r.full.available(r, pos, tail)
+	held := r.reserved.Load() - credit
+	if held >= avail {
+		return 0
+	}
+	return avail - held
+}
+
+// Reserve claims a frame for a message of n bytes at head and returns a
+// slice to build the message in. Calling commit publishes it. The slice
+// points straight into the mapping unless the frame wraps around the end
+// of the buffer or WithEncryption is set, in which case it is a temporary
+// that commit copies in. A full buffer is handled by the full policy as
+// for WriteMsg. Returns ErrIncompatibleOptions with WithCompression, since
+// the frame is sized before the message is written.
+//
+// Reserve holds the write lock until commit, so commit must be called
+// exactly once, and soon: other writes and Close wait for it. The slice
+// must not be used after commit. Not available with WithConcurrentWriters.
+func (r *RingBuffer) Reserve(n int) (buf []byte, commit func() error, err error) {
+	if n <= 0 {
+		return nil, nil, ErrInvalidSize
+	}
+	if r.opts.concurrentWriters {
+		// Claims are made without taking writeMu exclusively
+		return nil, nil, ErrIncompatibleOptions
+	}
+	if r.opts.codec != CodecNone {
+		// The frame is sized before the message is known
+		return nil, nil, ErrIncompatibleOptions
+	}
+	if err := r.limitWrite(n); err != nil {
+		return nil, nil, err
+	}
+
+	r.writeMu.Lock()
+	if !r.acceptsWrites() {
+		r.writeMu.Unlock()
+		return nil, nil, ErrClosed
+	}
+	f, err := r.makeFrame(uint32(n)+r.opts.sealOverhead(), 0)
+	if err != nil {
+		r.countWrite(false, err, 0)
+		r.writeMu.Unlock()
+		return nil, nil, err
+	}
+
+	first, second, end := r.span(f.payload(), f.msgLen)
+	inPlace := len(second) == 0 && r.aead == nil
+	if inPlace {
+		buf = first[:n:n]
+	} else {
+		buf = make([]byte, n)
+	}
+
+	done := false
+	commit = func() error {
+		if done {
+			return nil
+		}
+		done = true
+		defer r.writeMu.Unlock()
+
+		if !inPlace {
+			msg, err := r.seal(buf)
+			if err != nil {
+				return err
+			}
+			end = r.copyIn(f.payload(), msg)
+		}
+		f.time = r.stamp()
+		f.seq = r.sequence()
+		f.next = r.putLink(f, end)
+		r.publish(f)
+		r.countWrite(true, nil, int(f.msgLen))
+
+		if r.opts.debugChecks {
+			return r.checkInvariants("write")
+		}
+		return nil
+	}
+	return buf, commit, nil
+}
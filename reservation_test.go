@@ -0,0 +1,164 @@
+package ringbuffer
+
+import (
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestRingBufferReserveBytes(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_reserve.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_reserve.mmap")
+	defer rb.Close()
+
+	burst := make([]byte, 50)
+	res, ok := rb.ReserveBytes(10 * rb.FrameSize(len(burst)))
+	if !ok {
+		t.Fatalf("Failed to reserve space for the burst")
+	}
+
+	// A concurrent writer fills everything that is not reserved
+	var wg sync.WaitGroup
+	wg.Add(1)
+	stolen := 0
+	go func() {
+		defer wg.Done()
+		for {
+			if _, err := rb.WriteMsg(make([]byte, 20)); err != nil {
+				if err != ErrBufferFull {
+					t.Errorf("Expected ErrBufferFull from the concurrent writer, got: %v", err)
+				}
+				return
+			}
+			stolen++
+		}
+	}()
+	wg.Wait()
+	if stolen == 0 {
+		t.Errorf("Expected the concurrent writer to use the unreserved space")
+	}
+
+	// The whole burst still fits
+	for i := 0; i < 10; i++ {
+		if ok, err := res.WriteMsg(burst); !ok || err != nil {
+			t.Fatalf("Failed to write burst message %d: %v", i, err)
+		}
+	}
+	res.Release()
+	res.Release()
+	if n := rb.reserved.Load(); n != 0 {
+		t.Errorf("Expected no reserved bytes after Release, got %d", n)
+	}
+
+	// Nothing is left to reserve
+	if _, ok := rb.ReserveBytes(100); ok {
+		t.Errorf("Expected ReserveBytes to fail on a full buffer")
+	}
+}
+
+func TestRingBufferReserveBytesRelease(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_reserve.mmap", headerSize+224, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_reserve.mmap")
+	defer rb.Close()
+
+	if _, ok := rb.ReserveBytes(1024); ok {
+		t.Errorf("Expected ReserveBytes to fail for more than the buffer holds")
+	}
+	res, ok := rb.ReserveBytes(200)
+	if !ok {
+		t.Fatalf("Failed to reserve space")
+	}
+	if _, err := rb.WriteMsg(make([]byte, 40)); err != ErrBufferFull {
+		t.Errorf("Expected ErrBufferFull while the space is reserved, got: %v", err)
+	}
+
+	// Released space is available again
+	res.Release()
+	if ok, err := rb.WriteMsg(make([]byte, 40)); !ok || err != nil {
+		t.Errorf("Failed to write after Release: %v", err)
+	}
+}
+
+func TestRingBufferReserve(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"plain", nil},
+		{"encrypted", []Option{WithEncryption(testKey)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rb, err := NewRingBuffer("/tmp/test_rb_reserve.mmap", headerSize+224, true, tc.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create ring buffer: %v", err)
+			}
+			defer os.Remove("/tmp/test_rb_reserve.mmap")
+			defer rb.Close()
+
+			// Move head close to the end so the second message wraps
+			for _, n := range []int{120, 110} {
+				buf, commit, err := rb.Reserve(n)
+				if err != nil {
+					t.Fatalf("Failed to reserve %d bytes: %v", n, err)
+				}
+				for i := range buf {
+					buf[i] = byte(n + i)
+				}
+				if err := commit(); err != nil {
+					t.Fatalf("Failed to commit: %v", err)
+				}
+				msg, err := rb.ReadMsg()
+				if err != nil || len(msg) != n {
+					t.Fatalf("Expected a %d-byte message, got %d (%v)", n, len(msg), err)
+				}
+				for i, b := range msg {
+					if b != byte(n+i) {
+						t.Fatalf("Byte %d of the %d-byte message is %d", i, n, b)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRingBufferReserveUnpublished(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_reserve.mmap", 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_reserve.mmap")
+	defer rb.Close()
+
+	buf, commit, err := rb.Reserve(5)
+	if err != nil {
+		t.Fatalf("Failed to reserve: %v", err)
+	}
+	copy(buf, "hello")
+
+	// Readers see nothing before commit
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty before commit, got: %v", err)
+	}
+	if err := commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if err := commit(); err != nil {
+		t.Errorf("Expected a second commit to do nothing, got: %v", err)
+	}
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "hello" {
+		t.Errorf("Expected hello, got %q (%v)", msg, err)
+	}
+
+	if _, _, err := rb.Reserve(0); err != ErrInvalidSize {
+		t.Errorf("Expected ErrInvalidSize for an empty reservation, got: %v", err)
+	}
+	if _, _, err := rb.Reserve(1024); err != ErrMessageTooLarge {
+		t.Errorf("Expected ErrMessageTooLarge, got: %v", err)
+	}
+}
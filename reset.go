@@ -0,0 +1,57 @@
+package ringbuffer
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Reset discards every buffered message, leaving the buffer empty with head
+// and tail at the start of the data region, and increments the generation.
+// Sequence numbers keep counting. It holds both locks, so writes and reads
+// in progress finish first; other handles must not use the buffer while it
+// runs. Returns ErrIncompatibleOptions with reader groups, whose committed
+// offsets point into the data, and with WithLatestSlot.
+func (r *RingBuffer) Reset() error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+	if r.opts.groups > 0 || r.opts.latest {
+		return ErrIncompatibleOptions
+	}
+
+	r.relayout(nil)
+	if r.opts.concurrentWriters {
+		// The next claim starts at the new head
+		r.claimed.Store((r.claimed.Load()>>32 + 1) << 32)
+	}
+	if r.opts.debugChecks {
+		return r.checkInvariants("reset")
+	}
+	return nil
+}
+
+// Generation returns a counter stored in the header that changes whenever
+// offsets into the buffer stop meaning what they did: Reset, Defragment and
+// growing with WithAutoGrow increment it, and creating a buffer over an
+// existing file continues from the file's value. A reader keeping its own
+// offsets, from ReadOffset or an OffsetStore, can record the generation with
+// them and, if it changed, resume from ReadOffset instead. It returns 0 once
+// Close has started.
+func (r *RingBuffer) Generation() uint32 {
+	if !r.enter() {
+		return 0
+	}
+	defer r.leave()
+	return atomic.LoadUint32(r.generationPtr())
+}
+
+// generationPtr returns the generation counter in the header. Like the
+// used-bytes counter it is accessed atomically, as other processes read it.
+func (r *RingBuffer) generationPtr() *uint32 {
+	return (*uint32)(unsafe.Pointer(&r.buf[generationOffset]))
+}
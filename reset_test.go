@@ -0,0 +1,127 @@
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestRingBufferReset(t *testing.T) {
+	for _, s := range []Strategy{StrategySentinel, StrategyCount} {
+		rb, err := NewRingBuffer("/tmp/test_rb_reset.mmap", 256, true, WithStrategy(s), WithDebugChecks())
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+		generation := rb.Generation()
+
+		for _, msg := range []string{"one", "two", "three"} {
+			if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+				t.Fatalf("%v: failed to write message: %v", s, err)
+			}
+		}
+		if _, err := rb.ReadMsg(); err != nil {
+			t.Fatalf("%v: failed to read message: %v", s, err)
+		}
+		stale := rb.ReadOffset()
+
+		if err := rb.Reset(); err != nil {
+			t.Fatalf("%v: failed to reset: %v", s, err)
+		}
+		if got := rb.Generation(); got != generation+1 {
+			t.Errorf("%v: expected generation %d after Reset, got %d", s, generation+1, got)
+		}
+		if head, tail := rb.GetHeadTail(); head != rb.dataStart || tail != rb.dataStart {
+			t.Errorf("%v: expected head and tail at %d, got %d and %d", s, rb.dataStart, head, tail)
+		}
+		if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+			t.Errorf("%v: expected ErrBufferEmpty after Reset, got %v", s, err)
+		}
+		if err := rb.SetReadOffset(stale); err != ErrInvalidOffset {
+			t.Errorf("%v: expected ErrInvalidOffset for an offset from before Reset, got %v", s, err)
+		}
+
+		if ok, err := rb.WriteMsg([]byte("four")); !ok || err != nil {
+			t.Fatalf("%v: failed to write message after Reset: %v", s, err)
+		}
+		if msg, err := rb.ReadMsg(); err != nil || string(msg) != "four" {
+			t.Errorf("%v: expected \"four\", got %q (%v)", s, msg, err)
+		}
+		rb.Close()
+		if err := rb.Reset(); err != ErrClosed {
+			t.Errorf("%v: expected ErrClosed, got %v", s, err)
+		}
+		if got := rb.Generation(); got != 0 {
+			t.Errorf("%v: expected generation 0 once closed, got %d", s, got)
+		}
+	}
+	os.Remove("/tmp/test_rb_reset.mmap")
+}
+
+func TestRingBufferGeneration(t *testing.T) {
+	const filename = "/tmp/test_rb_generation.mmap"
+	rb, err := NewRingBuffer(filename, headerSize+224, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	generation := rb.Generation()
+
+	// Defragment moves the messages, so it counts as well
+	if ok, err := rb.WriteMsg([]byte("msg")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if err := rb.Defragment(); err != nil {
+		t.Fatalf("Failed to defragment: %v", err)
+	}
+	generation++
+	if got := rb.Generation(); got != generation {
+		t.Errorf("Expected generation %d after Defragment, got %d", generation, got)
+	}
+
+	// Other handles read it from the header
+	other, err := OpenRingBuffer(filename)
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	if got := other.Generation(); got != generation {
+		t.Errorf("Expected generation %d in another handle, got %d", generation, got)
+	}
+	other.Close()
+	rb.Close()
+
+	// Creating a buffer over the file carries the generation on
+	rb, err = NewRingBuffer(filename, headerSize+224, false)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	generation++
+	if got := rb.Generation(); got != generation {
+		t.Errorf("Expected generation %d after recreating the buffer, got %d", generation, got)
+	}
+	rb.Close()
+
+	grown, err := NewRingBuffer(filename, headerSize+96, true, WithAutoGrow(512))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer grown.Close()
+	generation = grown.Generation()
+	for grown.size < 512 {
+		if ok, err := grown.WriteMsg(make([]byte, 40)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	if got := grown.Generation(); got != generation+2 {
+		t.Errorf("Expected generation %d after growing twice, got %d", generation+2, got)
+	}
+
+	groups, err := NewRingBuffer("/tmp/test_rb_generation_groups.mmap", headerSize+224, true, WithReaderGroups(1))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_generation_groups.mmap")
+	defer groups.Close()
+	if err := groups.Reset(); !errors.Is(err, ErrIncompatibleOptions) {
+		t.Errorf("Expected ErrIncompatibleOptions with reader groups, got %v", err)
+	}
+}
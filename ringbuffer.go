@@ -1,39 +1,141 @@
 package ringbuffer
 
 import (
-	"encoding/binary"
+	"bytes"
+	"crypto/cipher"
 	"errors"
+	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
+	"unsafe"
 )
 
 const (
-	headerSize = 8 // 4 bytes for head, 4 bytes for tail
+	formatOffset     = FormatOffset   // offset of the format word (magic, version, strategy, features)
+	headOffset       = HeadOffset     // offset of the head pointer in the header
+	tailOffset       = TailOffset     // offset of the tail pointer in the header
+	usedOffset       = UsedOffset     // offset of the used-bytes counter (StrategyCount only)
+	futexOffset      = 16             // offset of the futex word (WithFutex only)
+	groupsOffset     = 20             // offset of the number of reader group slots, 2 bytes
+	cleanOffset      = 22             // offset of the clean shutdown flag, 2 bytes
+	generationOffset = 24             // offset of the generation counter, see Generation
+	featuresOffset   = FeaturesOffset // offset of the feature bits that do not fit in the format word
+	reservedOffset   = 32             // start of the reserved region, zero until a field takes it
+	headerSize       = HeaderSize     // the fields above and the reserved region, see headerLayout
+)
+
+const (
+	magicValue    = MagicValue    // "RB" in little-endian byte order, low half of the format word
+	formatVersion = FormatVersion // layout version stored in the format word
+
+	featureFlags      = 1 << 0 // frames carry a flags byte (WithFlags)
+	featureTimestamps = 1 << 1 // frames carry a write timestamp (WithTimestamps)
+	featureBackLinks  = 1 << 2 // frames end with a back-link (WithBackLinks)
+	featureEncryption = 1 << 3 // payloads are sealed with AES-GCM (WithEncryption)
+	featureNoWrap     = 1 << 4 // frames never wrap around the end (WithNoWrapWrites)
+	featureAlignShift = 5      // 3 bits of log2 payload alignment (WithPayloadAlignment)
+
+	// Strategies only take the low bit of their nibble, the rest of which
+	// holds further feature bits
+	strategyMask      = 0x1
+	formatCompression = 1 << 21 // payloads start with a codec byte (WithCompression)
+	formatSequence    = 1 << 22 // frames carry a sequence number (WithSequenceNumbers)
+	formatLatest      = 1 << 23 // the data region holds a single value (WithLatestSlot)
+
+	// The format word is full; further features take bits of the features
+	// field, which readers of layout version 3 do not know to check
+	extraCoalescing = 1 << 0 // frames carry a repeat count (WithCoalescing)
+	extraKnown      = extraCoalescing
 )
 
 var (
-	ErrBufferFull  = errors.New("ring buffer is full")
-	ErrInvalidSize = errors.New("invalid message size")
-	ErrBufferEmpty = errors.New("ring buffer is empty")
-	ErrClosed      = errors.New("ring buffer is closed")
+	ErrBufferFull    = errors.New("ring buffer is full")
+	ErrInvalidSize   = errors.New("invalid message size")
+	ErrBufferEmpty   = errors.New("ring buffer is empty")
+	ErrClosed        = errors.New("ring buffer is closed")
+	ErrStrategy      = errors.New("unknown full/empty strategy")
+	ErrCorruptHeader = errors.New("ring buffer header is corrupt")
+	ErrFullPolicy    = errors.New("unknown full policy")
+	ErrMapTooLarge   = errors.New("mapping too large for available memory")
+	ErrHugePages     = errors.New("huge pages not available")
 )
 
+// ErrUninitialized is returned when opening a file that was created or
+// truncated but never initialized by NewRingBuffer, so its header is all
+// zeros. It matches ErrCorruptHeader with errors.Is.
+var ErrUninitialized = fmt.Errorf("ring buffer file is not initialized: %w", ErrCorruptHeader)
+
+// ErrMessageTooLarge is returned for a message larger than MaxMsgSize, which
+// could never fit. It matches ErrInvalidSize with errors.Is.
+var ErrMessageTooLarge = fmt.Errorf("message larger than the buffer can hold: %w", ErrInvalidSize)
+
+// ErrFormatMismatch is returned when opening a file with an option that
+// conflicts with the format recorded in its header, such as a feature the
+// file was created without. It matches ErrIncompatibleOptions with errors.Is.
+var ErrFormatMismatch = fmt.Errorf("options do not match the file format: %w", ErrIncompatibleOptions)
+
 // RingBuffer implements a memory-mapped ring buffer.
 // Memory layout:
-// [magic(4)][head(4)][tail(4)][data...]
+// [format(4)][head(4)][tail(4)][used(4)][futex(4)][groups(2)][clean(2)][generation(4)][reserved(100)][group slots...][data...]
+// The format word holds a magic number, the layout version, the strategy
+// and the feature bits, so a file opened with mismatching options is rejected.
 type RingBuffer struct {
-	buf     []byte
-	size    int
-	writeMu sync.Mutex // Write lock
-	readMu  sync.Mutex // Read lock
-	closed  bool
+	name      string   // file name, for error messages
+	file      *os.File // backing file, nil for regions
+	buf       []byte
+	mem       []byte // whole mapping to unmap; may start before buf
+	size      int
+	dataStart uint32 // offset of the data region, after the group slots
+	msgLimit  int    // largest message the buffer can hold at its largest size
+	prefix    uint32 // size of the per-frame prefix before the payload
+	opts      options
+	full      fullStrategy
+	writeMu   sync.RWMutex // Write lock, shared by WithConcurrentWriters writers
+	readMu    sync.Mutex   // Read lock
+	closed    bool
+
+	reversePos  uint32    // ReadMsgReverse cursor, 0 to start from head; guarded by readMu
+	lastSeq     uint64    // sequence number ReadMsgChecked returned last; guarded by readMu
+	chunkOff    uint32    // bytes of the message at tail ReadMsgChunked has returned; guarded by readMu
+	tailCache   uint32    // last tail the writer saw, 0 if unknown, see WithSPSCFastPath; guarded by writeMu
+	headCache   uint32    // last head the reader saw, 0 if unknown, see WithSPSCFastPath; guarded by readMu
+	index       *seqIndex // sequence number index, see WithSequenceIndex
+	last        frame     // frame writeFrame published last, see WithCoalescing; guarded by writeMu
+	lastGen     uint32    // generation when last was published; guarded by writeMu
+	cleanAtOpen bool      // whether the previous user closed the file, see WasCleanlyClosed
+	limiter     rateLimiter
+	reserved    atomic.Uint32  // bytes held by outstanding reservations, see ReserveBytes
+	aead        cipher.AEAD    // payload cipher, WithEncryption only
+	dedup       *dedupWindow   // keys of recent writes, WithDedup only
+	stats       *statsCounters // WithStats only
+
+	notifyMu    sync.Mutex    // guards spaceFreed
+	spaceFreed  *sync.Cond    // signalled when a reader frees space or a writer adds data
+	waiters     atomic.Int32  // WaitReadableOrWritable callers blocked on spaceFreed
+	wanted      atomic.Uint64 // need of the writer blocked in waitForSpace, see wantSpace
+	doorbell    chan struct{} // one-slot wakeup for ReadMsgBlocking, rung by writes
+	readWaiters atomic.Int32  // callers blocked in ReadMsgBlocking
+	closing     atomic.Bool   // set by Close to release blocked writers and refuse lock-free operations
+	inflight    atomic.Int32  // lock-free operations using the mapping, see enter
+	growing     atomic.Bool   // set by grow to hold off lock-free operations while it remaps
+	draining    atomic.Bool   // set by Quiesce to refuse new writes
+	claimed     atomic.Uint64 // sequence and end of the last claimed frame, WithConcurrentWriters only
+
+	logger atomic.Pointer[func(string, ...any)] // receives warnings, see SetLogger
 }
 
 // NewRingBuffer creates a new mmap-backed ring buffer file
-func NewRingBuffer(mmapFileName string, size int, remove bool) (*RingBuffer, error) {
-	if size <= headerSize {
-		return nil, errors.New("buffer size must be larger than header size")
+func NewRingBuffer(mmapFileName string, size int, remove bool, opts ...Option) (*RingBuffer, error) {
+	o, err := parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.checkSize(size); err != nil {
+		return nil, err
 	}
 
 	if remove {
@@ -42,155 +144,455 @@ func NewRingBuffer(mmapFileName string, size int, remove bool) (*RingBuffer, err
 
 	file, err := os.OpenFile(mmapFileName, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("ringbuffer: open %s: %w", mmapFileName, err)
+	}
+
+	if o.hugePages {
+		if err := checkHugePages(file, int64(size)); err != nil {
+			file.Close()
+			return nil, err
+		}
 	}
-	defer file.Close()
 
 	// Ensure file size is correct
 	if err := file.Truncate(int64(size)); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("ringbuffer: truncate %s to %d bytes: %w", mmapFileName, size, err)
+	}
+
+	mem, err := mmapFile(file, 0, size, o)
+	if err != nil {
+		file.Close()
+		return nil, mmapError(mmapFileName, 0, size, err)
+	}
+
+	// The file stays open for Sync and is closed by Close
+	rb := createMapping(mmapFileName, mem[:size:size], mem, o)
+	rb.file = file
+	return rb.attachIndex()
+}
+
+// OpenRingBuffer maps an existing ring buffer file. Options that change the
+// record format are taken from the header when left out, and must agree
+// with it when given, or it returns ErrFormatMismatch.
+func OpenRingBuffer(mmapFileName string, opts ...Option) (*RingBuffer, error) {
+	o, err := parseOptions(opts)
+	if err != nil {
 		return nil, err
 	}
 
-	buf, err := syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	file, err := os.OpenFile(mmapFileName, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ringbuffer: open %s: %w", mmapFileName, err)
+	}
+
+	fileInfo, err := os.Stat(mmapFileName)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("ringbuffer: stat %s: %w", mmapFileName, err)
+	}
+
+	size := int(fileInfo.Size())
+	if size == 0 {
+		// Created with touch or similar; mmap would fail with EINVAL
+		file.Close()
+		return nil, fmt.Errorf("ringbuffer: %s is empty, create it with NewRingBuffer: %w", mmapFileName, ErrUninitialized)
+	}
+	if size < headerSize {
+		// Too short to have been created by NewRingBuffer, so probably cut
+		// off; no need to map it to find out
+		file.Close()
+		return nil, fmt.Errorf("ringbuffer: %s: size %d is smaller than header size %d, the file may be truncated: %w", mmapFileName, size, headerSize, ErrInvalidSize)
+	}
+	if o.hugePages {
+		if err := checkHugePages(file, int64(size)); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	mem, err := mmapFile(file, 0, size, o)
 	if err != nil {
+		file.Close()
+		return nil, mmapError(mmapFileName, 0, size, err)
+	}
+
+	// The file stays open for Sync and is closed by Close
+	rb, err := openMapping(mmapFileName, mem[:size:size], mem, o)
+	if err != nil {
+		file.Close()
 		return nil, err
 	}
+	rb.file = file
+	return rb.attachIndex()
+}
+
+// mmapError wraps a failed mmap of size bytes at offset in name. ENOMEM
+// additionally matches ErrMapTooLarge, since it usually means the size does
+// not fit in the address space or the kernel's overcommit limit.
+func mmapError(name string, offset int64, size int, err error) error {
+	if errors.Is(err, syscall.ENOMEM) {
+		return fmt.Errorf("ringbuffer: mmap %s at %d (%d bytes): %w: %w; use a smaller size or allow overcommit (vm.overcommit_memory)", name, offset, size, ErrMapTooLarge, err)
+	}
+	return fmt.Errorf("ringbuffer: mmap %s at %d (%d bytes): %w", name, offset, size, err)
+}
+
+// createMapping initializes an empty ring buffer in buf, which lies within
+// the mapping mem
+func createMapping(name string, buf, mem []byte, o options) *RingBuffer {
+	// Readers of an old buffer in the file should see the generation change
+	generation := uint32(fieldGeneration.get(buf)) + 1
 
 	// Zero out the entire buffer
 	for i := range buf {
 		buf[i] = 0
 	}
 
-	rb := &RingBuffer{
-		buf:  buf,
-		size: size,
-	}
+	rb := newRingBuffer(name, buf, mem, o)
 
 	// Initialize the buffer
 	rb.initialize()
-	return rb, nil
+	atomic.StoreUint32(rb.generationPtr(), generation)
+	rb.cleanAtOpen = true
+	return rb
 }
 
-func OpenRingBuffer(mmapFileName string) (*RingBuffer, error) {
-	file, err := os.OpenFile(mmapFileName, os.O_RDWR, 0644)
-	if err != nil {
-		return nil, err
+// openMapping wraps an existing ring buffer in buf, which lies within the
+// mapping mem. The mapping is released if the header cannot be used.
+func openMapping(name string, buf, mem []byte, o options) (*RingBuffer, error) {
+	if len(buf) < headerSize {
+		syscall.Munmap(mem)
+		return nil, fmt.Errorf("ringbuffer: %s: size %d is smaller than header size %d: %w", name, len(buf), headerSize, ErrInvalidSize)
 	}
-	defer file.Close()
-
-	fileInfo, err := os.Stat(mmapFileName)
+	// Take whatever the options leave out from the format the file records
+	o, err := o.adoptFormat(buf)
 	if err != nil {
+		syscall.Munmap(mem)
 		return nil, err
 	}
 
-	size := int(fileInfo.Size())
-
-	buf, err := syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
-	if err != nil {
+	// The number of reader group slots is part of the file layout
+	o.groups = int(fieldGroups.get(buf))
+	if err := o.validate(); err != nil {
+		syscall.Munmap(mem)
 		return nil, err
 	}
+	if int(o.dataStart()) >= len(buf) {
+		syscall.Munmap(mem)
+		return nil, fmt.Errorf("ringbuffer: %s: %d reader group slots do not fit in %d bytes: %w", name, o.groups, len(buf), ErrCorruptHeader)
+	}
 
-	rb := &RingBuffer{
-		buf:  buf,
-		size: size,
+	r := newRingBuffer(name, buf, mem, o)
+	if o.startAt == StartAtNewest && o.groups == 0 {
+		if err := r.skipBacklog(); err != nil {
+			syscall.Munmap(mem)
+			return nil, err
+		}
 	}
+	r.markOpen()
+	return r, nil
+}
 
-	return rb, nil
+// newRingBuffer wraps a mapping using already validated options
+func newRingBuffer(name string, buf, mem []byte, o options) *RingBuffer {
+	r := &RingBuffer{
+		name:      name,
+		buf:       buf,
+		mem:       mem,
+		size:      len(buf),
+		dataStart: o.dataStart(),
+		msgLimit:  o.maxMsgSize(max(len(buf), o.autoGrow)),
+		prefix:    o.prefixSize(),
+		opts:      o,
+		full:      newFullStrategy(o.strategy),
+		doorbell:  make(chan struct{}, 1),
+	}
+	r.spaceFreed = sync.NewCond(&r.notifyMu)
+	if o.encryptionKey != nil {
+		r.aead = newAEAD(o.encryptionKey)
+	}
+	if o.dedupWindow > 0 {
+		r.dedup = newDedupWindow(o.dedupWindow)
+	}
+	if o.stats {
+		r.stats = &statsCounters{}
+	}
+	return r
 }
 
 // initialize initializes the ring buffer
 func (r *RingBuffer) initialize() {
 	// Initialize head and tail
-	r.setHead(headerSize)
-	r.setTail(headerSize)
+	r.setHead(r.dataStart)
+	r.setTail(r.dataStart)
+	fieldGroups.put(r.buf, uint64(r.opts.groups))
+	fieldFormat.put(r.buf, uint64(r.opts.format()))
+	fieldFeatures.put(r.buf, uint64(r.opts.extraFeatures()))
 }
 
+// MaxMsgSizeFor returns the largest message a buffer of bufSize bytes
+// created with opts can hold, accounting for the header, the frame prefix
+// and the strategy's unusable bytes. It returns 0 if no message fits or the
+// options are invalid.
+func MaxMsgSizeFor(bufSize int, opts ...Option) int {
+	o, err := parseOptions(opts)
+	if err != nil {
+		return 0
+	}
+	return o.maxMsgSize(bufSize)
+}
+
+// MaxMsgSize returns the largest message this buffer can hold when empty.
+// Larger messages are rejected with ErrMessageTooLarge. A message of exactly
+// this size only fits while the head is at least a frame prefix away from
+// the end of the buffer: if an empty buffer's head sits closer to the end,
+// the skipped bytes count as used and the write returns ErrBufferFull until
+// a smaller message has wrapped the head to the start of the data region.
+func (r *RingBuffer) MaxMsgSize() int {
+	return r.opts.maxMsgSize(r.size)
+}
+
+// HeaderSize returns the number of bytes before the data region: the fixed
+// header, the reader group slots and, with WithSequenceNumbers, the last
+// sequence number. It reflects the layout of the file as opened, so a
+// buffer reopened without WithReaderGroups still counts the slots the file
+// was created with.
+func (r *RingBuffer) HeaderSize() int {
+	return int(r.dataStart)
+}
+
+// Cap returns the size of the data region, which is the buffer size less
+// HeaderSize. Frame prefixes and the strategy's unusable bytes come out of
+// it, so use MaxMsgSize for the largest message that fits.
+func (r *RingBuffer) Cap() int {
+	return r.size - int(r.dataStart)
+}
+
+// maxPayload returns the largest payload a frame can hold, which is larger
+// than MaxMsgSize by the compression and encryption overhead
+func (r *RingBuffer) maxPayload() uint32 {
+	return uint32(r.opts.maxPayload(r.size))
+}
+
+// Strategy returns the full/empty strategy in use.
+func (r *RingBuffer) Strategy() Strategy {
+	return r.opts.strategy
+}
+
+// GetHeadTail returns the head and tail offsets without taking a lock. It
+// returns 0, 0 once Close has started.
 func (r *RingBuffer) GetHeadTail() (uint32, uint32) {
-	return binary.LittleEndian.Uint32(r.buf[0:4]), binary.LittleEndian.Uint32(r.buf[4:8])
+	if !r.enter() {
+		return 0, 0
+	}
+	defer r.leave()
+	return r.headTail()
+}
+
+// headTail returns the head and tail offsets. Caller must hold writeMu or
+// readMu, or be registered with enter.
+func (r *RingBuffer) headTail() (uint32, uint32) {
+	return r.headOnly(), r.tailOnly()
+}
+
+// headOnly returns the head offset without the tail, see headTail. The
+// acquire load orders it before reading the frames it publishes.
+func (r *RingBuffer) headOnly() uint32 {
+	return atomic.LoadUint32(r.headPtr())
+}
+
+// tailOnly returns the tail offset without the head, see headTail. The
+// acquire load orders it before overwriting the space it frees.
+func (r *RingBuffer) tailOnly() uint32 {
+	return atomic.LoadUint32(r.tailPtr())
+}
+
+// loadHeadTail returns head and tail, or ErrCorruptHeader if either one
+// points outside the data region or the strategy's state is impossible
+func (r *RingBuffer) loadHeadTail() (uint32, uint32, error) {
+	if r.opts.latest {
+		// The data region holds the value slots, see WithLatestSlot
+		return 0, 0, ErrIncompatibleOptions
+	}
+	head, tail := r.headTail()
+	if !r.inData(head) || !r.inData(tail) || !r.full.valid(r) {
+		return 0, 0, ErrCorruptHeader
+	}
+	return head, tail, nil
 }
 
-// setHead sets the head pointer
+// inData reports whether off is a valid frame offset in the data region
+func (r *RingBuffer) inData(off uint32) bool {
+	return off >= r.dataStart && off < uint32(r.size)
+}
+
+// setHead sets the head pointer. The release store publishes the frames
+// written before it to readers loading head, see headOnly.
 func (r *RingBuffer) setHead(val uint32) {
-	binary.LittleEndian.PutUint32(r.buf[0:4], val)
+	atomic.StoreUint32(r.headPtr(), val)
 }
 
-// setTail sets the tail pointer
+// setTail sets the tail pointer. The release store hands the space of the
+// frames read before it back to writers loading tail, see tailOnly.
 func (r *RingBuffer) setTail(val uint32) {
-	binary.LittleEndian.PutUint32(r.buf[4:8], val)
+	atomic.StoreUint32(r.tailPtr(), val)
+}
+
+// headPtr returns the head pointer in the header. Like the other shared
+// header words it is only accessed atomically, in the host's byte order,
+// which must be little-endian to match the format.
+func (r *RingBuffer) headPtr() *uint32 {
+	return (*uint32)(unsafe.Pointer(&r.buf[headOffset]))
+}
+
+// tailPtr returns the tail pointer in the header, see headPtr
+func (r *RingBuffer) tailPtr() *uint32 {
+	return (*uint32)(unsafe.Pointer(&r.buf[tailOffset]))
 }
 
 // WriteMsg writes a message to the ring buffer
 // Returns (true, nil) if successful, (false, error) if failed
 func (r *RingBuffer) WriteMsg(msg []byte) (bool, error) {
+	return r.write(msg, 0, nil)
+}
+
+// WriteMsgFree writes a message like WriteMsg and returns the bytes still
+// free afterwards, read under the same lock so no other write on this
+// handle lands in between. A message needs FrameSize of them. Returns 0
+// and the error, such as ErrBufferFull, if the write fails, and ok false
+// with a nil error for a duplicate dropped by WithDedup. Not available
+// with WithLatestSlot or WithConcurrentWriters, which write without the
+// lock.
+func (r *RingBuffer) WriteMsgFree(msg []byte) (free int, ok bool, err error) {
+	if r.opts.latest || r.opts.concurrentWriters {
+		return 0, false, ErrIncompatibleOptions
+	}
+	if len(msg) == 0 {
+		return 0, false, ErrInvalidSize
+	}
+	if err := r.limitWrite(len(msg)); err != nil {
+		return 0, false, err
+	}
+	key := r.dedupKey(msg)
+	if msg, err = r.seal(msg); err != nil {
+		return 0, false, err
+	}
+	defer func() { r.countWrite(ok, err, len(msg)) }()
+
 	r.writeMu.Lock()
 	defer r.writeMu.Unlock()
 
-	if r.closed {
-		return false, ErrClosed
+	if !r.acceptsWrites() {
+		return 0, false, ErrClosed
 	}
-
-	msgLen := uint32(len(msg))
-	if msgLen == 0 {
-		return false, ErrInvalidSize
+	ok = !r.duplicate(key)
+	if ok {
+		if err := r.writeFrame(msg, 0, nil); err != nil {
+			return 0, false, err
+		}
+		r.written(key)
 	}
+	head, tail := r.headTail()
+	return int(r.free(head, tail, 0)), ok, nil
+}
 
-	// Message should not be larger than buffer capacity minus header and length field
-	maxMsgSize := uint32(r.size) - headerSize - 4
-	if msgLen > maxMsgSize {
+// write writes msg with the given flags, drawing on res if it is not nil
+func (r *RingBuffer) write(msg []byte, flags byte, res *Reservation) (ok bool, err error) {
+	if len(msg) == 0 && (flags == 0 || !r.opts.flags) {
+		// Only signals may be empty
 		return false, ErrInvalidSize
 	}
+	if err := r.limitWrite(len(msg)); err != nil {
+		return false, err
+	}
+	key := r.dedupKey(msg)
+	if msg, err = r.seal(msg); err != nil {
+		return false, err
+	}
+	defer func() { r.countWrite(ok, err, len(msg)) }()
+	if r.opts.latest {
+		return r.writeLatest(msg)
+	}
+	if r.opts.concurrentWriters {
+		return r.writeClaimed(msg, flags, res)
+	}
 
-	head, tail := r.GetHeadTail()
-	size := uint32(r.size)
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
 
-	// Calculate available space
-	// We need to keep at least 1 byte difference between head and tail
-	// to distinguish between empty and full buffer
-	var free uint32
-	if head >= tail {
-		free = (size - head) + (tail - headerSize)
-	} else {
-		free = tail - head
+	if !r.acceptsWrites() {
+		return false, ErrClosed
+	}
+	if r.duplicate(key) {
+		return false, nil
 	}
 
-	need := 4 + msgLen
-	if free <= need {
-		return false, ErrBufferFull
+	if err := r.writeFrame(msg, flags, res); err != nil {
+		return false, err
 	}
+	r.written(key)
+	return true, nil
+}
 
-	// Check if we need to wrap around for the message length
-	if head+4 > size {
-		head = headerSize
-		r.setHead(head)
+// writeFrame writes msg with the given flags, applying the full policy if
+// it does not fit. Caller must hold writeMu.
+func (r *RingBuffer) writeFrame(msg []byte, flags byte, res *Reservation) error {
+	if r.opts.coalesce && flags == 0 && r.coalesce(msg) {
+		return nil
+	}
+	f, err := r.makeFrame(uint32(len(msg)), res.credit())
+	if err != nil {
+		return err
 	}
 
-	// Write message length first
-	binary.LittleEndian.PutUint32(r.buf[head:head+4], msgLen)
+	// Copy the payload first so readers never see a partial message
+	f.flags = flags
+	f.time = r.stamp()
+	f.seq = r.sequence()
+	f.next = r.putLink(f, r.copyIn(f.payload(), msg))
+	r.publish(f)
+	res.draw(f.footprint())
+	if r.opts.coalesce {
+		r.last, r.lastGen = f, r.Generation()
+	}
 
-	// Calculate write position for message content
-	writeStart := head + 4
-	writeEnd := writeStart + msgLen
+	if r.opts.debugChecks {
+		return r.checkInvariants("write")
+	}
+	return nil
+}
 
-	// Handle wrap-around for message content
-	if writeEnd < size {
-		// No wrap-around needed
-		copy(r.buf[writeStart:writeEnd], msg)
-	} else {
-		// Wrap-around needed
-		firstPart := size - writeStart
-		copy(r.buf[writeStart:size], msg[:firstPart])
-		copy(r.buf[headerSize:headerSize+msgLen-firstPart], msg[firstPart:])
-		writeEnd = headerSize + msgLen - firstPart
+// makeFrame reserves a frame for msgLen bytes at head, growing the buffer
+// or applying the full policy if it does not fit. Caller must hold writeMu.
+func (r *RingBuffer) makeFrame(msgLen, credit uint32) (frame, error) {
+	var deadline time.Time
+	if r.opts.blockTimeout > 0 {
+		deadline = time.Now().Add(r.opts.blockTimeout)
 	}
 
-	// Update head pointer
-	r.setHead(writeEnd)
-	return true, nil
+	f, err := r.reserve(msgLen, credit)
+	for (err == ErrBufferFull || err == ErrMessageTooLarge) && r.canGrow() {
+		if err = r.grow(); err != nil {
+			return frame{}, err
+		}
+		f, err = r.reserve(msgLen, credit)
+	}
+	for err == ErrBufferFull {
+		if err = r.makeRoom(msgLen, credit, deadline); err != nil {
+			break
+		}
+		f, err = r.reserve(msgLen, credit)
+	}
+	return f, err
 }
 
 // ReadMsg reads a message from the ring buffer
 // Returns (msg, nil) if successful, (nil, error) if failed
 func (r *RingBuffer) ReadMsg() ([]byte, error) {
+	if r.opts.latest {
+		return r.readLatest()
+	}
+
 	r.readMu.Lock()
 	defer r.readMu.Unlock()
 
@@ -198,57 +600,278 @@ func (r *RingBuffer) ReadMsg() ([]byte, error) {
 		return nil, ErrClosed
 	}
 
-	head, tail := r.GetHeadTail()
-	if head == tail {
-		return nil, ErrBufferEmpty
+	msg, _, err := r.readFrame()
+	return msg, err
+}
+
+// TryReadMsg reads a message if one is available. ok is true only when a
+// message is returned; an empty buffer gives (nil, false, nil), so err is
+// only set for real failures such as ErrClosed or ErrCorruptHeader.
+func (r *RingBuffer) TryReadMsg() (msg []byte, ok bool, err error) {
+	msg, err = r.ReadMsg()
+	if err == ErrBufferEmpty {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return msg, true, nil
+}
+
+// ReadMsgWithRemaining reads a message like ReadMsg and also returns how
+// many bytes are still buffered after it, frame overhead included, as seen
+// under the same lock. Writers may add more at any time.
+// Returns (msg, remaining, nil) if successful, (nil, 0, error) if failed
+func (r *RingBuffer) ReadMsgWithRemaining() (msg []byte, remaining int, err error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, 0, ErrClosed
+	}
+
+	if msg, _, err = r.readFrame(); err != nil {
+		return nil, 0, err
+	}
+	head, tail := r.headTail()
+	return msg, int(r.used(head, tail)), nil
+}
+
+// readFrame reads and consumes the next message. Caller must hold readMu.
+func (r *RingBuffer) readFrame() ([]byte, frame, error) {
+	if r.opts.groups > 0 {
+		// The tail belongs to the reader groups' Commit
+		return nil, frame{}, ErrIncompatibleOptions
+	}
+	if err := readFault(); err != nil {
+		return nil, frame{}, err
+	}
+
+	f, err := r.peekFrame()
+	if err != nil {
+		return nil, frame{}, err
 	}
-	size := uint32(r.size)
 
-	// Check if we need to wrap around for the message length
-	if tail+4 > size {
-		tail = headerSize
-		r.setTail(tail)
+	msg := make([]byte, f.msgLen)
+	r.copyOut(f.payload(), msg)
+	r.consume(f)
+
+	if r.opts.debugChecks {
+		if err := r.checkInvariants("read"); err != nil {
+			return nil, frame{}, err
+		}
 	}
 
-	// Read message length
-	msgLen := binary.LittleEndian.Uint32(r.buf[tail : tail+4])
+	// A message that fails to decrypt stays consumed so it cannot wedge readers
+	if msg, err = r.open(msg); err != nil {
+		return nil, frame{}, err
+	}
+	return msg, f, nil
+}
 
-	// Calculate read position for message content
-	readStart := tail + 4
-	readEnd := readStart + msgLen
-	msg := make([]byte, msgLen)
+// PeekHeader returns the length and flags of the next message without
+// copying its payload or consuming it. flags is always 0 unless the buffer
+// uses WithFlags. With WithCompression or WithEncryption the length is that
+// of the opened message.
+func (r *RingBuffer) PeekHeader() (length uint32, flags byte, err error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
 
-	// Handle wrap-around for message content
-	if readEnd < size {
-		// No wrap-around needed
-		copy(msg, r.buf[readStart:readEnd])
-	} else {
-		// Wrap-around needed
-		firstPart := size - readStart
-		copy(msg[:firstPart], r.buf[readStart:size])
-		copy(msg[firstPart:], r.buf[headerSize:headerSize+msgLen-firstPart])
-		readEnd = headerSize + msgLen - firstPart
+	if r.closed {
+		return 0, 0, ErrClosed
 	}
 
-	// Update tail pointer
-	r.setTail(readEnd)
-	return msg, nil
+	f, err := r.peekFrame()
+	if err != nil {
+		return 0, 0, err
+	}
+	length, err = r.storedSize(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	return length, f.flags, nil
 }
 
-// Close releases mmap
+// Splice moves up to n messages from r to dst without an intermediate copy
+// and returns how many were moved. It stops early, without error, when r
+// runs empty or dst fills up; a message is either moved whole or left in r.
+// Encrypted and compressed messages are moved as they are, so both buffers
+// must use the same WithEncryption key, or neither, and both or neither
+// must use WithCompression.
+func (r *RingBuffer) Splice(dst *RingBuffer, n int) (int, error) {
+	// Same lock order as Close: writeMu before readMu
+	dst.writeMu.Lock()
+	defer dst.writeMu.Unlock()
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed || !dst.acceptsWrites() {
+		return 0, ErrClosed
+	}
+	if r.opts.groups > 0 {
+		// The tail belongs to the reader groups' Commit
+		return 0, ErrIncompatibleOptions
+	}
+	if dst.opts.concurrentWriters {
+		// Head is advanced by claims outside writeMu
+		return 0, ErrIncompatibleOptions
+	}
+	if !bytes.Equal(r.opts.encryptionKey, dst.opts.encryptionKey) || (r.opts.codec == CodecNone) != (dst.opts.codec == CodecNone) {
+		return 0, ErrIncompatibleOptions
+	}
+
+	moved := 0
+	for moved < n {
+		src, err := r.peekFrame()
+		if err == ErrBufferEmpty {
+			break
+		}
+		if err != nil {
+			return moved, err
+		}
+
+		if src.msgLen == 0 && !dst.opts.flags {
+			// The signal would lose the flags that make it valid
+			return moved, ErrIncompatibleOptions
+		}
+		if dst.opts.codec != CodecNone {
+			// The frame fitting says nothing about the message it expands to
+			size, err := r.storedSize(src)
+			if err != nil {
+				return moved, err
+			}
+			if int(size) > dst.msgLimit {
+				return moved, ErrMessageTooLarge
+			}
+		}
+		f, err := dst.reserve(src.msgLen, 0)
+		if err == ErrBufferFull {
+			break
+		}
+		if err != nil {
+			return moved, err
+		}
+
+		first, second, _ := r.span(src.payload(), src.msgLen)
+		f.flags = src.flags
+		if f.time = src.time; f.time == 0 {
+			f.time = dst.stamp()
+		}
+		f.seq = dst.sequence()
+		f.next = dst.putLink(f, dst.copyIn(dst.copyIn(f.payload(), first), second))
+		dst.publish(f)
+		dst.stats.add(Stats{Writes: 1, WriteBytes: uint64(src.msgLen)})
+		r.consume(src)
+		moved++
+	}
+	return moved, nil
+}
+
+// Close releases mmap. It waits for writes and reads in progress, which hold
+// the locks, and for lock-free calls such as GetHeadTail and the wait in
+// WaitForDataFutex, which are counted, so the mapping is never unmapped
+// while in use. Every handle has its own mapping, so closing one never
+// affects another handle on the same file, and closing twice returns
+// ErrClosed.
 func (r *RingBuffer) Close() error {
+	_, err := r.close(false, false)
+	return err
+}
+
+// CloseSync closes the buffer like Close, but first flushes it as Sync
+// does, after the clean shutdown flag is set, so a process that opens the
+// file next finds every message and the flag on disk even if the machine
+// fails in between. Another process sees the messages without it, since
+// the mapping is shared; CloseSync is about durability. If the flush
+// fails the buffer is still closed and the error returned. Buffers from
+// FromBytes return ErrIncompatibleOptions and stay open.
+func (r *RingBuffer) CloseSync() error {
+	if r.mem == nil && r.buf != nil {
+		return ErrIncompatibleOptions
+	}
+	_, err := r.close(false, true)
+	return err
+}
+
+// DrainAndClose reads out every message left in the buffer and closes it,
+// with no write or read in between, for a graceful shutdown that hands the
+// tail of the stream to a downstream sink. It returns the messages and any
+// error from reading or closing; a read error stops the drain but the
+// buffer is still closed. Returns ErrIncompatibleOptions with reader
+// groups, whose messages belong to the groups, and then does not close.
+func (r *RingBuffer) DrainAndClose() ([][]byte, error) {
+	if r.opts.groups > 0 || r.opts.latest {
+		return nil, ErrIncompatibleOptions
+	}
+	return r.close(true, false)
+}
+
+// close implements Close, first reading out the remaining messages if drain
+// is set, and flushing the mapping and file before releasing them if flush
+// is set
+func (r *RingBuffer) close(drain, flush bool) ([][]byte, error) {
+	// Release writers blocked on a full buffer, they hold writeMu, and stop
+	// new lock-free operations
+	r.closing.Store(true)
+	r.notifySpace()
+
 	r.writeMu.Lock()
 	defer r.writeMu.Unlock()
 	r.readMu.Lock()
 	defer r.readMu.Unlock()
 	if r.closed {
-		return ErrClosed
+		return nil, ErrClosed
+	}
+
+	var msgs [][]byte
+	var drainErr error
+	for drain {
+		msg, _, err := r.readFrame()
+		if err == ErrBufferEmpty {
+			break
+		}
+		if err != nil {
+			drainErr = err
+			break
+		}
+		msgs = append(msgs, msg)
 	}
+
 	r.closed = true
 	var err error
 	if r.buf != nil {
-		err = syscall.Munmap(r.buf)
+		// Cut short waits in WaitForDataFutex and ReadMsgBlocking instead of
+		// letting them time out
+		r.futexWake()
+		r.ringDoorbell()
+		r.awaitInflight()
+		r.markClosed()
+		if flush && r.mem != nil {
+			err = r.flush()
+		}
+		// Memory given to FromBytes belongs to the caller
+		if r.mem != nil {
+			if uerr := syscall.Munmap(r.mem); uerr != nil && err == nil {
+				err = fmt.Errorf("ringbuffer: munmap %s: %w", r.name, uerr)
+			}
+		}
 		r.buf = nil
+		r.mem = nil
 	}
-	return err
+	if r.file != nil {
+		if cerr := r.file.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("ringbuffer: close %s: %w", r.name, cerr)
+		}
+		r.file = nil
+	}
+	if r.index != nil {
+		if cerr := r.index.close(); cerr != nil && err == nil {
+			err = fmt.Errorf("ringbuffer: close index %s: %w", r.opts.seqIndexPath, cerr)
+		}
+		r.index = nil
+	}
+	if drainErr != nil {
+		return msgs, errors.Join(drainErr, err)
+	}
+	return msgs, err
 }
@@ -1,8 +1,15 @@
 package ringbuffer
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"runtime"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -65,8 +72,11 @@ func TestRingBufferInvalidSize(t *testing.T) {
 	// Test message too large
 	largeMsg := make([]byte, 1024)
 	ok, err = rb.WriteMsg(largeMsg)
-	if ok || err != ErrInvalidSize {
-		t.Errorf("Expected ErrInvalidSize for large message, got: %v", err)
+	if ok || err != ErrMessageTooLarge {
+		t.Errorf("Expected ErrMessageTooLarge for large message, got: %v", err)
+	}
+	if !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Expected ErrMessageTooLarge to match ErrInvalidSize, got: %v", err)
 	}
 }
 
@@ -106,7 +116,7 @@ func TestRingBufferFull(t *testing.T) {
 
 func TestRingBufferWrapAround(t *testing.T) {
 	// Create a small buffer to force wrap-around
-	rb, err := NewRingBuffer("/tmp/test_rb_wrap.mmap", 128, true)
+	rb, err := NewRingBuffer("/tmp/test_rb_wrap.mmap", headerSize+104, true)
 	if err != nil {
 		t.Fatalf("Failed to create ring buffer: %v", err)
 	}
@@ -230,9 +240,63 @@ func TestRingBufferConcurrentReadWrite(t *testing.T) {
 	}
 }
 
+func TestRingBufferConcurrentPublish(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_publish.mmap", headerSize+512, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_publish.mmap")
+
+	// A reader racing one writer through a small buffer must see every
+	// payload complete and in order once head covers it
+	const numMessages = 20000
+	go func() {
+		for i := 0; i < numMessages; i++ {
+			msg := bytes.Repeat([]byte{byte(i)}, 1+i%40)
+			for {
+				ok, err := rb.WriteMsg(msg)
+				if ok && err == nil {
+					break
+				}
+				if err != ErrBufferFull {
+					t.Errorf("Unexpected write error: %v", err)
+					return
+				}
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for i := 0; i < numMessages; {
+		msg, err := rb.ReadMsg()
+		if err == ErrBufferEmpty {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timeout waiting for message %d", i)
+			}
+			runtime.Gosched()
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected read error: %v", err)
+		}
+		if want := bytes.Repeat([]byte{byte(i)}, 1+i%40); !bytes.Equal(msg, want) {
+			t.Fatalf("Message %d: expected %v, got %v", i, want, msg)
+		}
+		i++
+	}
+
+	// The offsets are stored atomically but keep the file's byte order
+	rb.setHead(rb.dataStart + 8)
+	if got := binary.LittleEndian.Uint32(rb.buf[headOffset:]); got != rb.dataStart+8 {
+		t.Errorf("Expected head %d in the header, got %d", rb.dataStart+8, got)
+	}
+}
+
 func TestRingBufferBoundaryConditions(t *testing.T) {
 	// Test with minimum viable buffer size
-	rb, err := NewRingBuffer("/tmp/test_rb_boundary.mmap", 32, true)
+	rb, err := NewRingBuffer("/tmp/test_rb_boundary.mmap", headerSize+8, true)
 	if err != nil {
 		t.Fatalf("Failed to create ring buffer: %v", err)
 	}
@@ -240,10 +304,7 @@ func TestRingBufferBoundaryConditions(t *testing.T) {
 	defer os.Remove("/tmp/test_rb_boundary.mmap")
 
 	// Test message that exactly fits
-	// For a 32-byte buffer: 8 bytes header + 4 bytes msg length + msg content
-	// We need to account for the safety margin to distinguish full from empty
-	availableSpace := 32 - 8 - 4 - 1 // buffer - header - msglen - safety margin
-	maxMsg := make([]byte, availableSpace)
+	maxMsg := make([]byte, rb.MaxMsgSize())
 	for i := range maxMsg {
 		maxMsg[i] = byte('A' + i%26)
 	}
@@ -354,3 +415,910 @@ func TestRingBufferReopen(t *testing.T) {
 		t.Errorf("Message after reopen mismatch. Got: %s, Want: %s", string(readMsg), string(testMsg))
 	}
 }
+
+func TestRingBufferSplice(t *testing.T) {
+	src, err := NewRingBuffer("/tmp/test_rb_splice_src.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create source ring buffer: %v", err)
+	}
+	defer src.Close()
+	defer os.Remove("/tmp/test_rb_splice_src.mmap")
+
+	dst, err := NewRingBuffer("/tmp/test_rb_splice_dst.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create destination ring buffer: %v", err)
+	}
+	defer dst.Close()
+	defer os.Remove("/tmp/test_rb_splice_dst.mmap")
+
+	messages := []string{"one", "two", "three", "four", "five"}
+	for i, msg := range messages {
+		ok, err := src.WriteMsg([]byte(msg))
+		if !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+
+	moved, err := src.Splice(dst, 3)
+	if err != nil {
+		t.Fatalf("Failed to splice: %v", err)
+	}
+	if moved != 3 {
+		t.Fatalf("Expected 3 messages moved, got %d", moved)
+	}
+
+	// The destination holds the first three messages
+	for i, expectedMsg := range messages[:3] {
+		readMsg, err := dst.ReadMsg()
+		if err != nil {
+			t.Fatalf("Failed to read destination message %d: %v", i, err)
+		}
+		if string(readMsg) != expectedMsg {
+			t.Errorf("Destination message %d mismatch. Got: %s, Want: %s", i, string(readMsg), expectedMsg)
+		}
+	}
+	if _, err := dst.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty on destination, got: %v", err)
+	}
+
+	// The source keeps the last two
+	for i, expectedMsg := range messages[3:] {
+		readMsg, err := src.ReadMsg()
+		if err != nil {
+			t.Fatalf("Failed to read source message %d: %v", i, err)
+		}
+		if string(readMsg) != expectedMsg {
+			t.Errorf("Source message %d mismatch. Got: %s, Want: %s", i, string(readMsg), expectedMsg)
+		}
+	}
+	if _, err := src.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty on source, got: %v", err)
+	}
+}
+
+func TestRingBufferSpliceDestinationFull(t *testing.T) {
+	src, err := NewRingBuffer("/tmp/test_rb_splice_full_src.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create source ring buffer: %v", err)
+	}
+	defer src.Close()
+	defer os.Remove("/tmp/test_rb_splice_full_src.mmap")
+
+	// Room for exactly two 16-byte messages
+	dst, err := NewRingBuffer("/tmp/test_rb_splice_full_dst.mmap", headerSize+2*(4+16)+1, true)
+	if err != nil {
+		t.Fatalf("Failed to create destination ring buffer: %v", err)
+	}
+	defer dst.Close()
+	defer os.Remove("/tmp/test_rb_splice_full_dst.mmap")
+
+	for i := 0; i < 4; i++ {
+		msg := []byte(fmt.Sprintf("message-%08d", i))
+		if ok, err := src.WriteMsg(msg); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+
+	moved, err := src.Splice(dst, 4)
+	if err != nil {
+		t.Fatalf("Failed to splice: %v", err)
+	}
+	if moved != 2 {
+		t.Fatalf("Expected 2 messages moved, got %d", moved)
+	}
+
+	// The message that did not fit is still the next one in the source
+	readMsg, err := src.ReadMsg()
+	if err != nil {
+		t.Fatalf("Failed to read source message: %v", err)
+	}
+	if string(readMsg) != "message-00000002" {
+		t.Errorf("Source message mismatch. Got: %s, Want: message-00000002", string(readMsg))
+	}
+}
+
+func TestRingBufferCorruptHeadTail(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_corrupt_ptr.mmap", 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_corrupt_ptr.mmap")
+
+	if ok, err := rb.WriteMsg([]byte("hello")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+
+	cases := []struct {
+		name       string
+		head, tail uint32
+	}{
+		{"tail inside header", rb.dataStart, 2},
+		{"tail past end", rb.dataStart, 256},
+		{"head inside header", 0, rb.dataStart},
+		{"head past end", 1 << 30, rb.dataStart},
+	}
+	for _, c := range cases {
+		rb.setHead(c.head)
+		rb.setTail(c.tail)
+
+		if _, err := rb.ReadMsg(); err != ErrCorruptHeader {
+			t.Errorf("%s: expected ErrCorruptHeader from ReadMsg, got: %v", c.name, err)
+		}
+		if ok, err := rb.WriteMsg([]byte("x")); ok || err != ErrCorruptHeader {
+			t.Errorf("%s: expected ErrCorruptHeader from WriteMsg, got: %v", c.name, err)
+		}
+	}
+}
+
+func TestRingBufferCorruptLength(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_corrupt_len.mmap", 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_corrupt_len.mmap")
+
+	g, err := NewRingBuffer("/tmp/test_rb_corrupt_len_groups.mmap", 256, true, WithReaderGroups(1))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer g.Close()
+	defer os.Remove("/tmp/test_rb_corrupt_len_groups.mmap")
+	reader, err := g.ReaderGroup("group")
+	if err != nil {
+		t.Fatalf("Failed to create reader group: %v", err)
+	}
+
+	for _, msgLen := range []uint32{0, uint32(rb.MaxMsgSize()) + 1, 1 << 20} {
+		for _, b := range []*RingBuffer{rb, g} {
+			if ok, err := b.WriteMsg([]byte("hello")); !ok || err != nil {
+				t.Fatalf("Failed to write message: %v", err)
+			}
+			_, tail := b.GetHeadTail()
+			binary.LittleEndian.PutUint32(b.buf[tail:tail+4], msgLen)
+		}
+
+		if _, err := rb.ReadMsg(); err != ErrCorruptHeader {
+			t.Errorf("length %d: expected ErrCorruptHeader from ReadMsg, got: %v", msgLen, err)
+		}
+		if _, _, err := rb.PeekHeader(); err != ErrCorruptHeader {
+			t.Errorf("length %d: expected ErrCorruptHeader from PeekHeader, got: %v", msgLen, err)
+		}
+		if _, err := reader.ReadMsg(); err != ErrCorruptHeader {
+			t.Errorf("length %d: expected ErrCorruptHeader from Reader.ReadMsg, got: %v", msgLen, err)
+		}
+
+		// Drop the corrupt frame before the next case
+		head, _ := rb.GetHeadTail()
+		rb.setTail(head)
+		head, _ = g.GetHeadTail()
+		g.setTail(head)
+		reader.pos = head
+	}
+}
+
+func TestRingBufferWrapBoundary(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_wrap_boundary.mmap", 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_wrap_boundary.mmap")
+
+	// Start empty at every offset near the end, so the length field lands
+	// right before the end, straddles it or leaves no payload byte before it
+	size := uint32(rb.size)
+	for pos := size - 8; pos < size; pos++ {
+		for _, msgLen := range []int{1, 3, 4, 100} {
+			rb.setHead(pos)
+			rb.setTail(pos)
+			msg := bytes.Repeat([]byte{byte(msgLen)}, msgLen)
+			if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+				t.Fatalf("pos %d len %d: failed to write message: %v", pos, msgLen, err)
+			}
+			if got, err := rb.ReadMsg(); err != nil || !bytes.Equal(got, msg) {
+				t.Fatalf("pos %d len %d: expected message back, got %d bytes (%v)", pos, msgLen, len(got), err)
+			}
+		}
+	}
+
+	// A length that would wrap the copy around past head is corrupt
+	rb.setHead(size - 8)
+	rb.setTail(size - 8)
+	if ok, err := rb.WriteMsg([]byte("hello")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	_, tail := rb.GetHeadTail()
+	binary.LittleEndian.PutUint32(rb.buf[tail:tail+4], uint32(rb.MaxMsgSize()))
+	if _, err := rb.ReadMsg(); err != ErrCorruptHeader {
+		t.Errorf("Expected ErrCorruptHeader for a length running past head, got: %v", err)
+	}
+	if _, now := rb.GetHeadTail(); now != tail {
+		t.Errorf("Expected the corrupt frame not to be consumed")
+	}
+}
+
+// TestRingBufferPrefixAtEnd starts an empty buffer at every offset where
+// the prefix ends exactly at the end of the buffer, straddles it, or leaves
+// a gap of a few bytes, for each prefix size and strategy, and checks that
+// writer and reader agree on where the frame and the one after it are.
+func TestRingBufferPrefixAtEnd(t *testing.T) {
+	const name = "/tmp/test_rb_prefix_end.mmap"
+	defer os.Remove(name)
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"length only", nil},
+		{"flags", []Option{WithFlags()}},
+		{"timestamps", []Option{WithTimestamps()}},
+		{"flags and timestamps", []Option{WithFlags(), WithTimestamps()}},
+		{"sequences", []Option{WithSequenceNumbers()}},
+		{"count", []Option{WithStrategy(StrategyCount)}},
+		{"count with flags", []Option{WithStrategy(StrategyCount), WithFlags()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]Option{WithDebugChecks()}, tc.opts...)
+			o, _ := parseOptions(opts)
+			prefix, size := o.prefixSize(), uint32(256)
+			for pos := size - prefix - 4; pos < size; pos++ {
+				for _, msgLen := range []int{1, 2, 3, 4, 5, 40} {
+					rb, err := NewRingBufferWithState(name, int(size), pos, pos, opts...)
+					if err != nil {
+						t.Fatalf("pos %d: failed to create ring buffer: %v", pos, err)
+					}
+					first := bytes.Repeat([]byte{byte(msgLen)}, msgLen)
+					second := []byte("next frame")
+					for _, msg := range [][]byte{first, second} {
+						if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+							t.Fatalf("pos %d len %d: failed to write message: %v", pos, msgLen, err)
+						}
+					}
+
+					// A prefix that does not fit skips the rest of the buffer,
+					// and the skipped bytes count as used; a payload that ends
+					// exactly at the end puts the next frame at the start
+					at, used := pos, uint32(0)
+					for _, msg := range [][]byte{first, second} {
+						if at+prefix > size {
+							used += size - at
+							at = o.dataStart()
+						}
+						used += prefix + uint32(len(msg))
+						if at += prefix + uint32(len(msg)); at >= size {
+							at += o.dataStart() - size
+						}
+					}
+					if head, _ := rb.GetHeadTail(); head != at || rb.Lag() != int(used) {
+						t.Errorf("pos %d len %d: expected head %d with %d bytes used, got head %d with %d", pos, msgLen, at, used, head, rb.Lag())
+					}
+					for _, msg := range [][]byte{first, second} {
+						if got, err := rb.ReadMsg(); err != nil || !bytes.Equal(got, msg) {
+							t.Fatalf("pos %d len %d: expected %q back, got %q (%v)", pos, msgLen, msg, got, err)
+						}
+					}
+					if head, tail := rb.GetHeadTail(); head != tail || rb.Lag() != 0 {
+						t.Errorf("pos %d len %d: expected an empty buffer, got head %d tail %d", pos, msgLen, head, tail)
+					}
+					rb.Close()
+				}
+			}
+		})
+	}
+}
+
+func TestRingBufferFormatCheck(t *testing.T) {
+	filename := "/tmp/test_rb_format.mmap"
+	rb, err := NewRingBuffer(filename, 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	rb.Close()
+
+	// Options that change the layout must be rejected
+	for _, opt := range []Option{WithStrategy(StrategyCount), WithFlags()} {
+		if _, err := OpenRingBuffer(filename, opt); !errors.Is(err, ErrFormatMismatch) || !errors.Is(err, ErrIncompatibleOptions) {
+			t.Errorf("Expected ErrFormatMismatch for mismatched options, got: %v", err)
+		}
+	}
+
+	// A file without the magic number is not a ring buffer
+	f, err := os.OpenFile(filename, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	if _, err := f.WriteAt([]byte{0, 0}, formatOffset); err != nil {
+		t.Fatalf("Failed to clear magic: %v", err)
+	}
+	f.Close()
+
+	if _, err := OpenRingBuffer(filename); !errors.Is(err, ErrCorruptHeader) {
+		t.Errorf("Expected ErrCorruptHeader for bad magic, got: %v", err)
+	}
+}
+
+func TestRingBufferFormatAdopt(t *testing.T) {
+	filename := "/tmp/test_rb_format_adopt.mmap"
+	rb, err := NewRingBuffer(filename, 256, true,
+		WithStrategy(StrategyCount), WithFlags(), WithBackLinks(), WithPayloadAlignment(8))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	if ok, err := rb.WriteMsgFlags([]byte("hello"), 3); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	rb.Close()
+
+	// Without options, the record format comes from the header
+	rb, err = OpenRingBuffer(filename)
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	if rb.Strategy() != StrategyCount || !rb.opts.backLinks || rb.opts.align != 8 {
+		t.Errorf("Expected the strategy, back-links and alignment from the header, got %+v", rb.opts)
+	}
+	if msg, flags, err := rb.ReadMsgFlags(); err != nil || string(msg) != "hello" || flags != 3 {
+		t.Errorf("Expected \"hello\" with flags 3, got %q flags %d (%v)", msg, flags, err)
+	}
+	rb.Close()
+
+	// Options the file agrees with are accepted
+	rb, err = OpenRingBuffer(filename, WithFlags(), WithPayloadAlignment(8))
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer with matching options: %v", err)
+	}
+	rb.Close()
+
+	for _, opt := range []Option{WithTimestamps(), WithPayloadAlignment(16), WithStrategy(StrategySentinel), WithEncryption(testKey)} {
+		if _, err := OpenRingBuffer(filename, opt); !errors.Is(err, ErrFormatMismatch) {
+			t.Errorf("Expected ErrFormatMismatch for an option the file lacks, got: %v", err)
+		}
+	}
+
+	// The key cannot come from the header
+	encrypted, err := NewRingBuffer(filename, 256, false, WithEncryption(testKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	encrypted.Close()
+	if _, err := OpenRingBuffer(filename); !errors.Is(err, ErrFormatMismatch) {
+		t.Errorf("Expected ErrFormatMismatch for an encrypted file without a key, got: %v", err)
+	}
+}
+
+func TestRingBufferTooSmallForAMessage(t *testing.T) {
+	const filename = "/tmp/test_rb_too_small.mmap"
+	defer os.Remove(filename)
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithStrategy(StrategyCount)},
+		{WithFlags(), WithTimestamps(), WithBackLinks()},
+		{WithEncryption(testKey)},
+	} {
+		// Larger than the header, but with no room left for a payload
+		if _, err := NewRingBuffer(filename, headerSize+4, true, opts...); !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("Expected ErrInvalidSize for %d bytes, got: %v", headerSize+4, err)
+		}
+
+		// The smallest accepted size holds a 1-byte message
+		size := headerSize + 1
+		for MaxMsgSizeFor(size, opts...) == 0 {
+			if _, err := NewRingBuffer(filename, size, true, opts...); !errors.Is(err, ErrInvalidSize) {
+				t.Fatalf("Expected ErrInvalidSize for %d bytes, got: %v", size, err)
+			}
+			size++
+		}
+		rb, err := NewRingBuffer(filename, size, true, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer of %d bytes: %v", size, err)
+		}
+		if ok, err := rb.WriteMsg([]byte("x")); !ok || err != nil {
+			t.Errorf("Failed to write a 1-byte message to %d bytes: %v", size, err)
+		}
+		rb.Close()
+	}
+
+	// The error names the smallest size that works: a prefix, a 1-byte
+	// payload and the sentinel byte
+	_, err := NewRingBuffer(filename, headerSize+4+1, true)
+	if want := fmt.Sprintf("use at least %d bytes", headerSize+4+1+1); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected the error to say %q, got: %v", want, err)
+	}
+}
+
+func TestRingBufferMaxMsgSize(t *testing.T) {
+	for _, strategy := range []Strategy{StrategySentinel, StrategyCount} {
+		rb, err := NewRingBuffer("/tmp/test_rb_maxmsg.mmap", headerSize+32, true, WithStrategy(strategy))
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+
+		if rb.MaxMsgSize() != MaxMsgSizeFor(headerSize+32, WithStrategy(strategy)) {
+			t.Errorf("%v: MaxMsgSize %d disagrees with MaxMsgSizeFor %d", strategy, rb.MaxMsgSize(), MaxMsgSizeFor(headerSize+32, WithStrategy(strategy)))
+		}
+
+		// One byte more than the maximum never fits
+		ok, err := rb.WriteMsg(make([]byte, rb.MaxMsgSize()+1))
+		if ok || err != ErrMessageTooLarge {
+			t.Errorf("%v: expected ErrMessageTooLarge for a message of MaxMsgSize()+1 bytes, got: %v", strategy, err)
+		}
+
+		ok, err = rb.WriteMsg(make([]byte, rb.MaxMsgSize()))
+		if !ok || err != nil {
+			t.Errorf("%v: failed to write a message of MaxMsgSize() bytes: %v", strategy, err)
+		}
+
+		rb.Close()
+		os.Remove("/tmp/test_rb_maxmsg.mmap")
+	}
+
+	if n := MaxMsgSizeFor(headerSize); n != 0 {
+		t.Errorf("Expected MaxMsgSizeFor(headerSize) to be 0, got %d", n)
+	}
+}
+
+func TestRingBufferHeaderSize(t *testing.T) {
+	const size = 1024
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want int
+	}{
+		{"plain", nil, headerSize},
+		{"groups", []Option{WithReaderGroups(2)}, headerSize + 2*groupSlotSize},
+		{"sequences", []Option{WithSequenceNumbers()}, headerSize + 8},
+	} {
+		rb, err := NewRingBuffer("/tmp/test_rb_headersize.mmap", size, true, tc.opts...)
+		if err != nil {
+			t.Fatalf("%s: failed to create ring buffer: %v", tc.name, err)
+		}
+		if rb.HeaderSize() != tc.want {
+			t.Errorf("%s: expected HeaderSize %d, got %d", tc.name, tc.want, rb.HeaderSize())
+		}
+		if rb.Cap() != size-rb.HeaderSize() {
+			t.Errorf("%s: expected Cap %d, got %d", tc.name, size-rb.HeaderSize(), rb.Cap())
+		}
+		rb.Close()
+	}
+	defer os.Remove("/tmp/test_rb_headersize.mmap")
+
+	// A reopened buffer reports the layout of the file
+	reopened, err := OpenRingBuffer("/tmp/test_rb_headersize.mmap", WithSequenceNumbers())
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	if reopened.HeaderSize() != headerSize+8 || reopened.Cap() != size-reopened.HeaderSize() {
+		t.Errorf("Expected HeaderSize %d and Cap %d after reopening, got %d and %d", headerSize+8, size-headerSize-8, reopened.HeaderSize(), reopened.Cap())
+	}
+	reopened.Close()
+
+	// The group slots are recorded in the file, so they count without
+	// WithReaderGroups
+	groups, err := NewRingBuffer("/tmp/test_rb_headersize.mmap", size, true, WithReaderGroups(3))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	groups.Close()
+	reopened, err = OpenRingBuffer("/tmp/test_rb_headersize.mmap")
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	defer reopened.Close()
+	if want := headerSize + 3*groupSlotSize; reopened.HeaderSize() != want || reopened.Cap() != size-want {
+		t.Errorf("Expected HeaderSize %d and Cap %d after reopening, got %d and %d", want, size-want, reopened.HeaderSize(), reopened.Cap())
+	}
+}
+
+func TestRingBufferErrorWrapping(t *testing.T) {
+	_, err := OpenRingBuffer("/tmp/test_rb_does_not_exist.mmap")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist to be matchable, got: %v", err)
+	}
+	var pathErr *os.PathError
+	if !errors.As(err, &pathErr) {
+		t.Errorf("Expected *os.PathError to be matchable, got: %T", err)
+	}
+	if !strings.HasPrefix(err.Error(), "ringbuffer: open /tmp/test_rb_does_not_exist.mmap: ") {
+		t.Errorf("Expected operation and file name in error, got: %v", err)
+	}
+
+	_, err = NewRingBuffer("/tmp/test_rb_too_small.mmap", 4, true)
+	if !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Expected ErrInvalidSize to be matchable, got: %v", err)
+	}
+}
+
+func TestRingBufferSentinelWrapping(t *testing.T) {
+	const name = "/tmp/test_rb_sentinels.mmap"
+	defer os.Remove(name)
+
+	// create makes a fresh file and applies poke to its mapping
+	create := func(poke func(buf []byte)) {
+		rb, err := NewRingBuffer(name, 256, true)
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+		poke(rb.buf)
+		rb.Close()
+	}
+
+	tests := []struct {
+		name     string
+		err      func() error
+		sentinel error
+	}{
+		{"message too large", func() error { return ErrMessageTooLarge }, ErrInvalidSize},
+		{"size too small", func() error {
+			_, err := NewRingBuffer(name, 4, true)
+			return err
+		}, ErrInvalidSize},
+		{"key length", func() error {
+			_, err := NewRingBuffer(name, 256, true, WithEncryption(make([]byte, 16)))
+			return err
+		}, ErrInvalidSize},
+		{"alignment", func() error {
+			_, err := NewRingBuffer(name, 256, true, WithPayloadAlignment(3))
+			return err
+		}, ErrInvalidSize},
+		{"region offset", func() error {
+			f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0644)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = NewRingBufferRegion(f, 2, 256)
+			return err
+		}, ErrInvalidSize},
+		{"bad magic", func() error {
+			create(func(buf []byte) { buf[formatOffset] = 0 })
+			_, err := OpenRingBuffer(name)
+			return err
+		}, ErrCorruptHeader},
+		{"format version", func() error {
+			create(func(buf []byte) { buf[formatOffset+2] = 0xf })
+			_, err := OpenRingBuffer(name)
+			return err
+		}, ErrCorruptHeader},
+		{"format mismatch", func() error {
+			create(func([]byte) {})
+			_, err := OpenRingBuffer(name, WithFlags())
+			return err
+		}, ErrIncompatibleOptions},
+		{"debug checks", func() error {
+			rb, err := NewRingBuffer(name, 256, true, WithDebugChecks())
+			if err != nil {
+				return err
+			}
+			defer rb.Close()
+			rb.WriteMsg([]byte("hello"))
+			binary.LittleEndian.PutUint32(rb.buf[rb.dataStart:], 200)
+			_, err = rb.WriteMsg([]byte("world"))
+			return err
+		}, ErrCorruptHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err()
+			if err == tt.sentinel || !errors.Is(err, tt.sentinel) {
+				t.Errorf("Expected an error wrapping %v, got: %v", tt.sentinel, err)
+			}
+		})
+	}
+}
+
+func TestRingBufferOpenTruncated(t *testing.T) {
+	const name = "/tmp/test_rb_truncated.mmap"
+	rb, err := NewRingBuffer(name, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(name)
+	rb.Close()
+
+	// A file cut off inside the header fails cleanly instead of panicking
+	for _, size := range []int64{headerSize, headerSize - 1, 4} {
+		if err := os.Truncate(name, size); err != nil {
+			t.Fatalf("Failed to truncate file: %v", err)
+		}
+		if _, err := OpenRingBuffer(name); err == nil {
+			t.Errorf("Expected an error for a %d-byte file", size)
+		} else if size < headerSize && !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("Expected ErrInvalidSize for a %d-byte file, got: %v", size, err)
+		}
+		if _, err := OpenOrReset(name, true); err == nil {
+			t.Errorf("Expected OpenOrReset to fail for a %d-byte file", size)
+		}
+	}
+}
+
+func TestRingBufferUninitialized(t *testing.T) {
+	const name = "/tmp/test_rb_uninitialized.mmap"
+	defer os.Remove(name)
+
+	for _, size := range []int64{0, 1024} {
+		f, err := os.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := f.Truncate(size); err != nil {
+			t.Fatalf("Failed to truncate file: %v", err)
+		}
+		f.Close()
+
+		_, err = OpenRingBuffer(name)
+		if !errors.Is(err, ErrUninitialized) || !errors.Is(err, ErrCorruptHeader) {
+			t.Errorf("Expected ErrUninitialized for a %d-byte zero file, got: %v", size, err)
+		}
+	}
+
+	// OpenOrReset initializes it instead
+	rb, err := OpenOrReset(name, true)
+	if err != nil {
+		t.Fatalf("Failed to reset a zero file: %v", err)
+	}
+	defer rb.Close()
+	if ok, err := rb.WriteMsg([]byte("hello")); !ok || err != nil {
+		t.Errorf("Failed to write after reset: %v", err)
+	}
+}
+
+func TestRingBufferMapTooLarge(t *testing.T) {
+	// Whether mmap of an absurd size fails depends on the kernel's overcommit
+	// settings, so the ENOMEM path is checked on the error directly
+	err := mmapError("/tmp/test_rb_huge.mmap", 0, math.MaxInt32, syscall.ENOMEM)
+	if !errors.Is(err, ErrMapTooLarge) {
+		t.Errorf("Expected ErrMapTooLarge to be matchable, got: %v", err)
+	}
+	if !errors.Is(err, syscall.ENOMEM) {
+		t.Errorf("Expected ENOMEM to be matchable, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprint(math.MaxInt32)) {
+		t.Errorf("Expected the requested size in the error, got: %v", err)
+	}
+
+	err = mmapError("/tmp/test_rb_huge.mmap", 0, 1024, syscall.EACCES)
+	if errors.Is(err, ErrMapTooLarge) {
+		t.Errorf("Expected only ENOMEM to match ErrMapTooLarge, got: %v", err)
+	}
+}
+
+func TestRingBufferMapTooLargeReal(t *testing.T) {
+	const filename = "/tmp/test_rb_huge.mmap"
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer os.Remove(filename)
+	defer f.Close()
+
+	// Mapped the way NewRingBuffer maps it, but without sizing the file or
+	// zeroing the mapping, which would write all of it if mmap succeeded.
+	// mmap allows mapping past the end of a file.
+	size := math.MaxInt &^ (os.Getpagesize() - 1)
+	mem, err := mmapFile(f, 0, size, options{})
+	if err == nil {
+		syscall.Munmap(mem)
+		t.Skipf("Mapping %d bytes succeeded, the address space allows it", size)
+	}
+	if err := mmapError(filename, 0, size, err); !errors.Is(err, ErrMapTooLarge) {
+		t.Errorf("Expected ErrMapTooLarge for a %d-byte mapping, got: %v", size, err)
+	}
+}
+
+func FuzzReadMsg(f *testing.F) {
+	// A stored length that used to run far past the end of the mapping
+	seed := make([]byte, 16)
+	binary.LittleEndian.PutUint32(seed, 1844266353)
+	f.Add(seed, uint32(headerSize+8), uint32(headerSize), false)
+	f.Add(seed, uint32(1844266353), uint32(1844266353), true)
+	f.Add([]byte("\x05\x00\x00\x00hello"), uint32(headerSize+9), uint32(headerSize), false)
+
+	f.Fuzz(func(t *testing.T, data []byte, head, tail uint32, count bool) {
+		filename := "/tmp/test_rb_fuzz.mmap"
+		opts := []Option{WithFlags()}
+		if count {
+			opts = append(opts, WithStrategy(StrategyCount))
+		}
+		rb, err := NewRingBuffer(filename, 256, true, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+		defer os.Remove(filename)
+		defer rb.Close()
+
+		copy(rb.buf[rb.dataStart:], data)
+		rb.setHead(head)
+		rb.setTail(tail)
+		if count && len(data) >= 4 {
+			*rb.usedPtr() = binary.LittleEndian.Uint32(data)
+		}
+
+		// Only errors are allowed, never a panic; the loop is bounded
+		// because a corrupt used counter may never report empty
+		for i := 0; i < 64; i++ {
+			if _, _, err := rb.PeekHeader(); err != nil {
+				break
+			}
+			if _, err := rb.ReadMsg(); err != nil {
+				break
+			}
+		}
+	})
+}
+
+func TestRingBufferTryReadMsg(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_tryread.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_tryread.mmap")
+
+	if msg, ok, err := rb.TryReadMsg(); ok || err != nil || msg != nil {
+		t.Errorf("Expected (nil, false, nil) on an empty buffer, got: (%q, %v, %v)", msg, ok, err)
+	}
+
+	if ok, err := rb.WriteMsg([]byte("hello")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if msg, ok, err := rb.TryReadMsg(); !ok || err != nil || string(msg) != "hello" {
+		t.Errorf("Expected (hello, true, nil), got: (%q, %v, %v)", msg, ok, err)
+	}
+
+	rb.Close()
+	if _, ok, err := rb.TryReadMsg(); ok || err != ErrClosed {
+		t.Errorf("Expected ErrClosed after Close, got: (%v, %v)", ok, err)
+	}
+}
+
+func TestRingBufferReadMsgWithRemaining(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_remaining.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_remaining.mmap")
+	defer rb.Close()
+
+	msgs := []string{"first", "second", "third"}
+	for _, m := range msgs {
+		if ok, err := rb.WriteMsg([]byte(m)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+
+	// Each frame is a 4-byte length and the payload
+	want := []int{4 + 6 + 4 + 5, 4 + 5, 0}
+	for i, m := range msgs {
+		msg, remaining, err := rb.ReadMsgWithRemaining()
+		if err != nil || string(msg) != m || remaining != want[i] {
+			t.Errorf("Read %d: expected (%q, %d), got (%q, %d, %v)", i, m, want[i], msg, remaining, err)
+		}
+	}
+	if _, _, err := rb.ReadMsgWithRemaining(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+}
+
+func TestRingBufferWriteMsgFree(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_free.mmap", headerSize+100, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_free.mmap")
+	defer rb.Close()
+
+	// Free space shrinks by a frame per write until the next one fails
+	head, tail := rb.GetHeadTail()
+	last := int(rb.free(head, tail, 0))
+	msg := []byte("0123456789")
+	for i := 0; ; i++ {
+		free, ok, err := rb.WriteMsgFree(msg)
+		if err == ErrBufferFull {
+			if ok || free != 0 {
+				t.Errorf("Expected (0, false) on a full buffer, got (%d, %v)", free, ok)
+			}
+			if last >= rb.FrameSize(len(msg)) {
+				t.Errorf("Buffer full with %d bytes free", last)
+			}
+			break
+		}
+		if err != nil || !ok {
+			t.Fatalf("Write %d failed: %v", i, err)
+		}
+		if want := last - rb.FrameSize(len(msg)); free != want {
+			t.Errorf("Write %d: expected %d bytes free, got %d", i, want, free)
+		}
+		last = free
+	}
+
+	// Reading gives the space back; how much of it a write can use depends
+	// on where the wrap falls, so compare against the handle's own view
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	free, ok, err := rb.WriteMsgFree([]byte("x"))
+	if !ok || err != nil {
+		t.Fatalf("Failed to write after reading: %v", err)
+	}
+	if head, tail := rb.GetHeadTail(); free != int(rb.free(head, tail, 0)) {
+		t.Errorf("Expected %d bytes free, got %d", rb.free(head, tail, 0), free)
+	}
+	if _, _, err := rb.WriteMsgFree(nil); err != ErrInvalidSize {
+		t.Errorf("Expected ErrInvalidSize, got: %v", err)
+	}
+}
+
+func TestRingBufferDrainAndClose(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_drain.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_drain.mmap")
+
+	for i := 0; i < 5; i++ {
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("message-%d", i))); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+
+	msgs, err := rb.DrainAndClose()
+	if err != nil {
+		t.Fatalf("Failed to drain and close: %v", err)
+	}
+	if len(msgs) != 4 {
+		t.Fatalf("Expected 4 remaining messages, got %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		if want := fmt.Sprintf("message-%d", i+1); string(msg) != want {
+			t.Errorf("Expected %q, got %q", want, msg)
+		}
+	}
+
+	if _, err := rb.WriteMsg([]byte("late")); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after DrainAndClose, got: %v", err)
+	}
+	if _, err := rb.DrainAndClose(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from a second DrainAndClose, got: %v", err)
+	}
+}
+
+func TestRingBufferCloseIndependentHandles(t *testing.T) {
+	const name = "/tmp/test_rb_handles.mmap"
+	rb, err := NewRingBuffer(name, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(name)
+	defer rb.Close()
+	other, err := OpenRingBuffer(name)
+	if err != nil {
+		t.Fatalf("Failed to open second handle: %v", err)
+	}
+
+	if ok, err := other.WriteMsg([]byte("before")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if err := other.Close(); err != nil {
+		t.Fatalf("Failed to close second handle: %v", err)
+	}
+	if err := other.Close(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed on a second Close, got: %v", err)
+	}
+
+	// The first handle's mapping is untouched
+	if ok, err := rb.WriteMsg([]byte("after")); !ok || err != nil {
+		t.Fatalf("Failed to write after closing the other handle: %v", err)
+	}
+	for _, want := range []string{"before", "after"} {
+		if msg, err := rb.ReadMsg(); err != nil || string(msg) != want {
+			t.Errorf("Expected %q, got %q (%v)", want, msg, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// ErrSeqNotFound is returned by ReadBySeq when the message with the
+// sequence number is no longer buffered, was never written, or its index
+// entry was reused for a later number.
+var ErrSeqNotFound = errors.New("sequence number not found in the buffer")
+
+// seqIndexEntrySize is the size of an index entry: the 8-byte sequence
+// number, the 4-byte frame offset and 4 bytes of padding that keep the
+// sequence numbers aligned for atomic access
+const seqIndexEntrySize = 16
+
+// WithSequenceIndex keeps a companion index file at path that maps the
+// sequence numbers of the last entries frames written to their offsets,
+// so ReadBySeq finds a message without scanning the buffer. It requires
+// WithSequenceNumbers, from the option or the file. entries should be at
+// least the number of messages the buffer holds at once; a number whose
+// entry was reused by a later one is not found. The index is not
+// recorded in the buffer file, so every handle that writes must use it,
+// and each write pays for one more store into the index mapping. An
+// existing index file of the right size is kept, otherwise it is
+// recreated. Only NewRingBuffer, NewRingBufferWithState and
+// OpenRingBuffer support it.
+func WithSequenceIndex(path string, entries int) Option {
+	return func(o *options) {
+		o.seqIndexPath = path
+		o.seqIndexEntries = entries
+	}
+}
+
+// ReadBySeq returns the buffered message with sequence number seq without
+// consuming it, looking its offset up in the WithSequenceIndex index. The
+// frame found there must carry seq, so a stale entry is never mistaken for
+// the message. Returns ErrSeqNotFound if it is not buffered, and
+// ErrIncompatibleOptions without an index.
+func (r *RingBuffer) ReadBySeq(seq uint64) ([]byte, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+	if r.index == nil {
+		return nil, ErrIncompatibleOptions
+	}
+
+	offset, ok := r.index.get(seq)
+	if !ok {
+		return nil, ErrSeqNotFound
+	}
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return nil, err
+	}
+	used := r.used(head, tail)
+	if !r.inData(offset) || r.distance(offset, tail) >= used {
+		return nil, ErrSeqNotFound
+	}
+	f, err := r.frameBefore(offset, used-r.distance(offset, tail))
+	if err != nil || f.start != offset || f.seq != seq {
+		return nil, ErrSeqNotFound
+	}
+	msg := make([]byte, f.msgLen)
+	r.copyOut(f.payload(), msg)
+	return r.open(msg)
+}
+
+// seqIndex is the mapped index file of WithSequenceIndex, a ring of
+// entries slots indexed by sequence number
+type seqIndex struct {
+	file    *os.File
+	mem     []byte
+	entries uint64
+}
+
+// attachIndex opens the index WithSequenceIndex asks for, if any, closing
+// r if it cannot be used
+func (r *RingBuffer) attachIndex() (*RingBuffer, error) {
+	if r.opts.seqIndexPath == "" {
+		return r, nil
+	}
+	if !r.opts.sequences {
+		r.Close()
+		return nil, ErrIncompatibleOptions
+	}
+	index, err := openSeqIndex(r.opts.seqIndexPath, r.opts.seqIndexEntries)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	r.index = index
+	return r, nil
+}
+
+// openSeqIndex maps the index file at path, recreating it unless it
+// already has room for exactly entries entries
+func openSeqIndex(path string, entries int) (*seqIndex, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ringbuffer: open index %s: %w", path, err)
+	}
+	size := int64(entries) * seqIndexEntrySize
+	fi, err := file.Stat()
+	if err == nil && fi.Size() != size {
+		// Entries are placed by sequence number modulo their count
+		if err = file.Truncate(0); err == nil {
+			err = file.Truncate(size)
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("ringbuffer: size index %s: %w", path, err)
+	}
+	mem, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		file.Close()
+		return nil, mmapError(path, 0, int(size), err)
+	}
+	return &seqIndex{file: file, mem: mem, entries: uint64(entries)}, nil
+}
+
+// put records that the frame with sequence number seq starts at offset.
+// The offset is stored first, so a reader that sees seq sees at least this
+// offset or a later one, which ReadBySeq checks against the frame.
+func (x *seqIndex) put(seq uint64, offset uint32) {
+	slot := seq % x.entries * seqIndexEntrySize
+	atomic.StoreUint32((*uint32)(unsafe.Pointer(&x.mem[slot+8])), offset)
+	atomic.StoreUint64((*uint64)(unsafe.Pointer(&x.mem[slot])), seq)
+}
+
+// get returns the offset recorded for seq, if its entry holds it
+func (x *seqIndex) get(seq uint64) (uint32, bool) {
+	slot := seq % x.entries * seqIndexEntrySize
+	if atomic.LoadUint64((*uint64)(unsafe.Pointer(&x.mem[slot]))) != seq {
+		return 0, false
+	}
+	return atomic.LoadUint32((*uint32)(unsafe.Pointer(&x.mem[slot+8]))), true
+}
+
+// close unmaps and closes the index file
+func (x *seqIndex) close() error {
+	err := syscall.Munmap(x.mem)
+	if cerr := x.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
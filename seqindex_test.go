@@ -0,0 +1,91 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestReadBySeq(t *testing.T) {
+	const name = "/tmp/test_rb_seqindex.mmap"
+	const index = "/tmp/test_rb_seqindex.idx"
+	defer os.Remove(name)
+	defer os.Remove(index)
+
+	rb, err := NewRingBuffer(name, headerSize+256, true, WithSequenceNumbers(), WithSequenceIndex(index, 8))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+
+	// Keep five messages buffered while the data region and the index
+	// wrap several times; only those are found
+	seq := uint64(0)
+	for round := 0; round < 12; round++ {
+		for i := 0; i < 3 || round == 0 && i < 5; i++ {
+			seq++
+			if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("message %d", seq))); !ok || err != nil {
+				t.Fatalf("Failed to write message %d: %v", seq, err)
+			}
+		}
+		for i := 0; i < 3 && round > 0; i++ {
+			if _, err := rb.ReadMsg(); err != nil {
+				t.Fatalf("Failed to read message: %v", err)
+			}
+		}
+		for s := uint64(1); s <= seq+1; s++ {
+			msg, err := rb.ReadBySeq(s)
+			if s+5 > seq && s <= seq {
+				if err != nil || string(msg) != fmt.Sprintf("message %d", s) {
+					t.Errorf("Round %d: expected message %d, got %q (%v)", round, s, msg, err)
+				}
+			} else if err != ErrSeqNotFound {
+				t.Errorf("Round %d: expected ErrSeqNotFound for %d, got %q (%v)", round, s, msg, err)
+			}
+		}
+	}
+	if lag := rb.Lag(); lag == 0 {
+		t.Fatalf("Expected messages to be buffered")
+	}
+
+	// Moving the frames updates the index
+	if err := rb.Defragment(); err != nil {
+		t.Fatalf("Failed to defragment: %v", err)
+	}
+	if msg, err := rb.ReadBySeq(seq); err != nil || string(msg) != fmt.Sprintf("message %d", seq) {
+		t.Errorf("Expected message %d after Defragment, got %q (%v)", seq, msg, err)
+	}
+	rb.Close()
+
+	// The index file outlives the handle
+	rb, err = OpenRingBuffer(name, WithSequenceIndex(index, 8))
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	defer rb.Close()
+	if msg, err := rb.ReadBySeq(seq - 1); err != nil || string(msg) != fmt.Sprintf("message %d", seq-1) {
+		t.Errorf("Expected message %d after reopening, got %q (%v)", seq-1, msg, err)
+	}
+}
+
+func TestReadBySeqOptions(t *testing.T) {
+	const name = "/tmp/test_rb_seqindex_options.mmap"
+	const index = "/tmp/test_rb_seqindex_options.idx"
+	defer os.Remove(name)
+	defer os.Remove(index)
+
+	if _, err := NewRingBuffer(name, 1024, true, WithSequenceIndex(index, 8)); !errors.Is(err, ErrIncompatibleOptions) {
+		t.Errorf("Expected ErrIncompatibleOptions without sequence numbers, got: %v", err)
+	}
+	if _, err := NewRingBuffer(name, 1024, true, WithSequenceNumbers(), WithSequenceIndex(index, 0)); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Expected ErrInvalidSize for an empty index, got: %v", err)
+	}
+	rb, err := NewRingBuffer(name, 1024, true, WithSequenceNumbers())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	if _, err := rb.ReadBySeq(1); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions without an index, got: %v", err)
+	}
+}
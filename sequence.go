@@ -0,0 +1,64 @@
+package ringbuffer
+
+import "encoding/binary"
+
+// WithSequenceNumbers adds an 8-byte sequence number to every frame,
+// counting up from 1 in the order messages are published, so a reader can
+// tell when messages were lost, for example dropped by OverwriteOnFull
+// before it read them. The last number is kept in the file after the
+// reader group slots, so numbering continues across handles and restarts.
+// Use ReadMsgChecked to read it. The option is recorded in the file,
+// so OpenRingBuffer may leave it out.
+func WithSequenceNumbers() Option {
+	return func(o *options) {
+		o.sequences = true
+	}
+}
+
+// sequenceOffset returns the offset of the sequence number within the
+// prefix
+func (o options) sequenceOffset() uint32 {
+	offset := o.timestampOffset()
+	if o.timestamps {
+		offset += 8
+	}
+	return offset
+}
+
+// sequence assigns the sequence number of a frame published next, or
+// returns 0 without WithSequenceNumbers. Caller must hold writeMu, or with
+// WithConcurrentWriters be the next claim to publish.
+func (r *RingBuffer) sequence() uint64 {
+	if !r.opts.sequences {
+		return 0
+	}
+	slot := r.opts.sequenceSlot()
+	seq := binary.LittleEndian.Uint64(r.buf[slot:slot+8]) + 1
+	binary.LittleEndian.PutUint64(r.buf[slot:slot+8], seq)
+	return seq
+}
+
+// ReadMsgChecked reads a message together with its sequence number. gap is
+// true if the number is not one more than that of the message this handle
+// read last with ReadMsgChecked, meaning messages were lost or read
+// elsewhere in between; the first read on a handle never reports a gap.
+// Returns ErrIncompatibleOptions without WithSequenceNumbers.
+func (r *RingBuffer) ReadMsgChecked() (msg []byte, seq uint64, gap bool, err error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, 0, false, ErrClosed
+	}
+	if !r.opts.sequences {
+		return nil, 0, false, ErrIncompatibleOptions
+	}
+
+	msg, f, err := r.readFrame()
+	if err != nil {
+		return nil, 0, false, err
+	}
+	gap = r.lastSeq != 0 && f.seq != r.lastSeq+1
+	r.lastSeq = f.seq
+	return msg, f.seq, gap, nil
+}
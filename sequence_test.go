@@ -0,0 +1,121 @@
+package ringbuffer
+
+import (
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestSequenceNumbers(t *testing.T) {
+	const name = "/tmp/test_rb_sequence.mmap"
+	rb, err := NewRingBuffer(name, 1024, true, WithSequenceNumbers(), WithTimestamps())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(name)
+
+	for i := 0; i < 3; i++ {
+		if ok, err := rb.WriteMsg([]byte("hello")); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	for want := uint64(1); want <= 3; want++ {
+		msg, seq, gap, err := rb.ReadMsgChecked()
+		if err != nil || string(msg) != "hello" || seq != want || gap {
+			t.Errorf("Expected (hello, %d, false), got (%q, %d, %v, %v)", want, msg, seq, gap, err)
+		}
+	}
+	rb.Close()
+
+	// Numbering continues in the file
+	if plain, err := OpenRingBuffer(name); err != nil || !plain.opts.sequences {
+		t.Errorf("Expected sequence numbers to be taken from the header, got: %v", err)
+	} else {
+		plain.Close()
+	}
+	rb, err = OpenRingBuffer(name, WithSequenceNumbers(), WithTimestamps())
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	defer rb.Close()
+	if ok, err := rb.WriteMsg([]byte("again")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, seq, gap, err := rb.ReadMsgChecked(); seq != 4 || gap || err != nil {
+		t.Errorf("Expected sequence 4 without a gap on a new handle, got (%d, %v, %v)", seq, gap, err)
+	}
+}
+
+func TestSequenceNumbersGap(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_sequence.mmap", 256, true, WithSequenceNumbers(), WithFullPolicy(OverwriteOnFull))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_sequence.mmap")
+	defer rb.Close()
+
+	msg := make([]byte, 20)
+	rb.WriteMsg(msg)
+	rb.WriteMsg(msg)
+	if _, _, gap, err := rb.ReadMsgChecked(); gap || err != nil {
+		t.Fatalf("Expected no gap on the first read, got (%v, %v)", gap, err)
+	}
+	if _, _, gap, err := rb.ReadMsgChecked(); gap || err != nil {
+		t.Fatalf("Expected no gap on the next message, got (%v, %v)", gap, err)
+	}
+
+	// The writer laps the slow reader, overwriting messages it never read
+	for i := 0; i < 30; i++ {
+		if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	_, seq, gap, err := rb.ReadMsgChecked()
+	if err != nil || !gap || seq <= 3 {
+		t.Errorf("Expected a gap after being overrun, got (%d, %v, %v)", seq, gap, err)
+	}
+	if _, next, gap, err := rb.ReadMsgChecked(); err != nil || gap || next != seq+1 {
+		t.Errorf("Expected %d without a gap, got (%d, %v, %v)", seq+1, next, gap, err)
+	}
+
+	plain, err := NewRingBuffer("/tmp/test_rb_sequence_plain.mmap", 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_sequence_plain.mmap")
+	defer plain.Close()
+	if _, _, _, err := plain.ReadMsgChecked(); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions without the option, got: %v", err)
+	}
+}
+
+func TestSequenceNumbersConcurrentWriters(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_sequence.mmap", 1<<16, true, WithSequenceNumbers(), WithConcurrentWriters())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_sequence.mmap")
+	defer rb.Close()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if ok, err := rb.WriteMsg([]byte("concurrent")); !ok || err != nil {
+					t.Errorf("Failed to write message: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Numbers follow the order messages are read in
+	for want := uint64(1); want <= 800; want++ {
+		if _, seq, gap, err := rb.ReadMsgChecked(); err != nil || seq != want || gap {
+			t.Fatalf("Expected sequence %d, got (%d, %v, %v)", want, seq, gap, err)
+		}
+	}
+}
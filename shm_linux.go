@@ -0,0 +1,60 @@
+//go:build linux
+
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// shmDir is where Linux keeps POSIX shared memory objects; shm_open(3) is a
+// plain open of a file in it
+const shmDir = "/dev/shm"
+
+// NewRingBufferShm creates a ring buffer in the POSIX shared memory object
+// name, as shm_open would, replacing any existing object of that name. The
+// buffer lives in memory rather than on disk and can be shared between
+// processes, or containers, with the same IPC namespace and /dev/shm. The
+// object outlives the processes using it until UnlinkRingBufferShm.
+func NewRingBufferShm(name string, size int, opts ...Option) (*RingBuffer, error) {
+	path, err := shmPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewRingBuffer(path, size, true, opts...)
+}
+
+// OpenRingBufferShm maps an existing ring buffer in the POSIX shared memory
+// object name, as created by NewRingBufferShm. Options are handled as by
+// OpenRingBuffer.
+func OpenRingBufferShm(name string, opts ...Option) (*RingBuffer, error) {
+	path, err := shmPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return OpenRingBuffer(path, opts...)
+}
+
+// UnlinkRingBufferShm removes the shared memory object name, as shm_unlink
+// would. Buffers still mapping it keep working until closed.
+func UnlinkRingBufferShm(name string) error {
+	path, err := shmPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("ringbuffer: unlink shared memory %s: %w", name, err)
+	}
+	return nil
+}
+
+// shmPath returns the file backing the shared memory object name, which
+// may start with a slash but contain no other
+func shmPath(name string) (string, error) {
+	name = strings.TrimPrefix(name, "/")
+	if name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("ringbuffer: invalid shared memory name %q: %w", name, os.ErrInvalid)
+	}
+	return shmDir + "/" + name, nil
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestRingBufferShm(t *testing.T) {
+	if _, err := os.Stat(shmDir); err != nil {
+		t.Skipf("No %s: %v", shmDir, err)
+	}
+
+	rb, err := NewRingBufferShm("/test_rb_shm", 1024)
+	if err != nil {
+		t.Fatalf("Failed to create shared memory ring buffer: %v", err)
+	}
+	defer UnlinkRingBufferShm("test_rb_shm")
+	defer rb.Close()
+
+	// A second handle opened by name sees the same memory
+	other, err := OpenRingBufferShm("test_rb_shm")
+	if err != nil {
+		t.Fatalf("Failed to open shared memory ring buffer: %v", err)
+	}
+	defer other.Close()
+
+	if ok, err := rb.WriteMsg([]byte("over shm")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if msg, err := other.ReadMsg(); err != nil || string(msg) != "over shm" {
+		t.Errorf("Expected %q through the second handle, got %q (%v)", "over shm", msg, err)
+	}
+
+	if err := UnlinkRingBufferShm("test_rb_shm"); err != nil {
+		t.Errorf("Failed to unlink shared memory: %v", err)
+	}
+	if _, err := OpenRingBufferShm("test_rb_shm"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist after unlink, got: %v", err)
+	}
+}
+
+func TestRingBufferShmName(t *testing.T) {
+	for _, name := range []string{"", "/", "a/b", "../escape"} {
+		if _, err := NewRingBufferShm(name, 1024); !errors.Is(err, os.ErrInvalid) {
+			t.Errorf("Expected os.ErrInvalid for name %q, got: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package ringbuffer
+
+// cleanFlag is stored at cleanOffset by Close and cleared on open
+const cleanFlag = 1
+
+// WasCleanlyClosed reports whether the last handle to use the file before
+// this one was opened released it with Close. False means that process
+// probably crashed, and head, tail or the last frames may be inconsistent.
+// A buffer created by NewRingBuffer reports true.
+//
+// The flag is per file, not per handle: with several handles open at once,
+// the first Close marks the file clean even if another handle crashes later.
+func (r *RingBuffer) WasCleanlyClosed() bool {
+	return r.cleanAtOpen
+}
+
+// markOpen records whether the file was closed cleanly and clears the flag
+// until this handle is closed
+func (r *RingBuffer) markOpen() {
+	r.cleanAtOpen = fieldClean.get(r.buf) == cleanFlag
+	fieldClean.put(r.buf, 0)
+}
+
+// markClosed flags the file as cleanly closed. Caller must hold writeMu and
+// readMu.
+func (r *RingBuffer) markClosed() {
+	fieldClean.put(r.buf, cleanFlag)
+}
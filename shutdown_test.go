@@ -0,0 +1,46 @@
+package ringbuffer
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWasCleanlyClosed(t *testing.T) {
+	filename := "/tmp/test_rb_shutdown.mmap"
+	rb, err := NewRingBuffer(filename, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	if !rb.WasCleanlyClosed() {
+		t.Errorf("Expected a new buffer to report a clean close")
+	}
+	if ok, err := rb.WriteMsg([]byte("hello")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Failed to close ring buffer: %v", err)
+	}
+
+	// Reopen after a clean close, then simulate a crash by not closing
+	crashed, err := OpenRingBuffer(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	defer crashed.Close()
+	if !crashed.WasCleanlyClosed() {
+		t.Errorf("Expected a clean close to be detected")
+	}
+	if ok, err := crashed.WriteMsg([]byte("world")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+
+	rb, err = OpenRingBuffer(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen ring buffer: %v", err)
+	}
+	defer rb.Close()
+	if rb.WasCleanlyClosed() {
+		t.Errorf("Expected a missing Close to be detected")
+	}
+}
@@ -0,0 +1,75 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"io"
+)
+
+// ReadMsgSplit reads the next message into two destinations, its first
+// headerLen bytes into hdr and the rest into body, for messages made of a
+// fixed-size header and a variable body. It returns the length of the
+// body. A message shorter than headerLen, or hdr shorter than headerLen,
+// returns ErrInvalidSize; a body longer than body returns its length and
+// an error wrapping io.ErrShortBuffer, so the caller can retry with a
+// larger slice. In both cases the message stays in the buffer. Only a
+// message that fails to decrypt is consumed with its error, as with
+// ReadMsg.
+func (r *RingBuffer) ReadMsgSplit(headerLen int, hdr, body []byte) (bodyLen int, err error) {
+	if headerLen < 0 || len(hdr) < headerLen {
+		return 0, ErrInvalidSize
+	}
+
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return 0, ErrClosed
+	}
+	if r.opts.groups > 0 {
+		// The tail belongs to the reader groups' Commit
+		return 0, ErrIncompatibleOptions
+	}
+	if err := readFault(); err != nil {
+		return 0, err
+	}
+
+	f, err := r.peekFrame()
+	if err != nil {
+		return 0, err
+	}
+	var msg []byte
+	if r.opts.sealed() {
+		payload := make([]byte, f.msgLen)
+		r.copyOut(f.payload(), payload)
+		if msg, err = r.open(payload); err != nil {
+			r.consume(f)
+			return 0, err
+		}
+	}
+
+	n := int(f.msgLen)
+	if msg != nil {
+		n = len(msg)
+	}
+	if n < headerLen {
+		return 0, ErrInvalidSize
+	}
+	bodyLen = n - headerLen
+	if bodyLen > len(body) {
+		return bodyLen, fmt.Errorf("ringbuffer: message body of %d bytes does not fit in %d: %w", bodyLen, len(body), io.ErrShortBuffer)
+	}
+
+	if msg != nil {
+		copy(hdr, msg[:headerLen])
+		copy(body, msg[headerLen:])
+	} else {
+		pos := r.copyOut(f.payload(), hdr[:headerLen])
+		r.copyOut(pos, body[:bodyLen])
+	}
+	r.consume(f)
+
+	if r.opts.debugChecks {
+		return bodyLen, r.checkInvariants("read")
+	}
+	return bodyLen, nil
+}
@@ -0,0 +1,60 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestReadMsgSplit(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithEncryption(make([]byte, 32))}} {
+		const filename = "/tmp/test_rb_split.mmap"
+		rb, err := NewRingBuffer(filename, headerSize+128, true, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+
+		// Enough rounds for messages to wrap around the end
+		hdr := make([]byte, 4)
+		for i := 0; i < 10; i++ {
+			msg := fmt.Sprintf("H%03dbody of message %d", i, i)
+			if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+				t.Fatalf("Failed to write message %d: %v", i, err)
+			}
+
+			// A body that does not fit leaves the message buffered
+			small := make([]byte, 4)
+			n, err := rb.ReadMsgSplit(len(hdr), hdr, small)
+			if !errors.Is(err, io.ErrShortBuffer) || n != len(msg)-4 {
+				t.Fatalf("Message %d: expected io.ErrShortBuffer and %d, got %d (%v)", i, len(msg)-4, n, err)
+			}
+
+			body := make([]byte, n)
+			if n, err = rb.ReadMsgSplit(len(hdr), hdr, body); err != nil || n != len(msg)-4 {
+				t.Fatalf("Message %d: failed to read: %d (%v)", i, n, err)
+			}
+			if want := fmt.Sprintf("H%03d", i); string(hdr) != want {
+				t.Errorf("Message %d: expected header %q, got %q", i, want, hdr)
+			}
+			if want := fmt.Sprintf("body of message %d", i); string(body) != want {
+				t.Errorf("Message %d: expected body %q, got %q", i, want, body)
+			}
+		}
+
+		// A message shorter than the header stays buffered too
+		rb.WriteMsg([]byte("abc"))
+		if _, err := rb.ReadMsgSplit(len(hdr), hdr, make([]byte, 8)); err != ErrInvalidSize {
+			t.Errorf("Expected ErrInvalidSize for a short message, got: %v", err)
+		}
+		if msg, err := rb.ReadMsg(); err != nil || string(msg) != "abc" {
+			t.Errorf("Expected \"abc\" to stay buffered, got %q (%v)", msg, err)
+		}
+		if _, err := rb.ReadMsgSplit(len(hdr), hdr, nil); err != ErrBufferEmpty {
+			t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+		}
+		rb.Close()
+		os.Remove(filename)
+	}
+}
@@ -0,0 +1,55 @@
+package ringbuffer
+
+// WithSPSCFastPath lets the writer remember the last tail it saw and the
+// reader the last head, for a buffer with one writer and one reader. Only
+// the other side moves each of them, and only forward, so a stale value
+// just understates the room left: a write re-reads tail only when the
+// cached one leaves too little space, and a read re-reads head only when
+// the cached one says the buffer is empty.
+//
+// Only one handle may write the file and only one may read it, and no
+// other handle may reset, grow or defragment it while this one is open.
+// Goroutines sharing a handle are fine, as its write and read locks guard
+// the cached values.
+// With StrategyCount the space comes from the shared used-bytes count, so
+// the option saves only the tail or head read. It cannot be combined with
+// WithConcurrentWriters, reader groups or WithLatestSlot.
+func WithSPSCFastPath() Option {
+	return func(o *options) {
+		o.spsc = true
+	}
+}
+
+// reserveCached is reserve using the cached tail, with ok false if the
+// message does not fit before it. Caller must hold writeMu.
+func (r *RingBuffer) reserveCached(msgLen, credit uint32) (f frame, ok bool) {
+	if r.tailCache == 0 || !r.full.valid(r) {
+		return frame{}, false
+	}
+	head := r.headOnly()
+	if !r.inData(head) {
+		return frame{}, false
+	}
+	f, err := r.fit(head, r.tailCache, msgLen, credit)
+	return f, err == nil
+}
+
+// peekCached is peekFrame using the cached head, with ok false if the
+// buffer looks empty up to it. Caller must hold readMu.
+func (r *RingBuffer) peekCached() (f frame, ok bool, err error) {
+	if r.headCache == 0 || !r.full.valid(r) {
+		return frame{}, false, nil
+	}
+	tail := r.tailOnly()
+	if !r.inData(tail) || r.full.empty(r, r.headCache, tail) {
+		return frame{}, false, nil
+	}
+	f, err = r.frameBefore(tail, r.used(r.headCache, tail))
+	return f, true, err
+}
+
+// forgetCached drops the cached head and tail after both were moved back.
+// Caller must hold writeMu and readMu.
+func (r *RingBuffer) forgetCached() {
+	r.tailCache, r.headCache = 0, 0
+}
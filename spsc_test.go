@@ -0,0 +1,166 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestSPSCFastPath(t *testing.T) {
+	for _, s := range []Strategy{StrategySentinel, StrategyCount} {
+		t.Run(s.String(), func(t *testing.T) {
+			fast, err := NewRingBuffer("/tmp/test_rb_spsc_fast.mmap", 512, true, WithStrategy(s), WithSPSCFastPath())
+			if err != nil {
+				t.Fatalf("Failed to create ring buffer: %v", err)
+			}
+			defer os.Remove("/tmp/test_rb_spsc_fast.mmap")
+			defer fast.Close()
+			plain, err := NewRingBuffer("/tmp/test_rb_spsc_plain.mmap", 512, true, WithStrategy(s))
+			if err != nil {
+				t.Fatalf("Failed to create ring buffer: %v", err)
+			}
+			defer os.Remove("/tmp/test_rb_spsc_plain.mmap")
+			defer plain.Close()
+
+			// Both buffers must accept and return exactly the same messages
+			// through many wraps, including when full or empty
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < 5000; i++ {
+				if rng.Intn(2) == 0 {
+					msg := bytes.Repeat([]byte{byte(i)}, 1+rng.Intn(100))
+					okFast, errFast := fast.WriteMsg(msg)
+					okPlain, errPlain := plain.WriteMsg(msg)
+					if okFast != okPlain || !errors.Is(errFast, errPlain) {
+						t.Fatalf("Step %d: write returned %v, %v with the fast path and %v, %v without", i, okFast, errFast, okPlain, errPlain)
+					}
+				} else {
+					msgFast, errFast := fast.ReadMsg()
+					msgPlain, errPlain := plain.ReadMsg()
+					if !bytes.Equal(msgFast, msgPlain) || !errors.Is(errFast, errPlain) {
+						t.Fatalf("Step %d: read returned %v, %v with the fast path and %v, %v without", i, msgFast, errFast, msgPlain, errPlain)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSPSCFastPathMovedPointers(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_spsc_moved.mmap", 256, true, WithSPSCFastPath())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_spsc_moved.mmap")
+	defer rb.Close()
+
+	write := func(msg string) {
+		t.Helper()
+		if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+			t.Fatalf("Failed to write %q: %v", msg, err)
+		}
+	}
+	read := func(want string) {
+		t.Helper()
+		msg, err := rb.ReadMsg()
+		if err != nil || string(msg) != want {
+			t.Fatalf("Expected %q, got %q, %v", want, msg, err)
+		}
+	}
+
+	// Consume moves the tail past the head the reader cached
+	write("a")
+	read("a")
+	write("b")
+	write("c")
+	if err := rb.Consume(2); err != nil {
+		t.Fatalf("Failed to consume: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Fatalf("Expected ErrBufferEmpty after Consume, got %v", err)
+	}
+
+	// Defragment moves both back to the start of the data region
+	for i := 0; i < 8; i++ {
+		write(fmt.Sprintf("msg %d", i))
+	}
+	for i := 0; i < 6; i++ {
+		read(fmt.Sprintf("msg %d", i))
+	}
+	if err := rb.Defragment(); err != nil {
+		t.Fatalf("Failed to defragment: %v", err)
+	}
+	write("d")
+	read("msg 6")
+	read("msg 7")
+	read("d")
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Fatalf("Expected ErrBufferEmpty after Defragment, got %v", err)
+	}
+}
+
+func TestSPSCFastPathConcurrent(t *testing.T) {
+	for _, s := range []Strategy{StrategySentinel, StrategyCount} {
+		t.Run(s.String(), func(t *testing.T) {
+			rb, err := NewRingBuffer("/tmp/test_rb_spsc_concurrent.mmap", 1024, true,
+				WithStrategy(s), WithSPSCFastPath(), WithSequenceNumbers())
+			if err != nil {
+				t.Fatalf("Failed to create ring buffer: %v", err)
+			}
+			defer os.Remove("/tmp/test_rb_spsc_concurrent.mmap")
+			defer rb.Close()
+
+			const n = 20000
+			done := make(chan error, 1)
+			go func() {
+				for i := 0; i < n; {
+					msg, err := rb.ReadMsg()
+					if err == ErrBufferEmpty {
+						runtime.Gosched()
+						continue
+					}
+					if err != nil {
+						done <- err
+						return
+					}
+					if want := bytes.Repeat([]byte{byte(i)}, 1+i%60); !bytes.Equal(msg, want) {
+						done <- fmt.Errorf("message %d: got %v", i, msg)
+						return
+					}
+					i++
+				}
+				done <- nil
+			}()
+			for i := 0; i < n; {
+				ok, err := rb.WriteMsg(bytes.Repeat([]byte{byte(i)}, 1+i%60))
+				if err != nil && err != ErrBufferFull {
+					t.Fatalf("Failed to write message %d: %v", i, err)
+				}
+				if ok {
+					i++
+				} else {
+					runtime.Gosched()
+				}
+			}
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSPSCFastPathOptions(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithSPSCFastPath(), WithConcurrentWriters()},
+		{WithSPSCFastPath(), WithReaderGroups(2)},
+		{WithSPSCFastPath(), WithLatestSlot()},
+	} {
+		if _, err := NewRingBuffer("/tmp/test_rb_spsc_options.mmap", 256, true, opts...); !errors.Is(err, ErrIncompatibleOptions) {
+			t.Errorf("Expected ErrIncompatibleOptions, got %v", err)
+		}
+	}
+	os.Remove("/tmp/test_rb_spsc_options.mmap")
+}
@@ -0,0 +1,50 @@
+package ringbuffer
+
+// StartAt selects where a reader opening an existing buffer starts.
+type StartAt int
+
+const (
+	// StartAtOldest reads every buffered message first, then new ones as
+	// they arrive. This is the default.
+	StartAtOldest StartAt = iota
+	// StartAtNewest skips the buffered messages and only reads those
+	// written after the buffer is opened.
+	StartAtNewest
+)
+
+// String returns the name of the start position.
+func (s StartAt) String() string {
+	switch s {
+	case StartAtOldest:
+		return "oldest"
+	case StartAtNewest:
+		return "newest"
+	default:
+		return "unknown"
+	}
+}
+
+// WithStartAt selects where reading starts when an existing buffer is
+// opened. With StartAtNewest, OpenRingBuffer discards the buffered messages
+// by moving the tail to head, so they are gone for every handle, as if
+// consumed; use it only for the buffer's one reader. With reader groups the
+// tail is left alone and a group created by ReaderGroup starts at head
+// instead; existing groups resume from their committed offsets either way.
+// Not available with WithLatestSlot.
+func WithStartAt(s StartAt) Option {
+	return func(o *options) {
+		o.startAt = s
+	}
+}
+
+// skipBacklog moves the tail to head, discarding every buffered message
+func (r *RingBuffer) skipBacklog() error {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return err
+	}
+	return r.release(head, r.used(head, tail))
+}
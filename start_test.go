@@ -0,0 +1,110 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestStartAtNewest(t *testing.T) {
+	for _, s := range []Strategy{StrategySentinel, StrategyCount} {
+		const filename = "/tmp/test_rb_start.mmap"
+		rb, err := NewRingBuffer(filename, 1024, true, WithStrategy(s))
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+		for i := 0; i < 5; i++ {
+			if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("backlog %d", i))); !ok || err != nil {
+				t.Fatalf("%v: failed to write message: %v", s, err)
+			}
+		}
+		rb.Close()
+
+		// The default reads the backlog
+		rb, err = OpenRingBuffer(filename, WithStartAt(StartAtOldest))
+		if err != nil {
+			t.Fatalf("%v: failed to open ring buffer: %v", s, err)
+		}
+		if msg, err := rb.ReadMsg(); err != nil || string(msg) != "backlog 0" {
+			t.Errorf("%v: expected \"backlog 0\", got %q (%v)", s, msg, err)
+		}
+		rb.Close()
+
+		rb, err = OpenRingBuffer(filename, WithStartAt(StartAtNewest))
+		if err != nil {
+			t.Fatalf("%v: failed to open ring buffer: %v", s, err)
+		}
+		if lag := rb.Lag(); lag != 0 {
+			t.Errorf("%v: expected no backlog, lag is %d", s, lag)
+		}
+		for i := 0; i < 3; i++ {
+			if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("new %d", i))); !ok || err != nil {
+				t.Fatalf("%v: failed to write message: %v", s, err)
+			}
+		}
+		for i := 0; i < 3; i++ {
+			if msg, err := rb.ReadMsg(); err != nil || string(msg) != fmt.Sprintf("new %d", i) {
+				t.Errorf("%v: expected \"new %d\", got %q (%v)", s, i, msg, err)
+			}
+		}
+		if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+			t.Errorf("%v: expected ErrBufferEmpty, got %v", s, err)
+		}
+		rb.Close()
+		os.Remove(filename)
+	}
+}
+
+func TestStartAtNewestReaderGroups(t *testing.T) {
+	const filename = "/tmp/test_rb_start_groups.mmap"
+	rb, err := NewRingBuffer(filename, 1024, true, WithReaderGroups(2))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+	if _, err := rb.ReaderGroup("old"); err != nil {
+		t.Fatalf("Failed to create reader group: %v", err)
+	}
+	if ok, err := rb.WriteMsg([]byte("backlog")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	rb.Close()
+
+	rb, err = OpenRingBuffer(filename, WithStartAt(StartAtNewest))
+	if err != nil {
+		t.Fatalf("Failed to open ring buffer: %v", err)
+	}
+	defer rb.Close()
+	if ok, err := rb.WriteMsg([]byte("new")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+
+	// An existing group resumes, a new one starts at head
+	old, err := rb.ReaderGroup("old")
+	if err != nil {
+		t.Fatalf("Failed to reopen reader group: %v", err)
+	}
+	if msg, err := old.ReadMsg(); err != nil || string(msg) != "backlog" {
+		t.Errorf("Expected the existing group to read \"backlog\", got %q (%v)", msg, err)
+	}
+	fresh, err := rb.ReaderGroup("fresh")
+	if err != nil {
+		t.Fatalf("Failed to create reader group: %v", err)
+	}
+	if _, err := fresh.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected the new group to start at head, got %v", err)
+	}
+}
+
+func TestStartAtOptions(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithStartAt(StartAt(5))},
+		{WithStartAt(StartAtNewest), WithLatestSlot()},
+	} {
+		if _, err := NewRingBuffer("/tmp/test_rb_start_options.mmap", 256, true, opts...); !errors.Is(err, ErrIncompatibleOptions) {
+			t.Errorf("Expected ErrIncompatibleOptions, got %v", err)
+		}
+	}
+	os.Remove("/tmp/test_rb_start_options.mmap")
+}
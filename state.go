@@ -0,0 +1,80 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// NewRingBufferWithState creates a ring buffer with head and tail set to
+// the given offsets instead of the start of the data region, for
+// reproducing a wrap-around in a regression test or taking over frames
+// laid out by another tool. Unlike NewRingBuffer it keeps the existing
+// contents of the file's data region, so the frames between tail and head
+// must already be in place; they are validated before the buffer is
+// returned. head == tail makes an empty buffer positioned at that offset.
+// Both offsets must lie in the data region, or ErrInvalidSize is returned.
+func NewRingBufferWithState(mmapFileName string, size int, head, tail uint32, opts ...Option) (*RingBuffer, error) {
+	o, err := parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.latest {
+		// There are no frames to position, see WithLatestSlot
+		return nil, ErrIncompatibleOptions
+	}
+	if err := o.checkSize(size); err != nil {
+		return nil, err
+	}
+	for _, p := range []uint32{head, tail} {
+		if p < o.dataStart() || p >= uint32(size) {
+			return nil, fmt.Errorf("ringbuffer: offset %d outside data region [%d, %d): %w", p, o.dataStart(), size, ErrInvalidSize)
+		}
+	}
+
+	file, err := os.OpenFile(mmapFileName, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ringbuffer: open %s: %w", mmapFileName, err)
+	}
+	if o.hugePages {
+		if err := checkHugePages(file, int64(size)); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	if err := file.Truncate(int64(size)); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("ringbuffer: truncate %s to %d bytes: %w", mmapFileName, size, err)
+	}
+	mem, err := mmapFile(file, 0, size, o)
+	if err != nil {
+		file.Close()
+		return nil, mmapError(mmapFileName, 0, size, err)
+	}
+	buf := mem[:size:size]
+
+	// A fresh header and group slots, but the data region stays as it is
+	generation := uint32(fieldGeneration.get(buf)) + 1
+	clear(buf[:o.dataStart()])
+	rb := newRingBuffer(mmapFileName, buf, mem, o)
+	rb.initialize()
+	atomic.StoreUint32(rb.generationPtr(), generation)
+	rb.cleanAtOpen = true
+	rb.file = file
+	rb.setHead(head)
+	rb.setTail(tail)
+	if head != tail {
+		atomic.StoreUint32(rb.usedPtr(), rb.distance(head, tail))
+	}
+
+	for left, pos := rb.used(head, tail), tail; left > 0; {
+		f, err := rb.frameBefore(pos, left)
+		if err != nil {
+			rb.Close()
+			return nil, fmt.Errorf("ringbuffer: %s: no valid frame at %d between tail %d and head %d: %w", mmapFileName, pos, tail, head, err)
+		}
+		left -= f.footprint()
+		pos = f.next
+	}
+	return rb.attachIndex()
+}
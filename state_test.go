@@ -0,0 +1,59 @@
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestNewRingBufferWithState(t *testing.T) {
+	const name = "/tmp/test_rb_state.mmap"
+	defer os.Remove(name)
+
+	// An empty buffer positioned just before the end, so the first
+	// message wraps
+	rb, err := NewRingBufferWithState(name, 256, 250, 250, WithDebugChecks())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	msgs := []string{"wraps around the end", "second"}
+	for _, m := range msgs {
+		if ok, err := rb.WriteMsg([]byte(m)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	head, tail := rb.GetHeadTail()
+	if tail != 250 || head >= tail {
+		t.Fatalf("Expected the head to wrap past the end, got head %d tail %d", head, tail)
+	}
+	rb.Close()
+
+	// The same state over the existing frames reads them back
+	rb, err = NewRingBufferWithState(name, 256, head, tail, WithDebugChecks())
+	if err != nil {
+		t.Fatalf("Failed to recreate ring buffer: %v", err)
+	}
+	defer rb.Close()
+	for _, m := range msgs {
+		if msg, err := rb.ReadMsg(); err != nil || string(msg) != m {
+			t.Errorf("Expected %q, got %q (%v)", m, msg, err)
+		}
+	}
+}
+
+func TestNewRingBufferWithStateInvalid(t *testing.T) {
+	const name = "/tmp/test_rb_state.mmap"
+	os.Remove(name)
+	defer os.Remove(name)
+
+	for _, p := range [][2]uint32{{10, headerSize + 68}, {headerSize + 68, headerSize + 224}} {
+		if _, err := NewRingBufferWithState(name, headerSize+224, p[0], p[1]); !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("Expected ErrInvalidSize for head %d tail %d, got: %v", p[0], p[1], err)
+		}
+	}
+
+	// A fresh file has no frames between tail and head
+	if _, err := NewRingBufferWithState(name, headerSize+224, headerSize+68, headerSize+18); !errors.Is(err, ErrCorruptHeader) {
+		t.Errorf("Expected ErrCorruptHeader for missing frames, got: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package ringbuffer
+
+import "sync"
+
+// WithStats keeps counters of the messages written and read through this
+// handle, returned together by Stats. Each counted write or read also
+// takes a mutex of the counters' own, uncontended unless another
+// goroutine writes or reads at the same moment, which costs a few tens of
+// nanoseconds per message. Not available with reader groups, whose reads
+// do not consume, or WithLatestSlot.
+func WithStats() Option {
+	return func(o *options) {
+		o.stats = true
+	}
+}
+
+// Stats is a snapshot of the WithStats counters of one handle. Byte counts
+// are of messages as stored, after WithCompression or WithEncryption.
+type Stats struct {
+	Writes     uint64 // messages written, repeats folded by WithCoalescing included
+	WriteBytes uint64 // bytes of the messages written
+	Reads      uint64 // messages read, or discarded by Consume or SetReadOffset
+	ReadBytes  uint64 // bytes of the messages read or discarded
+	Dropped    uint64 // messages discarded by OverwriteOnFull
+	Deduped    uint64 // messages dropped by WithDedup
+	FullErrors uint64 // writes that returned ErrBufferFull
+}
+
+// Stats returns the WithStats counters. They are updated and read under
+// one lock, so a message is never counted without its bytes, and the
+// snapshot is as of one moment; it does not wait for writes or reads in
+// progress. The counters start at zero when the handle is opened and
+// remain readable after Close. Returns ErrIncompatibleOptions without
+// WithStats.
+func (r *RingBuffer) Stats() (Stats, error) {
+	if r.stats == nil {
+		return Stats{}, ErrIncompatibleOptions
+	}
+	r.stats.mu.Lock()
+	defer r.stats.mu.Unlock()
+	return r.stats.s, nil
+}
+
+// statsCounters holds the WithStats counters
+type statsCounters struct {
+	mu sync.Mutex
+	s  Stats
+}
+
+// add adds d to the counters, if they are kept
+func (c *statsCounters) add(d Stats) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.s.Writes += d.Writes
+	c.s.WriteBytes += d.WriteBytes
+	c.s.Reads += d.Reads
+	c.s.ReadBytes += d.ReadBytes
+	c.s.Dropped += d.Dropped
+	c.s.Deduped += d.Deduped
+	c.s.FullErrors += d.FullErrors
+	c.mu.Unlock()
+}
+
+// countWrite counts the outcome of writing one message of n bytes as
+// stored: written if ok, dropped as a duplicate if neither ok nor an
+// error, and a full buffer if the error says so
+func (r *RingBuffer) countWrite(ok bool, err error, n int) {
+	switch {
+	case ok:
+		r.stats.add(Stats{Writes: 1, WriteBytes: uint64(n)})
+	case err == nil:
+		r.stats.add(Stats{Deduped: 1})
+	case err == ErrBufferFull:
+		r.stats.add(Stats{FullErrors: 1})
+	}
+}
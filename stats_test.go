@@ -0,0 +1,175 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	const filename = "/tmp/test_rb_stats.mmap"
+	rb, err := NewRingBuffer(filename, headerSize+64, true, WithStats(), WithDedup(1, fnvKey))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(filename)
+
+	for _, msg := range []string{"one", "two", "two", "three"} {
+		rb.WriteMsg([]byte(msg))
+	}
+	rb.ReadMsg()
+	rb.Consume(1)
+	for i := 0; ; i++ {
+		if _, err := rb.WriteMsg([]byte(fmt.Sprintf("filler-%04d", i))); err != nil {
+			break
+		}
+	}
+	st, err := rb.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	fillers := st.Writes - 3
+	want := Stats{Writes: st.Writes, WriteBytes: 11 + 11*fillers, Reads: 2, ReadBytes: 6, Deduped: 1, FullErrors: 1}
+	if fillers == 0 || st != want {
+		t.Errorf("Expected %+v, got %+v", want, st)
+	}
+
+	// Counters survive Close
+	rb.Close()
+	if after, err := rb.Stats(); err != nil || after != st {
+		t.Errorf("Expected %+v after Close, got %+v (%v)", st, after, err)
+	}
+}
+
+func TestStatsDropped(t *testing.T) {
+	const filename = "/tmp/test_rb_stats_dropped.mmap"
+	rb, err := NewRingBuffer(filename, headerSize+64, true, WithStats(), WithFullPolicy(OverwriteOnFull))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove(filename)
+
+	for i := 0; i < 10; i++ {
+		if ok, err := rb.WriteMsg([]byte("twelve bytes")); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+	st, _ := rb.Stats()
+	if st.Writes != 10 || st.WriteBytes != 120 || st.Reads != 0 || st.Dropped == 0 || st.FullErrors != 0 {
+		t.Errorf("Unexpected counters: %+v", st)
+	}
+	if buffered := 10 - st.Dropped; uint64(rb.Lag()) != buffered*16 {
+		t.Errorf("Expected %d messages buffered, lag is %d", buffered, rb.Lag())
+	}
+}
+
+func TestStatsSplice(t *testing.T) {
+	src, err := NewRingBuffer("/tmp/test_rb_stats_src.mmap", 1024, true, WithStats())
+	if err != nil {
+		t.Fatalf("Failed to create source ring buffer: %v", err)
+	}
+	defer src.Close()
+	defer os.Remove("/tmp/test_rb_stats_src.mmap")
+	dst, err := NewRingBuffer("/tmp/test_rb_stats_dst.mmap", 1024, true, WithStats())
+	if err != nil {
+		t.Fatalf("Failed to create destination ring buffer: %v", err)
+	}
+	defer dst.Close()
+	defer os.Remove("/tmp/test_rb_stats_dst.mmap")
+
+	for _, msg := range []string{"one", "two", "three"} {
+		if ok, err := src.WriteMsg([]byte(msg)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	if moved, err := src.Splice(dst, 2); moved != 2 || err != nil {
+		t.Fatalf("Expected to splice 2 messages, got %d (%v)", moved, err)
+	}
+
+	// The moved frames are reads of the source and writes of the destination
+	if st, err := src.Stats(); err != nil || st != (Stats{Writes: 3, WriteBytes: 11, Reads: 2, ReadBytes: 6}) {
+		t.Errorf("Unexpected source stats %+v (%v)", st, err)
+	}
+	if st, err := dst.Stats(); err != nil || st != (Stats{Writes: 2, WriteBytes: 6}) {
+		t.Errorf("Unexpected destination stats %+v (%v)", st, err)
+	}
+}
+
+func TestStatsConsistent(t *testing.T) {
+	const filename = "/tmp/test_rb_stats_consistent.mmap"
+	rb, err := NewRingBuffer(filename, headerSize+32768, true, WithStats())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove(filename)
+
+	// Every snapshot taken while a writer and a reader run counts whole
+	// messages with their bytes. The buffer holds all of them, so neither
+	// has to wait for the other.
+	const msgLen, count = 10, 2000
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < count; i++ {
+			if ok, err := rb.WriteMsg(make([]byte, msgLen)); !ok || err != nil {
+				t.Errorf("Failed to write message %d: %v", i, err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < count; {
+			if _, err := rb.ReadMsg(); err == nil {
+				i++
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		st, _ := rb.Stats()
+		if st.WriteBytes != st.Writes*msgLen || st.ReadBytes != st.Reads*msgLen || st.Reads > st.Writes {
+			t.Fatalf("Inconsistent snapshot: %+v", st)
+		}
+		runtime.Gosched()
+		select {
+		case <-done:
+			if st, _ := rb.Stats(); st.Writes != count || st.Reads != count {
+				t.Errorf("Expected %d writes and reads, got %+v", count, st)
+			}
+			return
+		default:
+		}
+	}
+}
+
+func TestStatsOptions(t *testing.T) {
+	const filename = "/tmp/test_rb_stats_options.mmap"
+	defer os.Remove(filename)
+
+	rb, err := NewRingBuffer(filename, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	if _, err := rb.Stats(); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions without WithStats, got: %v", err)
+	}
+	rb.Close()
+	for _, opt := range []Option{WithReaderGroups(1), WithLatestSlot()} {
+		if _, err := NewRingBuffer(filename, 1024, true, WithStats(), opt); !errors.Is(err, ErrIncompatibleOptions) {
+			t.Errorf("Expected ErrIncompatibleOptions, got: %v", err)
+		}
+	}
+}
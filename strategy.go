@@ -0,0 +1,136 @@
+package ringbuffer
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Strategy selects how the ring buffer tells a full buffer apart from an
+// empty one. Both states would otherwise look the same: head == tail.
+type Strategy uint32
+
+const (
+	// StrategySentinel keeps one byte between head and tail permanently
+	// unused, so head == tail always means empty. The usable capacity is
+	// one byte less than the data region. This is the default.
+	StrategySentinel Strategy = iota
+	// StrategyCount tracks the number of used bytes in the header, so the
+	// whole data region can be filled and head == tail may also mean full.
+	StrategyCount
+)
+
+// String returns the name of the strategy.
+func (s Strategy) String() string {
+	switch s {
+	case StrategySentinel:
+		return "sentinel"
+	case StrategyCount:
+		return "count"
+	default:
+		return "unknown"
+	}
+}
+
+// fullStrategy implements the empty/full discrimination for a Strategy.
+type fullStrategy interface {
+	// available returns how many bytes may be written at head.
+	available(r *RingBuffer, head, tail uint32) uint32
+	// empty reports whether there is nothing to read.
+	empty(r *RingBuffer, head, tail uint32) bool
+	// produced records that n bytes were added behind head.
+	produced(r *RingBuffer, n uint32)
+	// consumed records that n bytes were released behind tail.
+	consumed(r *RingBuffer, n uint32)
+	// slack returns the number of data bytes that can never be used.
+	slack() uint32
+	// valid reports whether the strategy's state in the header is possible,
+	// so that no free-space computation can underflow.
+	valid(r *RingBuffer) bool
+}
+
+// newFullStrategy returns the implementation for s, or nil if s is unknown.
+func newFullStrategy(s Strategy) fullStrategy {
+	switch s {
+	case StrategySentinel:
+		return sentinelStrategy{}
+	case StrategyCount:
+		return countStrategy{}
+	default:
+		return nil
+	}
+}
+
+// distance returns the number of data bytes from tail up to head.
+func (r *RingBuffer) distance(head, tail uint32) uint32 {
+	if head >= tail {
+		return head - tail
+	}
+	return uint32(r.size) - tail + head - r.dataStart
+}
+
+// used returns the number of data bytes between tail and head, which unlike
+// distance is also correct for a full StrategyCount buffer
+func (r *RingBuffer) used(head, tail uint32) uint32 {
+	return uint32(r.size) - r.dataStart - r.full.slack() - r.full.available(r, head, tail)
+}
+
+// sentinelStrategy never lets head catch up with tail.
+type sentinelStrategy struct{}
+
+func (sentinelStrategy) available(r *RingBuffer, head, tail uint32) uint32 {
+	// One byte always stays free so that a full buffer never has head == tail.
+	return uint32(r.size) - r.dataStart - r.distance(head, tail) - sentinelStrategy{}.slack()
+}
+
+func (sentinelStrategy) empty(r *RingBuffer, head, tail uint32) bool {
+	return head == tail
+}
+
+func (sentinelStrategy) produced(r *RingBuffer, n uint32) {}
+
+func (sentinelStrategy) consumed(r *RingBuffer, n uint32) {}
+
+func (sentinelStrategy) slack() uint32 {
+	return 1
+}
+
+func (sentinelStrategy) valid(r *RingBuffer) bool {
+	// Any head and tail in the data region are a possible state; frames at
+	// a wrong tail are caught by frameBefore
+	return true
+}
+
+// countStrategy keeps a shared byte count in the header.
+type countStrategy struct{}
+
+func (countStrategy) available(r *RingBuffer, head, tail uint32) uint32 {
+	return uint32(r.size) - r.dataStart - atomic.LoadUint32(r.usedPtr())
+}
+
+func (countStrategy) empty(r *RingBuffer, head, tail uint32) bool {
+	return atomic.LoadUint32(r.usedPtr()) == 0
+}
+
+func (countStrategy) produced(r *RingBuffer, n uint32) {
+	atomic.AddUint32(r.usedPtr(), n)
+}
+
+func (countStrategy) consumed(r *RingBuffer, n uint32) {
+	atomic.AddUint32(r.usedPtr(), ^(n - 1))
+}
+
+func (countStrategy) slack() uint32 {
+	return 0
+}
+
+func (countStrategy) valid(r *RingBuffer) bool {
+	// A reader that consumed more than was written wraps the count around
+	return atomic.LoadUint32(r.usedPtr()) <= uint32(r.size)-r.dataStart
+}
+
+// usedPtr returns the used-bytes counter in the header. It is updated with
+// atomic operations because readers and writers hold different locks and
+// may live in different processes.
+func (r *RingBuffer) usedPtr() *uint32 {
+	return (*uint32)(unsafe.Pointer(&r.buf[usedOffset]))
+}
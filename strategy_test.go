@@ -0,0 +1,231 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestStrategyDefault(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_strategy_default.mmap", headerSize+32, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_strategy_default.mmap")
+
+	if rb.Strategy() != StrategySentinel {
+		t.Errorf("Expected default strategy %v, got: %v", StrategySentinel, rb.Strategy())
+	}
+
+	// The sentinel byte can never be used
+	fullMsg := make([]byte, rb.MaxMsgSize()+1)
+	ok, err := rb.WriteMsg(fullMsg)
+	if ok || err != ErrMessageTooLarge {
+		t.Errorf("Expected ErrMessageTooLarge for message filling the sentinel byte, got: %v", err)
+	}
+}
+
+func TestStrategyCountFullCapacity(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_strategy_count.mmap", headerSize+32, true, WithStrategy(StrategyCount))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_strategy_count.mmap")
+
+	if rb.Strategy() != StrategyCount {
+		t.Errorf("Expected strategy %v, got: %v", StrategyCount, rb.Strategy())
+	}
+
+	// The whole data region is usable
+	fullMsg := make([]byte, rb.MaxMsgSize())
+	if len(fullMsg) != headerSize+32-int(rb.dataStart)-4 {
+		t.Errorf("Expected the whole data region to be usable, MaxMsgSize is %d", len(fullMsg))
+	}
+	for i := range fullMsg {
+		fullMsg[i] = byte('a' + i%26)
+	}
+	ok, err := rb.WriteMsg(fullMsg)
+	if !ok || err != nil {
+		t.Fatalf("Failed to write message filling the buffer: %v", err)
+	}
+
+	// head == tail now, but the buffer is full rather than empty
+	head, tail := rb.GetHeadTail()
+	if head != tail {
+		t.Errorf("Expected head == tail on a full buffer, got head=%d tail=%d", head, tail)
+	}
+	ok, err = rb.WriteMsg([]byte("x"))
+	if ok || err != ErrBufferFull {
+		t.Errorf("Expected ErrBufferFull, got: %v", err)
+	}
+
+	readMsg, err := rb.ReadMsg()
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if string(readMsg) != string(fullMsg) {
+		t.Errorf("Read message doesn't match written message")
+	}
+
+	_, err = rb.ReadMsg()
+	if err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+}
+
+func TestStrategyCountWrapAround(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_strategy_wrap.mmap", headerSize+32, true, WithStrategy(StrategyCount))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_strategy_wrap.mmap")
+
+	// Cycle messages of varying sizes through the ring several times
+	for i := 0; i < 50; i++ {
+		msg := make([]byte, 1+i%17)
+		for j := range msg {
+			msg[j] = byte(i + j)
+		}
+		ok, err := rb.WriteMsg(msg)
+		if !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+		readMsg, err := rb.ReadMsg()
+		if err != nil {
+			t.Fatalf("Failed to read message %d: %v", i, err)
+		}
+		if string(readMsg) != string(msg) {
+			t.Fatalf("Message %d mismatch", i)
+		}
+	}
+
+	_, err = rb.ReadMsg()
+	if err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+}
+
+func TestStrategyCountFullAfterWrap(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_strategy_full_wrap.mmap", headerSize+32, true, WithStrategy(StrategyCount))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_strategy_full_wrap.mmap")
+
+	// Move head and tail off the start, then fill all 32 bytes with 8-byte
+	// frames, so head wraps around onto tail
+	rb.WriteMsg([]byte("skip"))
+	rb.ReadMsg()
+	for i := 0; i < 4; i++ {
+		if ok, err := rb.WriteMsg([]byte(fmt.Sprintf("msg%d", i))); !ok || err != nil {
+			t.Fatalf("Failed to write message %d: %v", i, err)
+		}
+	}
+	if head, tail := rb.GetHeadTail(); head != tail || head == rb.dataStart {
+		t.Fatalf("Expected head to meet tail after a wrap, got head %d, tail %d", head, tail)
+	}
+	if rb.IsEmpty() {
+		t.Errorf("Expected a full buffer not to look empty")
+	}
+	if ok, err := rb.WriteMsg([]byte("x")); ok || err != ErrBufferFull {
+		t.Errorf("Expected ErrBufferFull, got: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if msg, err := rb.ReadMsg(); err != nil || string(msg) != fmt.Sprintf("msg%d", i) {
+			t.Errorf("Expected msg%d, got %q (%v)", i, msg, err)
+		}
+	}
+	if _, err := rb.ReadMsg(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty, got: %v", err)
+	}
+}
+
+func TestStrategyUnknown(t *testing.T) {
+	_, err := NewRingBuffer("/tmp/test_rb_strategy_unknown.mmap", 64, true, WithStrategy(Strategy(99)))
+	defer os.Remove("/tmp/test_rb_strategy_unknown.mmap")
+	if err != ErrStrategy {
+		t.Errorf("Expected ErrStrategy, got: %v", err)
+	}
+}
+
+func TestStrategyFromFile(t *testing.T) {
+	const name = "/tmp/test_rb_strategy_file.mmap"
+	rb, err := NewRingBuffer(name, headerSize+32, true, WithStrategy(StrategyCount))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove(name)
+
+	// Fill every data byte, which only StrategyCount can tell from empty
+	if ok, err := rb.WriteMsg(make([]byte, rb.MaxMsgSize())); !ok || err != nil {
+		t.Fatalf("Failed to fill the buffer: %v", err)
+	}
+	rb.Close()
+
+	if _, err := OpenRingBuffer(name, WithStrategy(StrategySentinel)); !errors.Is(err, ErrIncompatibleOptions) {
+		t.Errorf("Expected ErrIncompatibleOptions for an explicit other strategy, got: %v", err)
+	}
+
+	rb, err = OpenRingBuffer(name)
+	if err != nil {
+		t.Fatalf("Failed to open without WithStrategy: %v", err)
+	}
+	defer rb.Close()
+	if rb.Strategy() != StrategyCount {
+		t.Errorf("Expected the recorded StrategyCount, got %v", rb.Strategy())
+	}
+	if msg, err := rb.ReadMsg(); err != nil || len(msg) != rb.MaxMsgSize() {
+		t.Errorf("Expected the full-size message, got %d bytes (%v)", len(msg), err)
+	}
+}
+
+func TestStrategyTailPastHead(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_strategy_tail.mmap", 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_strategy_tail.mmap")
+	defer rb.Close()
+
+	if ok, err := rb.WriteMsg([]byte("hello")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	// A broken reader moves tail just past head
+	head, _ := rb.GetHeadTail()
+	rb.setTail(head + 8)
+
+	if _, err := rb.ReadMsg(); !errors.Is(err, ErrCorruptHeader) {
+		t.Errorf("Expected ErrCorruptHeader reading at a tail past head, got: %v", err)
+	}
+	// Only the 7 bytes up to the tail look free, never a wrapped-around size
+	if _, err := rb.WriteMsg([]byte("hello")); err != ErrBufferFull {
+		t.Errorf("Expected ErrBufferFull, got: %v", err)
+	}
+}
+
+func TestStrategyCountUnderflow(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_strategy_tail.mmap", 256, true, WithStrategy(StrategyCount))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_strategy_tail.mmap")
+	defer rb.Close()
+
+	if ok, err := rb.WriteMsg([]byte("hello")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	// A reader consumed more than was written, wrapping the count around
+	rb.full.consumed(rb, 100)
+
+	if _, err := rb.ReadMsg(); !errors.Is(err, ErrCorruptHeader) {
+		t.Errorf("Expected ErrCorruptHeader reading, got: %v", err)
+	}
+	if _, err := rb.WriteMsg([]byte("hello")); !errors.Is(err, ErrCorruptHeader) {
+		t.Errorf("Expected ErrCorruptHeader writing, got: %v", err)
+	}
+}
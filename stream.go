@@ -0,0 +1,80 @@
+package ringbuffer
+
+import "io"
+
+// ReadMsgStream returns a reader over the next message's payload, read
+// straight from the mapping, so messages larger than any buffer at hand
+// can be processed in pieces. The message is consumed when the reader
+// returns io.EOF or is closed; closing it early discards the rest.
+//
+// The stream holds the read lock until then: other reads, Close, and
+// writers that need to drop or move messages wait until it is drained or
+// closed, so it must not be left open. Returns ErrIncompatibleOptions with
+// reader groups, WithCompression or WithEncryption, whose messages must be
+// read whole.
+func (r *RingBuffer) ReadMsgStream() (io.ReadCloser, error) {
+	r.readMu.Lock()
+
+	if r.closed {
+		r.readMu.Unlock()
+		return nil, ErrClosed
+	}
+	if r.opts.groups > 0 || r.opts.sealed() {
+		r.readMu.Unlock()
+		return nil, ErrIncompatibleOptions
+	}
+
+	f, err := r.peekFrame()
+	if err != nil {
+		r.readMu.Unlock()
+		return nil, err
+	}
+	return &msgStream{r: r, f: f}, nil
+}
+
+// msgStream reads one message in place. It holds readMu until done.
+type msgStream struct {
+	r    *RingBuffer
+	f    frame
+	off  uint32 // payload bytes read so far
+	done bool
+	err  error // error from the final debug check, returned by Close
+}
+
+func (s *msgStream) Read(p []byte) (int, error) {
+	if s.done {
+		return 0, io.EOF
+	}
+	if s.off == s.f.msgLen {
+		// A signal has no payload
+		s.finish()
+		return 0, io.EOF
+	}
+	n := min(uint32(len(p)), s.f.msgLen-s.off)
+	_, _, pos := s.r.span(s.f.payload(), s.off)
+	s.r.copyOut(pos, p[:n])
+	s.off += n
+	if s.off == s.f.msgLen {
+		s.finish()
+	}
+	return int(n), nil
+}
+
+// Close consumes the message, discarding any unread rest, and releases the
+// read lock. It is safe to call more than once.
+func (s *msgStream) Close() error {
+	if !s.done {
+		s.finish()
+	}
+	return s.err
+}
+
+// finish consumes the message and releases readMu
+func (s *msgStream) finish() {
+	s.done = true
+	s.r.consume(s.f)
+	if s.r.opts.debugChecks {
+		s.err = s.r.checkInvariants("read")
+	}
+	s.r.readMu.Unlock()
+}
@@ -0,0 +1,107 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestRingBufferReadMsgStream(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_stream.mmap", headerSize+224, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_stream.mmap")
+	defer rb.Close()
+
+	// Move the head close to the end so the next message wraps around
+	if ok, err := rb.WriteMsg(make([]byte, 150)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+
+	msg := make([]byte, 180)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if ok, err := rb.WriteMsg([]byte("next")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+
+	_, tail := rb.GetHeadTail()
+	s, err := rb.ReadMsgStream()
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+
+	// Read in small pieces; the tail only moves once the stream is drained
+	var got bytes.Buffer
+	chunk := make([]byte, 7)
+	for {
+		n, err := s.Read(chunk)
+		got.Write(chunk[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read stream: %v", err)
+		}
+		if _, now := rb.GetHeadTail(); got.Len() < len(msg) && now != tail {
+			t.Fatalf("Expected the tail to stay put while streaming")
+		}
+	}
+	if !bytes.Equal(got.Bytes(), msg) {
+		t.Errorf("Expected streamed message to match the written one")
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Failed to close drained stream: %v", err)
+	}
+
+	if next, err := rb.ReadMsg(); err != nil || string(next) != "next" {
+		t.Errorf("Expected next message after the stream, got %q (%v)", next, err)
+	}
+}
+
+func TestRingBufferReadMsgStreamClose(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_stream.mmap", 256, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_stream.mmap")
+	defer rb.Close()
+
+	if _, err := rb.ReadMsgStream(); err != ErrBufferEmpty {
+		t.Errorf("Expected ErrBufferEmpty from an empty buffer, got: %v", err)
+	}
+
+	for _, m := range []string{"first message", "second"} {
+		if ok, err := rb.WriteMsg([]byte(m)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+
+	// Closing early discards the rest of the message
+	s, err := rb.ReadMsgStream()
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	part := make([]byte, 5)
+	if n, err := s.Read(part); n != 5 || err != nil || string(part) != "first" {
+		t.Errorf("Expected %q, got %q (%v)", "first", part[:n], err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Failed to close stream: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Expected closing twice to be harmless, got: %v", err)
+	}
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "second" {
+		t.Errorf("Expected second message, got %q (%v)", msg, err)
+	}
+}
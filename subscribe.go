@@ -0,0 +1,134 @@
+package ringbuffer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// subscribePollInterval is how often SubscribeFile checks, while the buffer
+// is empty, whether the file was replaced or rebuilt
+const subscribePollInterval = 100 * time.Millisecond
+
+// SubscribeFile consumes the buffer in the file name and delivers its
+// messages on the first channel until ctx is done. It follows the file
+// across recreation: when the path names a different file than the one
+// open, or the generation counter moves because the buffer was reset or
+// grown, it drains what is left in the old mapping and reopens the file
+// with OpenRingBuffer, reading the new file from its oldest message so
+// nothing written there before the switch is missed. While the file is
+// missing or not yet initialized, it waits and tries again. opts are
+// passed to OpenRingBuffer.
+//
+// Any other error is sent on the second channel and ends the
+// subscription. Both channels are closed when it ends. Messages are
+// consumed before they are delivered, so one read when ctx is done is
+// dropped. The file change is noticed by polling, within
+// subscribePollInterval of the buffer running empty.
+func SubscribeFile(ctx context.Context, name string, opts ...Option) (<-chan []byte, <-chan error) {
+	msgs := make(chan []byte)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(msgs)
+		if err := subscribe(ctx, name, opts, msgs); err != nil && ctx.Err() == nil {
+			errs <- err
+		}
+	}()
+	return msgs, errs
+}
+
+// subscribe runs SubscribeFile until ctx is done or an error ends it
+func subscribe(ctx context.Context, name string, opts []Option, msgs chan<- []byte) error {
+	for {
+		rb, fi, err := openSubscribed(ctx, name, opts)
+		if err != nil {
+			return err
+		}
+		err = follow(ctx, rb, name, fi, msgs)
+		rb.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// openSubscribed opens the file, waiting while it is missing or still
+// being created, and returns it with the file's identity
+func openSubscribed(ctx context.Context, name string, opts []Option) (*RingBuffer, os.FileInfo, error) {
+	for {
+		// Stat first: if the file is replaced in between, the stale
+		// identity makes follow reopen at once rather than miss the change
+		fi, err := os.Stat(name)
+		if err == nil {
+			var rb *RingBuffer
+			rb, err = OpenRingBuffer(name, opts...)
+			if err == nil {
+				return rb, fi, nil
+			}
+		}
+		if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, ErrUninitialized) && !errors.Is(err, ErrInvalidSize) {
+			return nil, nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(subscribePollInterval):
+		}
+	}
+}
+
+// follow delivers rb's messages until the file is replaced or rebuilt, and
+// returns nil once everything left in rb has been delivered
+func follow(ctx context.Context, rb *RingBuffer, name string, fi os.FileInfo, msgs chan<- []byte) error {
+	generation := rb.Generation()
+	for {
+		wait, cancel := context.WithTimeout(ctx, subscribePollInterval)
+		msg, err := rb.ReadMsgBlocking(wait)
+		cancel()
+		if err == nil {
+			select {
+			case msgs <- msg:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err != context.DeadlineExceeded || ctx.Err() != nil {
+			return err
+		}
+
+		// The buffer ran empty; reopen if the file is no longer this one.
+		// A missing file is being replaced, so wait for the new one there.
+		if rb.Generation() != generation {
+			return nil
+		}
+		now, err := os.Stat(name)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err != nil || !os.SameFile(fi, now) {
+			return drain(ctx, rb, msgs)
+		}
+	}
+}
+
+// drain delivers the messages still in rb, which the writer may have added
+// after the last read but before it moved to the new file
+func drain(ctx context.Context, rb *RingBuffer, msgs chan<- []byte) error {
+	for {
+		msg, err := rb.ReadMsg()
+		if err == ErrBufferEmpty {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case msgs <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
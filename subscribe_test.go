@@ -0,0 +1,128 @@
+package ringbuffer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSubscribeFile(t *testing.T) {
+	const filename = "/tmp/test_rb_subscribe.mmap"
+	const next = "/tmp/test_rb_subscribe.mmap.next"
+	defer os.Remove(filename)
+	defer os.Remove(next)
+
+	old, err := NewRingBuffer(filename, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer old.Close()
+	writeMsgs(t, old, "one", "two")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgs, errs := SubscribeFile(ctx, filename)
+	expectMsgs(t, msgs, "one", "two")
+
+	// The writer rotates: the new file already holds messages when it is
+	// renamed into place, and one more goes to the old file before that
+	rb, err := NewRingBuffer(next, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	writeMsgs(t, rb, "three", "four")
+	writeMsgs(t, old, "late")
+	if err := os.Rename(next, filename); err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+	expectMsgs(t, msgs, "late", "three", "four")
+	writeMsgs(t, rb, "five")
+	expectMsgs(t, msgs, "five")
+
+	// A reset moves the generation, and later writes still arrive
+	if err := rb.Reset(); err != nil {
+		t.Fatalf("Failed to reset: %v", err)
+	}
+	writeMsgs(t, rb, "six")
+	expectMsgs(t, msgs, "six")
+
+	cancel()
+	if _, ok := <-msgs; ok {
+		t.Errorf("Expected the message channel to be closed")
+	}
+	if err, ok := <-errs; ok {
+		t.Errorf("Expected no error after cancelling, got: %v", err)
+	}
+}
+
+func TestSubscribeFileMissing(t *testing.T) {
+	const filename = "/tmp/test_rb_subscribe_missing.mmap"
+	os.Remove(filename)
+	defer os.Remove(filename)
+
+	// The subscription waits for the file to appear
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgs, errs := SubscribeFile(ctx, filename)
+	time.Sleep(2 * subscribePollInterval)
+	rb, err := NewRingBuffer(filename, 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	writeMsgs(t, rb, "hello")
+	expectMsgs(t, msgs, "hello")
+	cancel()
+
+	// Anything but a missing or half-created file ends it
+	if err := os.WriteFile(filename, make([]byte, 256), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	bad := []byte{0xff, 0xff, 0xff, 0xff}
+	f, err := os.OpenFile(filename, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	f.WriteAt(bad, formatOffset)
+	f.Close()
+	msgs, errs = SubscribeFile(context.Background(), filename)
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrCorruptHeader) {
+			t.Errorf("Expected ErrCorruptHeader, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Subscription did not end on a corrupt file")
+	}
+	if _, ok := <-msgs; ok {
+		t.Errorf("Expected the message channel to be closed")
+	}
+}
+
+// writeMsgs writes each message to rb
+func writeMsgs(t *testing.T, rb *RingBuffer, msgs ...string) {
+	t.Helper()
+	for _, m := range msgs {
+		if ok, err := rb.WriteMsg([]byte(m)); !ok || err != nil {
+			t.Fatalf("Failed to write %q: %v", m, err)
+		}
+	}
+}
+
+// expectMsgs receives len(want) messages and compares them with want
+func expectMsgs(t *testing.T, msgs <-chan []byte, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		select {
+		case msg, ok := <-msgs:
+			if !ok || string(msg) != w {
+				t.Fatalf("Expected %q, got %q (open %v)", w, msg, ok)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %q", w)
+		}
+	}
+}
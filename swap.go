@@ -0,0 +1,87 @@
+package ringbuffer
+
+// WriteReadSwap writes msg to a buffer used as a fixed-depth window: if msg
+// does not fit, the oldest message is popped first and returned, otherwise
+// popped is nil. Whether to pop and the pop itself happen under both
+// locks, and the write lock is held until msg is written, so no other read
+// can take the popped message and no other write can take the space it
+// freed. If popping the oldest message would still not make room,
+// ErrBufferFull is returned and nothing changes; the full policy is not
+// applied. Not available with reader groups, WithConcurrentWriters or
+// WithLatestSlot.
+func (r *RingBuffer) WriteReadSwap(msg []byte) (popped []byte, err error) {
+	if r.opts.groups > 0 || r.opts.concurrentWriters || r.opts.latest {
+		return nil, ErrIncompatibleOptions
+	}
+	if len(msg) == 0 {
+		return nil, ErrInvalidSize
+	}
+	if err := r.limitWrite(len(msg)); err != nil {
+		return nil, err
+	}
+	if msg, err = r.seal(msg); err != nil {
+		return nil, err
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if !r.acceptsWrites() {
+		return nil, ErrClosed
+	}
+	if popped, err = r.popForSwap(uint32(len(msg))); err != nil {
+		r.countWrite(false, err, len(msg))
+		return nil, err
+	}
+	// Only readers ran since the pop, and they can only free more space
+	if err := r.writeFrame(msg, 0, nil); err != nil {
+		return nil, err
+	}
+	r.countWrite(true, nil, len(msg))
+	if popped == nil {
+		return nil, nil
+	}
+	// A message that fails to decrypt stays popped, as with ReadMsg
+	return r.open(popped)
+}
+
+// popForSwap consumes and returns the message at tail if a message of
+// msgLen bytes does not fit at head but would without it, and returns nil
+// if it fits already. Caller must hold writeMu.
+func (r *RingBuffer) popForSwap(msgLen uint32) ([]byte, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	_, err := r.reserve(msgLen, 0)
+	if err != ErrBufferFull {
+		return nil, err
+	}
+	f, err := r.peekFrame()
+	if err == ErrBufferEmpty {
+		return nil, ErrBufferFull
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Consuming f frees exactly its footprint, whatever the strategy
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return nil, err
+	}
+	avail := r.full.available(r, head, tail) + f.footprint()
+	need := r.place(head, msgLen).footprint() + r.reserved.Load()
+	if need > avail {
+		return nil, ErrBufferFull
+	}
+
+	popped := make([]byte, f.msgLen)
+	r.copyOut(f.payload(), popped)
+	r.consume(f)
+	if r.opts.debugChecks {
+		if err := r.checkInvariants("read"); err != nil {
+			return nil, err
+		}
+	}
+	return popped, nil
+}
@@ -0,0 +1,70 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestWriteReadSwap(t *testing.T) {
+	for _, s := range []Strategy{StrategySentinel, StrategyCount} {
+		const filename = "/tmp/test_rb_swap.mmap"
+		rb, err := NewRingBuffer(filename, headerSize+60, true, WithStrategy(s))
+		if err != nil {
+			t.Fatalf("Failed to create ring buffer: %v", err)
+		}
+
+		// Three 16-byte frames fill the buffer under either strategy; from then on every write
+		// pops the oldest message
+		for i := 0; i < 8; i++ {
+			popped, err := rb.WriteReadSwap([]byte(fmt.Sprintf("window %d....", i)))
+			if err != nil {
+				t.Fatalf("%v: swap %d failed: %v", s, i, err)
+			}
+			want := ""
+			if i >= 3 {
+				want = fmt.Sprintf("window %d....", i-3)
+			}
+			if string(popped) != want || (want == "") != (popped == nil) {
+				t.Errorf("%v: swap %d: expected %q, got %q", s, i, want, popped)
+			}
+		}
+		for i := 5; i < 8; i++ {
+			if msg, err := rb.ReadMsg(); err != nil || string(msg) != fmt.Sprintf("window %d....", i) {
+				t.Errorf("%v: expected \"window %d....\", got %q (%v)", s, i, msg, err)
+			}
+		}
+
+		// A message that needs more than the oldest frame's space changes
+		// nothing
+		for _, msg := range [][]byte{[]byte("a"), make([]byte, 40)} {
+			if ok, err := rb.WriteMsg(msg); !ok || err != nil {
+				t.Fatalf("%v: failed to write message: %v", s, err)
+			}
+		}
+		if _, err := rb.WriteReadSwap(make([]byte, 30)); err != ErrBufferFull {
+			t.Errorf("%v: expected ErrBufferFull, got: %v", s, err)
+		}
+		if msg, err := rb.ReadMsg(); err != nil || string(msg) != "a" {
+			t.Errorf("%v: expected \"a\" to stay buffered, got %q (%v)", s, msg, err)
+		}
+		rb.Close()
+		os.Remove(filename)
+	}
+}
+
+func TestWriteReadSwapOptions(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_swap_options.mmap", 1024, true, WithReaderGroups(1))
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_swap_options.mmap")
+	if _, err := rb.WriteReadSwap([]byte("x")); !errors.Is(err, ErrIncompatibleOptions) {
+		t.Errorf("Expected ErrIncompatibleOptions, got: %v", err)
+	}
+	rb.Close()
+	if _, err := rb.WriteReadSwap([]byte("x")); err != ErrIncompatibleOptions && err != ErrClosed {
+		t.Errorf("Expected an error after Close, got: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package ringbuffer
+
+import "time"
+
+// WithTimestamps adds an 8-byte write timestamp to every frame, taken with
+// time.Now().UnixNano() when the message is published and returned by
+// ReadMsgWithTime. The option is recorded in the file, so OpenRingBuffer
+// may leave it out.
+//
+// The timestamp comes from the writer's wall clock. Latency computed by a
+// reader in another process on the same host is accurate up to clock
+// reads; across hosts sharing the file, clock skew between them is added
+// to every measurement and may even make it negative.
+func WithTimestamps() Option {
+	return func(o *options) {
+		o.timestamps = true
+	}
+}
+
+// ReadMsgWithTime reads a message and the time it was written, in Unix
+// nanoseconds. Without WithTimestamps the time is always 0.
+// Returns (msg, time, nil) if successful, (nil, 0, error) if failed
+func (r *RingBuffer) ReadMsgWithTime() ([]byte, int64, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, 0, ErrClosed
+	}
+
+	msg, f, err := r.readFrame()
+	if err != nil {
+		return nil, 0, err
+	}
+	return msg, f.time, nil
+}
+
+// ReadSince returns every buffered message written at or after t, in Unix
+// nanoseconds, oldest first, without consuming anything. All messages are
+// checked, as timestamps from the wall clock need not increase. An empty
+// result is not an error. Returns ErrIncompatibleOptions without
+// WithTimestamps.
+func (r *RingBuffer) ReadSince(t int64) ([][]byte, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+	if !r.opts.timestamps {
+		return nil, ErrIncompatibleOptions
+	}
+
+	head, tail, err := r.loadHeadTail()
+	if err != nil {
+		return nil, err
+	}
+	var msgs [][]byte
+	left, pos := r.used(head, tail), tail
+	for left > 0 {
+		f, err := r.frameBefore(pos, left)
+		if err != nil {
+			return nil, err
+		}
+		if f.time >= t {
+			msg := make([]byte, f.msgLen)
+			r.copyOut(f.payload(), msg)
+			if msg, err = r.open(msg); err != nil {
+				return nil, err
+			}
+			msgs = append(msgs, msg)
+		}
+		left -= f.footprint()
+		pos = f.next
+	}
+	return msgs, nil
+}
+
+// stamp returns the timestamp for a frame published now, or 0 without
+// WithTimestamps
+func (r *RingBuffer) stamp() int64 {
+	if !r.opts.timestamps {
+		return 0
+	}
+	return time.Now().UnixNano()
+}
@@ -0,0 +1,105 @@
+package ringbuffer
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadMsgWithTime(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_timestamps.mmap", 1024, true, WithTimestamps(), WithFlags())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_timestamps.mmap")
+
+	before := time.Now().UnixNano()
+	if ok, err := rb.WriteMsgFlags([]byte("timed message"), 0x7); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	after := time.Now().UnixNano()
+
+	length, flags, err := rb.PeekHeader()
+	if err != nil || length != uint32(len("timed message")) || flags != 0x7 {
+		t.Errorf("Header mismatch. Got: length=%d flags=%#x (%v)", length, flags, err)
+	}
+
+	readMsg, ts, err := rb.ReadMsgWithTime()
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if string(readMsg) != "timed message" {
+		t.Errorf("Message mismatch. Got: %s", string(readMsg))
+	}
+	if ts < before || ts > after {
+		t.Errorf("Timestamp %d outside write window [%d, %d]", ts, before, after)
+	}
+}
+
+func TestReadMsgWithTimeWithoutOption(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_notimestamps.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_notimestamps.mmap")
+
+	if ok, err := rb.WriteMsg([]byte("untimed")); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	readMsg, ts, err := rb.ReadMsgWithTime()
+	if err != nil || string(readMsg) != "untimed" || ts != 0 {
+		t.Errorf("Expected untimed message with time 0, got: %s time=%d (%v)", string(readMsg), ts, err)
+	}
+}
+
+func TestReadSince(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_since.mmap", 1024, true, WithTimestamps())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer rb.Close()
+	defer os.Remove("/tmp/test_rb_since.mmap")
+
+	// Split the messages in two by time
+	var cut int64
+	for i, msg := range []string{"old 1", "old 2", "new 1", "new 2", "new 3"} {
+		if i == 2 {
+			time.Sleep(time.Millisecond)
+			cut = time.Now().UnixNano()
+		}
+		if ok, err := rb.WriteMsg([]byte(msg)); !ok || err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+
+	msgs, err := rb.ReadSince(cut)
+	if err != nil {
+		t.Fatalf("Failed to read since %d: %v", cut, err)
+	}
+	if len(msgs) != 3 || string(msgs[0]) != "new 1" || string(msgs[2]) != "new 3" {
+		t.Errorf("Expected the three new messages, got %q", msgs)
+	}
+	if msgs, err := rb.ReadSince(time.Now().UnixNano() + int64(time.Hour)); err != nil || len(msgs) != 0 {
+		t.Errorf("Expected no messages from the future, got %q (%v)", msgs, err)
+	}
+
+	// Nothing was consumed
+	if msg, err := rb.ReadMsg(); err != nil || string(msg) != "old 1" {
+		t.Errorf("Expected \"old 1\" still first, got %q (%v)", msg, err)
+	}
+	if msgs, err := rb.ReadSince(0); err != nil || len(msgs) != 4 {
+		t.Errorf("Expected the 4 remaining messages, got %q (%v)", msgs, err)
+	}
+
+	plain, err := NewRingBuffer("/tmp/test_rb_since_plain.mmap", 1024, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer plain.Close()
+	defer os.Remove("/tmp/test_rb_since_plain.mmap")
+	if _, err := plain.ReadSince(0); err != ErrIncompatibleOptions {
+		t.Errorf("Expected ErrIncompatibleOptions without WithTimestamps, got: %v", err)
+	}
+}
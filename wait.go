@@ -0,0 +1,61 @@
+package ringbuffer
+
+import "time"
+
+// WaitReadableOrWritable blocks until the buffer has a message to read or
+// room for a message of writeSize bytes, for a stage that both reads and
+// writes, and reports which conditions hold. It returns (false, false, nil)
+// if neither holds within timeout; zero waits until one does or the buffer
+// is closed. Both are only hints: another reader or writer may act first.
+// Readability ignores reader groups, whose readers track their own offsets.
+func (r *RingBuffer) WaitReadableOrWritable(writeSize int, timeout time.Duration) (readable, writable bool, err error) {
+	if writeSize <= 0 {
+		return false, false, ErrInvalidSize
+	}
+	msgLen := uint32(writeSize) + r.opts.sealOverhead()
+	if msgLen > r.maxPayload() {
+		return false, false, ErrMessageTooLarge
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	r.notifyMu.Lock()
+	defer r.notifyMu.Unlock()
+
+	r.waiters.Add(1)
+	defer r.waiters.Add(-1)
+
+	for {
+		// Checked under notifyMu, so a read or write after this point is
+		// guaranteed to wake us
+		if !r.enter() {
+			return false, false, ErrClosed
+		}
+		head, tail, err := r.loadHeadTail()
+		if err == nil {
+			readable = !r.full.empty(r, head, tail)
+			_, ferr := r.fit(head, tail, msgLen, 0)
+			writable = ferr == nil
+		}
+		r.leave()
+		if err != nil || readable || writable {
+			return readable, writable, err
+		}
+
+		wait := blockPollInterval
+		if !deadline.IsZero() {
+			left := time.Until(deadline)
+			if left <= 0 {
+				return false, false, nil
+			}
+			if left < wait {
+				wait = left
+			}
+		}
+		timer := time.AfterFunc(wait, r.notifySpace)
+		r.spaceFreed.Wait()
+		timer.Stop()
+	}
+}
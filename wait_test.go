@@ -0,0 +1,75 @@
+package ringbuffer
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWaitReadableOrWritable(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_wait.mmap", headerSize+224, true)
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_wait.mmap")
+	defer rb.Close()
+
+	// Empty: only writable
+	if readable, writable, err := rb.WaitReadableOrWritable(100, time.Second); readable || !writable || err != nil {
+		t.Errorf("Expected (false, true, nil) on an empty buffer, got (%v, %v, %v)", readable, writable, err)
+	}
+
+	// Too full for another 100 bytes: only readable
+	if ok, err := rb.WriteMsg(make([]byte, 150)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if readable, writable, err := rb.WaitReadableOrWritable(100, time.Second); !readable || writable || err != nil {
+		t.Errorf("Expected (true, false, nil) on a full buffer, got (%v, %v, %v)", readable, writable, err)
+	}
+
+	if _, _, err := rb.WaitReadableOrWritable(0, time.Second); err != ErrInvalidSize {
+		t.Errorf("Expected ErrInvalidSize, got: %v", err)
+	}
+	if _, _, err := rb.WaitReadableOrWritable(1024, time.Second); err != ErrMessageTooLarge {
+		t.Errorf("Expected ErrMessageTooLarge, got: %v", err)
+	}
+}
+
+func TestWaitReadableOrWritableWakes(t *testing.T) {
+	rb, err := NewRingBuffer("/tmp/test_rb_wait.mmap", headerSize+224, true, WithNoWrapWrites())
+	if err != nil {
+		t.Fatalf("Failed to create ring buffer: %v", err)
+	}
+	defer os.Remove("/tmp/test_rb_wait.mmap")
+	defer rb.Close()
+
+	// Leave an empty buffer whose head is too far along for 150 contiguous
+	// bytes, so neither condition holds
+	if ok, err := rb.WriteMsg(make([]byte, 150)); !ok || err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if _, err := rb.ReadMsg(); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if readable, writable, err := rb.WaitReadableOrWritable(150, 30*time.Millisecond); readable || writable || err != nil {
+		t.Fatalf("Expected (false, false, nil) after the timeout, got (%v, %v, %v)", readable, writable, err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rb.WriteMsg([]byte("x"))
+	}()
+	start := time.Now()
+	readable, writable, err := rb.WaitReadableOrWritable(150, 0)
+	if !readable || writable || err != nil {
+		t.Errorf("Expected (true, false, nil) after the write, got (%v, %v, %v)", readable, writable, err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Waited %v for the write", d)
+	}
+
+	rb.Close()
+	if _, _, err := rb.WaitReadableOrWritable(1, 0); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got: %v", err)
+	}
+}